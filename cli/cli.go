@@ -1,10 +1,18 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"strings"
 	"temp/config"
 	"temp/global"
 	"temp/repositories"
+	"temp/services"
+	"temp/utils"
 
 	"go.uber.org/zap"
 )
@@ -39,6 +47,21 @@ func GetCommands() map[string]Command {
 			Description: "Seed the database with sample data",
 			Handler:     SeedCommand,
 		},
+		"create-admin": {
+			Name:        "create-admin",
+			Description: "Create an admin user or promote an existing one (-email, -name, -password or ADMIN_PASSWORD)",
+			Handler:     CreateAdminCommand,
+		},
+		"breached-filter": {
+			Name:        "breached-filter",
+			Description: "Build the breached-password bloom filter from a list with one password per line (-input, -output, -fp)",
+			Handler:     BreachedFilterCommand,
+		},
+		"import-users": {
+			Name:        "import-users",
+			Description: "Import users with their existing password hashes from a JSON array (-file)",
+			Handler:     ImportUsersCommand,
+		},
 		"help": {
 			Name:        "help",
 			Description: "Show help information",
@@ -72,8 +95,7 @@ func MigrateCommand(cfg *config.Config) error {
 	global.DB = db
 
 	// Run migrations
-	userRepo := repositories.NewUserRepo()
-	if err := userRepo.Migrate(); err != nil {
+	if err := runMigrations(); err != nil {
 		global.Logger.Error("Migration failed", zap.Error(err))
 		return err
 	}
@@ -82,6 +104,42 @@ func MigrateCommand(cfg *config.Config) error {
 	return nil
 }
 
+// runMigrations migrates the tables owned by every repository
+func runMigrations() error {
+	// Roles come first because user_roles references them
+	if err := repositories.NewRoleRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewAdminUserRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewTokenRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewOneTimeTokenRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewRecoveryCodeRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewAPIKeyRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewPasswordHistoryRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewLinkedIdentityRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewOAuthClientRepo().Migrate(); err != nil {
+		return err
+	}
+	if err := repositories.NewOAuthConsentRepo().Migrate(); err != nil {
+		return err
+	}
+	return repositories.NewOAuthTokenRepo().Migrate()
+}
+
 // RollbackCommand rollbacks last migration (placeholder)
 func RollbackCommand(cfg *config.Config) error {
 	global.Logger.Info("Rolling back last migration...")
@@ -111,13 +169,14 @@ func FreshMigrateCommand(cfg *config.Config) error {
 	global.DB = db
 
 	// Drop all tables
-	if err := global.DB.Migrator().DropTable("users"); err != nil {
-		global.Logger.Warn("Failed to drop users table", zap.Error(err))
+	for _, table := range []string{"oauth_refresh_tokens", "oauth_consents", "oauth_clients", "linked_identities", "password_histories", "api_keys", "recovery_codes", "one_time_tokens", "refresh_tokens", "user_roles", "role_permissions", "users", "roles", "permissions"} {
+		if err := global.DB.Migrator().DropTable(table); err != nil {
+			global.Logger.Warn("Failed to drop table", zap.String("table", table), zap.Error(err))
+		}
 	}
 
 	// Re-run migrations
-	userRepo := repositories.NewUserRepo()
-	if err := userRepo.Migrate(); err != nil {
+	if err := runMigrations(); err != nil {
 		global.Logger.Error("Migration failed", zap.Error(err))
 		return err
 	}
@@ -137,13 +196,176 @@ func SeedCommand(cfg *config.Config) error {
 	}
 	global.DB = db
 
-	// Add seeding logic here
-	// Example: Create admin user, sample data, etc.
+	// Create the built-in roles and permissions
+	if err := runMigrations(); err != nil {
+		return err
+	}
+	if err := newRoleService().EnsureDefaultRoles(); err != nil {
+		return fmt.Errorf("failed to create default roles: %w", err)
+	}
 
 	global.Logger.Info("Database seeding completed successfully")
 	return nil
 }
 
+// CreateAdminCommand creates the first admin. Arguments follow the command
+// name; the password may be passed in ADMIN_PASSWORD to keep it out of the
+// shell history.
+func CreateAdminCommand(cfg *config.Config) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the admin")
+	name := fs.String("name", "Administrator", "name of a new admin")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password of a new admin")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("create-admin requires -email")
+	}
+
+	// Initialize database
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	global.DB = db
+
+	if err := runMigrations(); err != nil {
+		return err
+	}
+	roles := newRoleService()
+	if err := roles.EnsureDefaultRoles(); err != nil {
+		return fmt.Errorf("failed to create default roles: %w", err)
+	}
+
+	// A new user needs a password; promoting an existing one does not
+	if _, err := repositories.NewAdminUserRepo().FindByEmail(*email); errors.Is(err, repositories.ErrUserNotFound) && len(*password) < 6 {
+		return errors.New("create-admin requires a -password of at least 6 characters for a new user")
+	}
+
+	u, err := roles.CreateAdmin(*name, *email, *password)
+	if err != nil {
+		return fmt.Errorf("failed to create admin: %w", err)
+	}
+
+	global.Logger.Info("Admin user ready", zap.Uint("user_id", u.ID), zap.String("email", u.Email))
+	return nil
+}
+
+// BreachedFilterCommand builds the bloom filter that password_policy.breached_filter_path
+// points to. The list is read twice: once to size the filter and once to fill it.
+func BreachedFilterCommand(cfg *config.Config) error {
+	fs := flag.NewFlagSet("breached-filter", flag.ContinueOnError)
+	input := fs.String("input", "", "breached passwords, one per line")
+	output := fs.String("output", "breached-passwords.bloom", "filter file to write")
+	fpRate := fs.Float64("fp", 0.001, "false positive rate")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("breached-filter requires -input")
+	}
+	if *fpRate <= 0 || *fpRate >= 1 {
+		return errors.New("breached-filter requires a -fp between 0 and 1")
+	}
+
+	count := 0
+	if err := eachLine(*input, func(string) { count++ }); err != nil {
+		return err
+	}
+	filter := utils.NewBloomFilter(count, *fpRate)
+	if err := eachLine(*input, filter.Add); err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := filter.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	global.Logger.Info("Breached password filter written", zap.String("path", *output), zap.Int("passwords", count))
+	return nil
+}
+
+// ImportUsersCommand imports users exported from another system. The file
+// holds a JSON array of {"name", "email", "password_hash", "email_verified"}
+// objects; records that are invalid or already registered are skipped.
+func ImportUsersCommand(cfg *config.Config) error {
+	fs := flag.NewFlagSet("import-users", flag.ContinueOnError)
+	path := fs.String("file", "", "JSON file with the users to import")
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("import-users requires -file")
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	var records []services.UserImport
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", *path, err)
+	}
+
+	// Initialize database
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	global.DB = db
+
+	if err := runMigrations(); err != nil {
+		return err
+	}
+	roles := newRoleService()
+	if err := roles.EnsureDefaultRoles(); err != nil {
+		return fmt.Errorf("failed to create default roles: %w", err)
+	}
+
+	// Imported hashes above the configured work factors are refused
+	utils.SetHashCostLimits(utils.HashCostLimits(cfg.PasswordHashing.Limits))
+	report, err := services.NewUserImportService(repositories.NewAdminUserRepo(), roles).Import(records)
+	if err != nil {
+		return fmt.Errorf("failed to import users: %w", err)
+	}
+	for _, skipped := range report.Skipped {
+		global.Logger.Warn("User skipped", zap.Int("index", skipped.Index), zap.String("email", skipped.Email), zap.String("reason", skipped.Error))
+	}
+
+	global.Logger.Info("Users imported", zap.Int("created", report.Created), zap.Int("skipped", len(report.Skipped)))
+	return nil
+}
+
+// eachLine calls fn with every non-empty line of a file
+func eachLine(path string, fn func(string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			fn(line)
+		}
+	}
+	return scanner.Err()
+}
+
+func newRoleService() *services.RoleService {
+	return services.NewRoleService(repositories.NewRoleRepo(), repositories.NewAdminUserRepo())
+}
+
 // HelpCommand displays help information
 func HelpCommand(cfg *config.Config) error {
 	fmt.Println("Available Commands:")
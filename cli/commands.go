@@ -5,7 +5,6 @@ import (
 	"os"
 	"temp/config"
 	"temp/global"
-	"temp/repositories"
 )
 
 // ParseAndExecute parses CLI arguments and executes commands. Returns (handled, error)
@@ -15,13 +14,15 @@ func ParseAndExecute(cfg *config.Config) (bool, error) {
 	}
 	switch os.Args[1] {
 	case "migrate":
-		userRepo := repositories.NewUserRepo()
-		if err := userRepo.Migrate(); err != nil {
+		if err := runMigrations(); err != nil {
 			return true, fmt.Errorf("migration failed: %w", err)
 		}
 		global.Logger.Info("Migration completed successfully.")
 		return true, nil
 	default:
+		if _, exists := GetCommands()[os.Args[1]]; exists {
+			return true, ExecuteCommand(os.Args[1], cfg)
+		}
 		fmt.Println("Unknown command:", os.Args[1])
 		return true, nil
 	}
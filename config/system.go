@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -31,8 +32,20 @@ type Config struct {
 	} `mapstructure:"redis"`
 
 	JWT struct {
-		Secret          string `mapstructure:"secret"`
-		ExpirationHours int    `mapstructure:"expiration_hours"`
+		Secret                  string `mapstructure:"secret"`
+		AccessExpirationMinutes int    `mapstructure:"access_expiration_minutes"`
+		RefreshExpirationHours  int    `mapstructure:"refresh_expiration_hours"`
+		// Signing keys (RS256 and EdDSA are read from KeysDir, HS256 uses Secret)
+		Algorithm         string `mapstructure:"algorithm"`
+		KeysDir           string `mapstructure:"keys_dir"`
+		KeyRotationHours  int    `mapstructure:"key_rotation_hours"`
+		KeyRetentionHours int    `mapstructure:"key_retention_hours"`
+		KeyReloadMinutes  int    `mapstructure:"key_reload_minutes"`
+		// Validation of incoming tokens
+		Issuer            string   `mapstructure:"issuer"`
+		Audience          string   `mapstructure:"audience"`
+		ClockSkewSeconds  int      `mapstructure:"clock_skew_seconds"`
+		AllowedAlgorithms []string `mapstructure:"allowed_algorithms"`
 	} `mapstructure:"jwt"`
 
 	Email struct {
@@ -42,8 +55,124 @@ type Config struct {
 		Username string `mapstructure:"username"`
 		Password string `mapstructure:"password"`
 		From     string `mapstructure:"from"`
+		// Base URL of the frontend that links in emails point to
+		AppURL string `mapstructure:"app_url"`
 	} `mapstructure:"email"`
 
+	Auth struct {
+		RequireEmailVerification  bool `mapstructure:"require_email_verification"`
+		VerificationTokenTTLHours int  `mapstructure:"verification_token_ttl_hours"`
+		PasswordResetTTLMinutes   int  `mapstructure:"password_reset_ttl_minutes"`
+		// Two-factor authentication
+		MFAIssuer              string `mapstructure:"mfa_issuer"`
+		MFAChallengeTTLMinutes int    `mapstructure:"mfa_challenge_ttl_minutes"`
+		// How logins hand out the session: bearer returns tokens in the
+		// response body, cookie sets a session cookie instead and both
+		// does both. The Authorization header is accepted in every mode.
+		Mode          string        `mapstructure:"mode"`
+		SessionCookie SessionCookie `mapstructure:"session_cookie"`
+		// Sensitive operations such as changing the password require a
+		// login or reauthentication within this many minutes
+		RecentAuthMaxAgeMinutes int `mapstructure:"recent_auth_max_age_minutes"`
+	} `mapstructure:"auth"`
+
+	// Passwordless login through emailed single-use links
+	MagicLink struct {
+		TTLMinutes    int `mapstructure:"ttl_minutes"`
+		MaxRequests   int `mapstructure:"max_requests"`
+		WindowMinutes int `mapstructure:"window_minutes"`
+		// Only the IP address and user agent that requested a link can use it
+		BindClient bool `mapstructure:"bind_client"`
+	} `mapstructure:"magic_link"`
+
+	// Login through external identity providers, keyed by the provider name
+	// used in the /auth/{provider} routes
+	OAuth struct {
+		StateTTLMinutes int                      `mapstructure:"state_ttl_minutes"`
+		Providers       map[string]OAuthProvider `mapstructure:"providers"`
+	} `mapstructure:"oauth"`
+
+	// OAuth2 authorization server for internal applications. Tokens are
+	// signed with the JWT keys and jwt.issuer is the issuer URL published
+	// in the discovery document.
+	OAuthServer struct {
+		Enabled                     bool `mapstructure:"enabled"`
+		AuthorizationCodeTTLSeconds int  `mapstructure:"authorization_code_ttl_seconds"`
+		AccessTokenTTLMinutes       int  `mapstructure:"access_token_ttl_minutes"`
+		RefreshTokenTTLHours        int  `mapstructure:"refresh_token_ttl_hours"`
+	} `mapstructure:"oauth_server"`
+
+	// Email invitations to join an organization
+	Organizations struct {
+		InvitationTTLHours int `mapstructure:"invitation_ttl_hours"`
+	} `mapstructure:"organizations"`
+
+	// Hashing of new passwords. Stored hashes of every supported algorithm
+	// keep working and are rehashed at the next login when the algorithm or
+	// parameters here have changed.
+	PasswordHashing struct {
+		Algorithm string `mapstructure:"algorithm"`
+		Argon2id  struct {
+			MemoryKiB   uint32 `mapstructure:"memory_kib"`
+			Iterations  uint32 `mapstructure:"iterations"`
+			Parallelism uint8  `mapstructure:"parallelism"`
+		} `mapstructure:"argon2id"`
+		Bcrypt struct {
+			Cost int `mapstructure:"cost"`
+		} `mapstructure:"bcrypt"`
+		Scrypt struct {
+			LogN uint8 `mapstructure:"ln"`
+			R    int   `mapstructure:"r"`
+			P    int   `mapstructure:"p"`
+		} `mapstructure:"scrypt"`
+		// Highest work factors accepted in stored and imported hashes
+		Limits struct {
+			Argon2idMemoryKiB   uint32 `mapstructure:"argon2id_memory_kib"`
+			Argon2idIterations  uint32 `mapstructure:"argon2id_iterations"`
+			Argon2idParallelism uint8  `mapstructure:"argon2id_parallelism"`
+			BcryptCost          int    `mapstructure:"bcrypt_cost"`
+			ScryptLogN          uint8  `mapstructure:"scrypt_ln"`
+			ScryptR             int    `mapstructure:"scrypt_r"`
+			ScryptP             int    `mapstructure:"scrypt_p"`
+			PBKDF2Iterations    int    `mapstructure:"pbkdf2_iterations"`
+		} `mapstructure:"limits"`
+	} `mapstructure:"password_hashing"`
+
+	// Rules for new passwords
+	PasswordPolicy struct {
+		MinLength          int  `mapstructure:"min_length"`
+		MaxLength          int  `mapstructure:"max_length"`
+		RequireUpper       bool `mapstructure:"require_upper"`
+		RequireLower       bool `mapstructure:"require_lower"`
+		RequireDigit       bool `mapstructure:"require_digit"`
+		RequireSymbol      bool `mapstructure:"require_symbol"`
+		History            int  `mapstructure:"history"`
+		RejectPersonalInfo bool `mapstructure:"reject_personal_info"`
+		// Bloom filter of breached passwords built with the breached-filter
+		// command; empty disables the check
+		BreachedFilterPath string `mapstructure:"breached_filter_path"`
+	} `mapstructure:"password_policy"`
+
+	// Brute-force protection of the login. Failures are counted per account
+	// and per client IP within a sliding window.
+	Lockout struct {
+		AccountThreshold      int `mapstructure:"account_threshold"`
+		IPThreshold           int `mapstructure:"ip_threshold"`
+		WindowMinutes         int `mapstructure:"window_minutes"`
+		LockoutMinutes        int `mapstructure:"lockout_minutes"`
+		DelayAfter            int `mapstructure:"delay_after"`
+		BaseDelaySeconds      int `mapstructure:"base_delay_seconds"`
+		MaxDelaySeconds       int `mapstructure:"max_delay_seconds"`
+		UnlockTokenTTLMinutes int `mapstructure:"unlock_token_ttl_minutes"`
+	} `mapstructure:"lockout"`
+
+	// Request rate limits. Routes refer to policies by name; see
+	// routes.NewRouter for which policy guards which routes.
+	RateLimit struct {
+		Enabled  bool                       `mapstructure:"enabled"`
+		Policies map[string]RateLimitPolicy `mapstructure:"policies"`
+	} `mapstructure:"rate_limit"`
+
 	CORS struct {
 		Enabled        bool     `mapstructure:"enabled"`
 		AllowedOrigins []string `mapstructure:"allowed_origins"`
@@ -57,6 +186,54 @@ type Config struct {
 	} `mapstructure:"logging"`
 }
 
+// RateLimitPolicy limits requests to Limit per WindowSeconds. Algorithm is
+// sliding_window or token_bucket and Key is ip, user or api_key.
+type RateLimitPolicy struct {
+	Algorithm     string `mapstructure:"algorithm"`
+	Limit         int    `mapstructure:"limit"`
+	WindowSeconds int    `mapstructure:"window_seconds"`
+	Key           string `mapstructure:"key"`
+}
+
+// OAuthProvider configures an OAuth2 or OpenID Connect identity provider.
+// OpenID Connect providers only need an Issuer; the endpoints are
+// discovered. Plain OAuth2 providers need AuthURL, TokenURL and
+// UserInfoURL, and Claims names the user info fields to read.
+type OAuthProvider struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	Issuer       string   `mapstructure:"issuer"`
+	AuthURL      string   `mapstructure:"auth_url"`
+	TokenURL     string   `mapstructure:"token_url"`
+	UserInfoURL  string   `mapstructure:"userinfo_url"`
+	Claims       struct {
+		Subject       string `mapstructure:"subject"`
+		Email         string `mapstructure:"email"`
+		EmailVerified string `mapstructure:"email_verified"`
+		Name          string `mapstructure:"name"`
+	} `mapstructure:"claims"`
+	// Treat every email from the provider as verified, for providers that
+	// only hand out verified addresses but do not say so
+	TrustEmail bool `mapstructure:"trust_email"`
+}
+
+// SessionCookie configures the cookies of the cookie auth mode. Requests
+// authenticated by the cookie that are not GET, HEAD or OPTIONS must send
+// the value of the CSRF cookie in CSRFHeader. SameSite is lax, strict or
+// none.
+type SessionCookie struct {
+	Name           string `mapstructure:"name"`
+	CSRFCookieName string `mapstructure:"csrf_cookie_name"`
+	CSRFHeader     string `mapstructure:"csrf_header"`
+	Domain         string `mapstructure:"domain"`
+	Path           string `mapstructure:"path"`
+	Secure         bool   `mapstructure:"secure"`
+	SameSite       string `mapstructure:"same_site"`
+}
+
 // LoadConfig reads configuration from file
 func LoadConfig(path string) (*Config, error) {
 	v := viper.New()
@@ -72,8 +249,12 @@ func LoadConfig(path string) (*Config, error) {
 	_ = v.BindEnv("db.dsn", "DB_DSN")
 	_ = v.BindEnv("redis.password", "REDIS_PASSWORD")
 	_ = v.BindEnv("jwt.secret", "JWT_SECRET")
+	_ = v.BindEnv("jwt.keys_dir", "JWT_KEYS_DIR")
 	_ = v.BindEnv("email.password", "EMAIL_PASSWORD")
 
+	// Session cookies are only sent over HTTPS unless turned off explicitly
+	v.SetDefault("auth.session_cookie.secure", true)
+
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
@@ -92,8 +273,305 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.DB.Driver = "mysql"
 	}
 
-	if cfg.JWT.ExpirationHours == 0 {
-		cfg.JWT.ExpirationHours = 24
+	if cfg.JWT.AccessExpirationMinutes == 0 {
+		cfg.JWT.AccessExpirationMinutes = 15
+	}
+
+	if cfg.JWT.RefreshExpirationHours == 0 {
+		cfg.JWT.RefreshExpirationHours = 720
+	}
+
+	if cfg.JWT.Algorithm == "" {
+		cfg.JWT.Algorithm = "HS256"
+	}
+
+	if cfg.JWT.KeysDir == "" {
+		cfg.JWT.KeysDir = "keys"
+	}
+
+	if cfg.JWT.KeyRetentionHours == 0 {
+		cfg.JWT.KeyRetentionHours = 24
+	}
+
+	if cfg.JWT.KeyReloadMinutes == 0 {
+		cfg.JWT.KeyReloadMinutes = 5
+	}
+
+	if cfg.JWT.Issuer == "" {
+		cfg.JWT.Issuer = "user-management-api"
+	}
+
+	if cfg.JWT.Audience == "" {
+		cfg.JWT.Audience = "user-management-api"
+	}
+
+	if len(cfg.JWT.AllowedAlgorithms) == 0 {
+		cfg.JWT.AllowedAlgorithms = []string{cfg.JWT.Algorithm}
+	}
+
+	if cfg.Email.AppURL == "" {
+		cfg.Email.AppURL = "http://localhost:3000"
+	}
+
+	if cfg.Auth.VerificationTokenTTLHours == 0 {
+		cfg.Auth.VerificationTokenTTLHours = 24
+	}
+
+	if cfg.Auth.PasswordResetTTLMinutes == 0 {
+		cfg.Auth.PasswordResetTTLMinutes = 30
+	}
+
+	if cfg.Auth.MFAIssuer == "" {
+		cfg.Auth.MFAIssuer = "User Management API"
+	}
+
+	if cfg.Auth.MFAChallengeTTLMinutes == 0 {
+		cfg.Auth.MFAChallengeTTLMinutes = 5
+	}
+
+	if cfg.Auth.RecentAuthMaxAgeMinutes == 0 {
+		cfg.Auth.RecentAuthMaxAgeMinutes = 10
+	}
+
+	if cfg.Auth.Mode == "" {
+		cfg.Auth.Mode = "bearer"
+	}
+	switch cfg.Auth.Mode {
+	case "bearer", "cookie", "both":
+	default:
+		return nil, fmt.Errorf("auth.mode: unknown mode %q", cfg.Auth.Mode)
+	}
+
+	if cfg.Auth.SessionCookie.Name == "" {
+		cfg.Auth.SessionCookie.Name = "session"
+	}
+
+	if cfg.Auth.SessionCookie.CSRFCookieName == "" {
+		cfg.Auth.SessionCookie.CSRFCookieName = "csrf_token"
+	}
+
+	if cfg.Auth.SessionCookie.CSRFHeader == "" {
+		cfg.Auth.SessionCookie.CSRFHeader = "X-CSRF-Token"
+	}
+
+	if cfg.Auth.SessionCookie.Path == "" {
+		cfg.Auth.SessionCookie.Path = "/"
+	}
+
+	if cfg.Auth.SessionCookie.SameSite == "" {
+		cfg.Auth.SessionCookie.SameSite = "lax"
+	}
+	switch cfg.Auth.SessionCookie.SameSite {
+	case "lax", "strict":
+	case "none":
+		// Browsers drop SameSite=None cookies without Secure
+		if !cfg.Auth.SessionCookie.Secure {
+			return nil, fmt.Errorf("auth.session_cookie: same_site none requires secure")
+		}
+	default:
+		return nil, fmt.Errorf("auth.session_cookie.same_site: unknown value %q", cfg.Auth.SessionCookie.SameSite)
+	}
+
+	if cfg.MagicLink.TTLMinutes == 0 {
+		cfg.MagicLink.TTLMinutes = 15
+	}
+
+	if cfg.MagicLink.MaxRequests == 0 {
+		cfg.MagicLink.MaxRequests = 3
+	}
+
+	if cfg.MagicLink.WindowMinutes == 0 {
+		cfg.MagicLink.WindowMinutes = 15
+	}
+
+	if cfg.OAuth.StateTTLMinutes == 0 {
+		cfg.OAuth.StateTTLMinutes = 10
+	}
+
+	for name, provider := range cfg.OAuth.Providers {
+		if !provider.Enabled {
+			continue
+		}
+		if provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("oauth.providers.%s: client_id and redirect_url are required", name)
+		}
+		if provider.Issuer == "" && (provider.AuthURL == "" || provider.TokenURL == "" || provider.UserInfoURL == "") {
+			return nil, fmt.Errorf("oauth.providers.%s: issuer, or auth_url, token_url and userinfo_url are required", name)
+		}
+	}
+
+	if cfg.OAuthServer.AuthorizationCodeTTLSeconds == 0 {
+		cfg.OAuthServer.AuthorizationCodeTTLSeconds = 60
+	}
+
+	if cfg.OAuthServer.AccessTokenTTLMinutes == 0 {
+		cfg.OAuthServer.AccessTokenTTLMinutes = 15
+	}
+
+	if cfg.OAuthServer.RefreshTokenTTLHours == 0 {
+		cfg.OAuthServer.RefreshTokenTTLHours = 720
+	}
+
+	if cfg.OAuthServer.Enabled {
+		// Client applications verify ID tokens with the published keys, so
+		// the issuer must be a URL and tokens must not use a shared secret
+		if issuer, err := url.Parse(cfg.JWT.Issuer); err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+			return nil, fmt.Errorf("oauth_server: jwt.issuer must be the URL of this service")
+		}
+		if cfg.JWT.Algorithm == "HS256" {
+			return nil, fmt.Errorf("oauth_server: jwt.algorithm must be an asymmetric algorithm")
+		}
+	}
+
+	if cfg.Organizations.InvitationTTLHours == 0 {
+		cfg.Organizations.InvitationTTLHours = 168
+	}
+
+	if cfg.PasswordHashing.Algorithm == "" {
+		cfg.PasswordHashing.Algorithm = "argon2id"
+	}
+	switch cfg.PasswordHashing.Algorithm {
+	case "argon2id", "bcrypt", "scrypt":
+	default:
+		return nil, fmt.Errorf("password_hashing.algorithm: unknown algorithm %q", cfg.PasswordHashing.Algorithm)
+	}
+
+	if cfg.PasswordHashing.Argon2id.MemoryKiB == 0 {
+		cfg.PasswordHashing.Argon2id.MemoryKiB = 19456
+	}
+
+	if cfg.PasswordHashing.Argon2id.Iterations == 0 {
+		cfg.PasswordHashing.Argon2id.Iterations = 2
+	}
+
+	if cfg.PasswordHashing.Argon2id.Parallelism == 0 {
+		cfg.PasswordHashing.Argon2id.Parallelism = 1
+	}
+
+	if cfg.PasswordHashing.Bcrypt.Cost == 0 {
+		cfg.PasswordHashing.Bcrypt.Cost = 12
+	}
+
+	if cfg.PasswordHashing.Scrypt.LogN == 0 {
+		cfg.PasswordHashing.Scrypt.LogN = 15
+	}
+
+	if cfg.PasswordHashing.Scrypt.R == 0 {
+		cfg.PasswordHashing.Scrypt.R = 8
+	}
+
+	if cfg.PasswordHashing.Scrypt.P == 0 {
+		cfg.PasswordHashing.Scrypt.P = 1
+	}
+
+	limits := &cfg.PasswordHashing.Limits
+	if limits.Argon2idMemoryKiB == 0 {
+		limits.Argon2idMemoryKiB = 65536
+	}
+
+	if limits.Argon2idIterations == 0 {
+		limits.Argon2idIterations = 10
+	}
+
+	if limits.Argon2idParallelism == 0 {
+		limits.Argon2idParallelism = 8
+	}
+
+	if limits.BcryptCost == 0 {
+		limits.BcryptCost = 14
+	}
+
+	if limits.ScryptLogN == 0 {
+		limits.ScryptLogN = 17
+	}
+
+	if limits.ScryptR == 0 {
+		limits.ScryptR = 16
+	}
+
+	if limits.ScryptP == 0 {
+		limits.ScryptP = 4
+	}
+
+	if limits.PBKDF2Iterations == 0 {
+		limits.PBKDF2Iterations = 1200000
+	}
+
+	// New hashes must stay verifiable
+	switch {
+	case cfg.PasswordHashing.Argon2id.MemoryKiB > limits.Argon2idMemoryKiB,
+		cfg.PasswordHashing.Argon2id.Iterations > limits.Argon2idIterations,
+		cfg.PasswordHashing.Argon2id.Parallelism > limits.Argon2idParallelism:
+		return nil, fmt.Errorf("password_hashing.argon2id: parameters exceed password_hashing.limits")
+	case cfg.PasswordHashing.Bcrypt.Cost > limits.BcryptCost:
+		return nil, fmt.Errorf("password_hashing.bcrypt: cost exceeds password_hashing.limits")
+	case cfg.PasswordHashing.Scrypt.LogN > limits.ScryptLogN,
+		cfg.PasswordHashing.Scrypt.R > limits.ScryptR,
+		cfg.PasswordHashing.Scrypt.P > limits.ScryptP:
+		return nil, fmt.Errorf("password_hashing.scrypt: parameters exceed password_hashing.limits")
+	}
+
+	if cfg.PasswordPolicy.MinLength == 0 {
+		cfg.PasswordPolicy.MinLength = 8
+	}
+
+	// bcrypt refuses passwords longer than 72 bytes
+	if cfg.PasswordPolicy.MaxLength == 0 {
+		cfg.PasswordPolicy.MaxLength = 128
+		if cfg.PasswordHashing.Algorithm == "bcrypt" {
+			cfg.PasswordPolicy.MaxLength = 72
+		}
+	}
+
+	if cfg.Lockout.AccountThreshold == 0 {
+		cfg.Lockout.AccountThreshold = 5
+	}
+
+	if cfg.Lockout.IPThreshold == 0 {
+		cfg.Lockout.IPThreshold = 20
+	}
+
+	if cfg.Lockout.WindowMinutes == 0 {
+		cfg.Lockout.WindowMinutes = 15
+	}
+
+	if cfg.Lockout.LockoutMinutes == 0 {
+		cfg.Lockout.LockoutMinutes = 15
+	}
+
+	if cfg.Lockout.DelayAfter == 0 {
+		cfg.Lockout.DelayAfter = 3
+	}
+
+	if cfg.Lockout.BaseDelaySeconds == 0 {
+		cfg.Lockout.BaseDelaySeconds = 1
+	}
+
+	if cfg.Lockout.MaxDelaySeconds == 0 {
+		cfg.Lockout.MaxDelaySeconds = 30
+	}
+
+	if cfg.Lockout.UnlockTokenTTLMinutes == 0 {
+		cfg.Lockout.UnlockTokenTTLMinutes = 60
+	}
+
+	for name, policy := range cfg.RateLimit.Policies {
+		if policy.Algorithm == "" {
+			policy.Algorithm = "sliding_window"
+		}
+		if policy.Key == "" {
+			policy.Key = "ip"
+		}
+		if policy.Algorithm != "sliding_window" && policy.Algorithm != "token_bucket" {
+			return nil, fmt.Errorf("rate_limit.policies.%s: unknown algorithm %q", name, policy.Algorithm)
+		}
+		if policy.Key != "ip" && policy.Key != "user" && policy.Key != "api_key" {
+			return nil, fmt.Errorf("rate_limit.policies.%s: unknown key %q", name, policy.Key)
+		}
+		if policy.Limit <= 0 || policy.WindowSeconds <= 0 {
+			return nil, fmt.Errorf("rate_limit.policies.%s: limit and window_seconds must be positive", name)
+		}
+		cfg.RateLimit.Policies[name] = policy
 	}
 
 	if cfg.Logging.Level == "" {
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	service  services.AdminServiceInterface
+	importer services.UserImportServiceInterface
+}
+
+func NewAdminHandler(s services.AdminServiceInterface, importer services.UserImportServiceInterface) *AdminHandler {
+	return &AdminHandler{service: s, importer: importer}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List users with filters and sorting. Pages are selected with offset or with the next_cursor of the previous page, not both. Requires the users:read permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param email query string false "Email contains"
+// @Param name query string false "Name contains"
+// @Param status query string false "Account status" Enums(active, disabled, unverified)
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Param sort query string false "Sort field: id, email, name or created_at, prefixed with - for descending order (default -created_at)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of users to skip"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Success 200 {object} object{users=[]object{id=int,name=string,email=string,email_verified_at=string,roles=[]string,disabled_at=string,password_reset_required=bool,created_at=string},total=int,next_cursor=string} "Users"
+// @Failure 400 {object} object{error=string} "Bad request - invalid filter, sort or cursor"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	var req struct {
+		Email         string    `form:"email"`
+		Name          string    `form:"name"`
+		Status        string    `form:"status" binding:"omitempty,oneof=active disabled unverified"`
+		CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+		CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+		Sort          string    `form:"sort"`
+		Limit         int       `form:"limit" binding:"omitempty,min=1,max=100"`
+		Offset        int       `form:"offset" binding:"omitempty,min=0"`
+		Cursor        string    `form:"cursor"`
+	}
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	params := services.UserListParams{
+		Email:  req.Email,
+		Name:   req.Name,
+		Status: req.Status,
+		Sort:   req.Sort,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+		Cursor: req.Cursor,
+	}
+	if !req.CreatedAfter.IsZero() {
+		params.CreatedAfter = &req.CreatedAfter
+	}
+	if !req.CreatedBefore.IsZero() {
+		params.CreatedBefore = &req.CreatedBefore
+	}
+
+	page, err := h.service.ListUsers(params)
+	if err != nil {
+		h.handleError(c, "Failed to list users", err)
+		return
+	}
+
+	users := make([]gin.H, 0, len(page.Users))
+	for i := range page.Users {
+		users = append(users, adminUserResponse(&page.Users[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "total": page.Total, "next_cursor": page.NextCursor})
+}
+
+// GetUser godoc
+// @Summary Get a user
+// @Description Get a user with roles and account status. Requires the users:read permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{id=int,name=string,email=string,email_verified_at=string,roles=[]string,mfa_enabled=bool,disabled_at=string,password_reset_required=bool,created_at=string,updated_at=string} "User"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.GetUser(id)
+	if err != nil {
+		h.handleError(c, "Failed to load user", err)
+		return
+	}
+	c.JSON(http.StatusOK, adminUserResponse(u))
+}
+
+// DisableUser godoc
+// @Summary Disable a user
+// @Description Block logins of a user and end all their sessions. API keys of disabled users stop working. Requires the users:write permission and a recent login or /reauthenticate.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{message=string,user=object{id=int,email=string,disabled_at=string}} "User disabled"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id or own account"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{id}/disable [post]
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.DisableUser(principal.UserID, id)
+	if err != nil {
+		h.handleError(c, "Failed to disable user", err)
+		return
+	}
+
+	global.Logger.Info("User disabled", zap.Uint("admin_id", principal.UserID), zap.Uint("user_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "user disabled", "user": adminUserResponse(u)})
+}
+
+// EnableUser godoc
+// @Summary Enable a user
+// @Description Allow a disabled user to log in again. Requires the users:write permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{message=string,user=object{id=int,email=string,disabled_at=string}} "User enabled"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{id}/enable [post]
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.EnableUser(id)
+	if err != nil {
+		h.handleError(c, "Failed to enable user", err)
+		return
+	}
+
+	global.Logger.Info("User enabled", zap.Uint("user_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "user enabled", "user": adminUserResponse(u)})
+}
+
+// ForcePasswordReset godoc
+// @Summary Force a password reset
+// @Description End all sessions of a user, refuse password logins until a new password is set and email a reset link. Requires the users:write permission and a recent login or /reauthenticate.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{message=string,user=object{id=int,email=string,password_reset_required=bool}} "Password reset required"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{id}/force-password-reset [post]
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.ForcePasswordReset(id)
+	if err != nil {
+		h.handleError(c, "Failed to force password reset", err)
+		return
+	}
+
+	global.Logger.Info("Password reset forced", zap.Uint("user_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "password reset required", "user": adminUserResponse(u)})
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description End all sessions of a user and delete the account with its tokens, API keys and role assignments. Requires the users:write permission and a recent login or /reauthenticate.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{message=string} "User deleted"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id or own account"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteUser(principal.UserID, id); err != nil {
+		h.handleError(c, "Failed to delete user", err)
+		return
+	}
+
+	global.Logger.Info("User deleted", zap.Uint("admin_id", principal.UserID), zap.Uint("user_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted"})
+}
+
+// ImportUsers godoc
+// @Summary Import users
+// @Description Create users from another system with their existing password hashes. Supported hashes are argon2id, bcrypt and scrypt as well as the legacy pbkdf2_sha256$ and sha1$ formats; outdated hashes are replaced at the user's first successful login. Invalid records and registered emails are skipped and reported. Requires the users:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{users=[]object{name=string,email=string,password_hash=string,email_verified=bool}} true "Users to import (at most 1000)"
+// @Success 200 {object} object{created=int,skipped=[]object{index=int,email=string,error=string}} "Import report"
+// @Failure 400 {object} object{error=string} "Bad request - invalid input"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/users/import [post]
+func (h *AdminHandler) ImportUsers(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Users []services.UserImport `json:"users" binding:"required,min=1,max=1000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.importer.Import(req.Users)
+	if err != nil {
+		h.handleError(c, "Failed to import users", err)
+		return
+	}
+
+	global.Logger.Info("Users imported", zap.Uint("admin_id", principal.UserID),
+		zap.Int("created", report.Created), zap.Int("skipped", len(report.Skipped)))
+	c.JSON(http.StatusOK, report)
+}
+
+// handleError maps admin service errors to responses
+func (h *AdminHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidUserQuery), errors.Is(err, services.ErrCannotModifySelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		global.Logger.Error(msg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+// userIDParam parses the :id path parameter and answers 400 if it is invalid
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// adminUserResponse extends the profile with the account status
+func adminUserResponse(u *models.User) gin.H {
+	res := userResponse(u)
+	res["disabled_at"] = u.DisabledAt
+	res["password_reset_required"] = u.PasswordResetRequired
+	return res
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminHandler_ListUsers(t *testing.T) {
+	createdAfter := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockAdminService)
+		expectedStatus int
+	}{
+		{
+			name:  "filtered page",
+			query: "?email=example&status=disabled&created_after=2024-05-01T00:00:00Z&sort=email&limit=10",
+			setupMock: func(mockService *MockAdminService) {
+				mockService.On("ListUsers", mock.MatchedBy(func(p services.UserListParams) bool {
+					return p.Email == "example" && p.Status == "disabled" && p.Sort == "email" && p.Limit == 10 &&
+						p.CreatedAfter != nil && p.CreatedAfter.Equal(createdAfter) && p.CreatedBefore == nil
+				})).Return(&services.UserPage{Users: []models.User{{ID: 2, Email: "b@example.com"}}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "invalid sort",
+			query: "?sort=password",
+			setupMock: func(mockService *MockAdminService) {
+				mockService.On("ListUsers", mock.Anything).Return(nil, services.ErrInvalidUserQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown status",
+			query:          "?status=deleted",
+			setupMock:      func(mockService *MockAdminService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAdminService{}
+			tt.setupMock(mockService)
+
+			handler := NewAdminHandler(mockService, &MockUserImportService{})
+			req := testutils.CreateTestRequest("GET", "/admin/users"+tt.query, nil)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.ListUsers(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Users []map[string]interface{} `json:"users"`
+					Total int64                    `json:"total"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Len(t, response.Users, 1)
+				assert.Equal(t, int64(1), response.Total)
+				assert.Contains(t, response.Users[0], "disabled_at")
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminHandler_DisableUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		setupMock      func(*MockAdminService)
+		expectedStatus int
+	}{
+		{
+			name: "user disabled",
+			id:   "2",
+			setupMock: func(mockService *MockAdminService) {
+				disabledAt := time.Now()
+				mockService.On("DisableUser", uint(1), uint(2)).Return(&models.User{ID: 2, DisabledAt: &disabledAt}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "own account",
+			id:   "1",
+			setupMock: func(mockService *MockAdminService) {
+				mockService.On("DisableUser", uint(1), uint(1)).Return(nil, services.ErrCannotModifySelf)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "user not found",
+			id:   "9",
+			setupMock: func(mockService *MockAdminService) {
+				mockService.On("DisableUser", uint(1), uint(9)).Return(nil, repositories.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "abc",
+			setupMock:      func(mockService *MockAdminService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAdminService{}
+			tt.setupMock(mockService)
+
+			handler := NewAdminHandler(mockService, &MockUserImportService{})
+			req := testutils.CreateTestRequest("POST", "/admin/users/"+tt.id+"/disable", nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			testutils.SetUserInContext(c, 1)
+
+			handler.DisableUser(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminHandler_DeleteUser(t *testing.T) {
+	mockService := &MockAdminService{}
+	mockService.On("DeleteUser", uint(1), uint(2)).Return(nil)
+
+	handler := NewAdminHandler(mockService, &MockUserImportService{})
+	req := testutils.CreateTestRequest("DELETE", "/admin/users/2", nil)
+	c, w := testutils.CreateTestContext(req)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	testutils.SetUserInContext(c, 1)
+
+	handler.DeleteUser(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminHandler_ImportUsers(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           interface{}
+		setupMock      func(*MockUserImportService)
+		expectedStatus int
+	}{
+		{
+			name: "users imported",
+			body: map[string]interface{}{"users": []map[string]interface{}{
+				{"name": "Ann", "email": "ann@example.com", "password_hash": "sha1$abc12$952b96a80331ff38f3696eb26bdb4b21da124835"},
+			}},
+			setupMock: func(mockImporter *MockUserImportService) {
+				mockImporter.On("Import", mock.MatchedBy(func(users []services.UserImport) bool {
+					return len(users) == 1 && users[0].Email == "ann@example.com"
+				})).Return(&services.ImportReport{Created: 1, Skipped: []services.ImportFailure{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no users",
+			body:           map[string]interface{}{"users": []interface{}{}},
+			setupMock:      func(mockImporter *MockUserImportService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockImporter := &MockUserImportService{}
+			tt.setupMock(mockImporter)
+
+			handler := NewAdminHandler(&MockAdminService{}, mockImporter)
+			req := testutils.CreateTestRequest("POST", "/admin/users/import", tt.body)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.ImportUsers(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var report services.ImportReport
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+				assert.Equal(t, 1, report.Created)
+			}
+			mockImporter.AssertExpectations(t)
+		})
+	}
+}
+
+// MockAdminService is a mock implementation of AdminServiceInterface interface
+type MockAdminService struct {
+	mock.Mock
+}
+
+// Ensure MockAdminService implements AdminServiceInterface interface
+var _ services.AdminServiceInterface = (*MockAdminService)(nil)
+
+func (m *MockAdminService) ListUsers(params services.UserListParams) (*services.UserPage, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UserPage), args.Error(1)
+}
+
+func (m *MockAdminService) GetUser(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAdminService) DisableUser(actorID, id uint) (*models.User, error) {
+	args := m.Called(actorID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAdminService) EnableUser(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAdminService) ForcePasswordReset(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAdminService) DeleteUser(actorID, id uint) error {
+	args := m.Called(actorID, id)
+	return args.Error(0)
+}
+
+// MockUserImportService is a mock implementation of UserImportServiceInterface interface
+type MockUserImportService struct {
+	mock.Mock
+}
+
+// Ensure MockUserImportService implements UserImportServiceInterface interface
+var _ services.UserImportServiceInterface = (*MockUserImportService)(nil)
+
+func (m *MockUserImportService) Import(records []services.UserImport) (*services.ImportReport, error) {
+	args := m.Called(records)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.ImportReport), args.Error(1)
+}
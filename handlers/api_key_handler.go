@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	service services.APIKeyServiceInterface
+}
+
+func NewAPIKeyHandler(s services.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{service: s}
+}
+
+// Create godoc
+// @Summary Create an API key
+// @Description Create a named API key limited to the given scopes (profile:read, profile:write). The key is returned only once and is used like an access token: "Authorization: Bearer pat_...". Without expires_in_days the key does not expire. Requires a recent login or /reauthenticate.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{name=string,scopes=[]string,expires_in_days=int} true "Key name, scopes and optional lifetime"
+// @Success 201 {object} object{key=string,api_key=object{id=int,name=string,prefix=string,scopes=[]string,expires_at=string,last_used_at=string,created_at=string}} "API key created"
+// @Failure 400 {object} object{error=string} "Bad request - validation error or unknown scope"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required,min=1"`
+		ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expiresIn := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	key, plain, err := h.service.Create(principal.UserID, req.Name, req.Scopes, expiresIn)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to create API key", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	global.Logger.Info("API key created",
+		zap.Uint("user_id", principal.UserID),
+		zap.Uint("api_key_id", key.ID),
+		zap.String("prefix", key.Prefix),
+	)
+	c.JSON(http.StatusCreated, gin.H{"key": plain, "api_key": apiKeyResponse(key)})
+}
+
+// List godoc
+// @Summary List API keys
+// @Description List the active API keys of the current user with their prefix, scopes, expiry and last use
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{api_keys=[]object{id=int,name=string,prefix=string,scopes=[]string,expires_at=string,last_used_at=string,created_at=string}} "API keys"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	keys, err := h.service.List(principal.UserID)
+	if err != nil {
+		global.Logger.Error("Failed to list API keys", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(keys))
+	for i := range keys {
+		items = append(items, apiKeyResponse(&keys[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": items})
+}
+
+// Revoke godoc
+// @Summary Revoke an API key
+// @Description Revoke one of the current user's API keys. Requests with the key are rejected immediately.
+// @Tags API Keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} object{message=string} "API key revoked"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key"
+// @Failure 404 {object} object{error=string} "API key not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	if err := h.service.Revoke(principal.UserID, uint(id)); err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to revoke API key", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+
+	global.Logger.Info("API key revoked", zap.Uint("user_id", principal.UserID), zap.Uint64("api_key_id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "api key revoked"})
+}
+
+func apiKeyResponse(k *models.APIKey) gin.H {
+	return gin.H{
+		"id":           k.ID,
+		"name":         k.Name,
+		"prefix":       k.Prefix,
+		"scopes":       k.Scopes,
+		"expires_at":   k.ExpiresAt,
+		"last_used_at": k.LastUsedAt,
+		"created_at":   k.CreatedAt,
+	}
+}
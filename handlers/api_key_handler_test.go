@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockAPIKeyService)
+		expectedStatus int
+	}{
+		{
+			name:        "key created",
+			requestBody: gin.H{"name": "ci", "scopes": []string{"profile:read"}, "expires_in_days": 30},
+			setupMock: func(mockService *MockAPIKeyService) {
+				key := &models.APIKey{ID: 3, Name: "ci", Prefix: "pat_00000001", Scopes: []string{"profile:read"}}
+				mockService.On("Create", uint(1), "ci", []string{"profile:read"}, 30*24*time.Hour).Return(key, "pat_00000001_secret", nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:        "unknown scope",
+			requestBody: gin.H{"name": "ci", "scopes": []string{"admin"}},
+			setupMock: func(mockService *MockAPIKeyService) {
+				mockService.On("Create", uint(1), "ci", []string{"admin"}, time.Duration(0)).Return(nil, "", services.ErrInvalidAPIScope)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing scopes",
+			requestBody:    gin.H{"name": "ci"},
+			setupMock:      func(mockService *MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			handler := NewAPIKeyHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/api-keys", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.Create(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var response gin.H
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "pat_00000001_secret", response["key"])
+				assert.Equal(t, "pat_00000001", response["api_key"].(map[string]interface{})["prefix"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyHandler_List(t *testing.T) {
+	mockService := &MockAPIKeyService{}
+	mockService.On("List", uint(1)).Return([]models.APIKey{{ID: 3, Name: "ci", Prefix: "pat_00000001"}}, nil)
+
+	handler := NewAPIKeyHandler(mockService)
+	req := testutils.CreateTestRequest("GET", "/api-keys", nil)
+	c, w := testutils.CreateTestContext(req)
+	testutils.SetUserInContext(c, 1)
+
+	handler.List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		APIKeys []map[string]interface{} `json:"api_keys"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.APIKeys, 1)
+	assert.NotContains(t, response.APIKeys[0], "key_hash")
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Revoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		setupMock      func(*MockAPIKeyService)
+		expectedStatus int
+	}{
+		{
+			name: "key revoked",
+			id:   "3",
+			setupMock: func(mockService *MockAPIKeyService) {
+				mockService.On("Revoke", uint(1), uint(3)).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "key of another user",
+			id:   "4",
+			setupMock: func(mockService *MockAPIKeyService) {
+				mockService.On("Revoke", uint(1), uint(4)).Return(repositories.ErrAPIKeyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "abc",
+			setupMock:      func(mockService *MockAPIKeyService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockAPIKeyService{}
+			tt.setupMock(mockService)
+
+			handler := NewAPIKeyHandler(mockService)
+			req := testutils.CreateTestRequest("DELETE", "/api-keys/"+tt.id, nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "id", Value: tt.id}}
+			testutils.SetUserInContext(c, 1)
+
+			handler.Revoke(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// MockAPIKeyService is a mock implementation of APIKeyServiceInterface interface
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+// Ensure MockAPIKeyService implements APIKeyServiceInterface interface
+var _ services.APIKeyServiceInterface = (*MockAPIKeyService)(nil)
+
+func (m *MockAPIKeyService) Create(userID uint, name string, scopes []string, expiresIn time.Duration) (*models.APIKey, string, error) {
+	args := m.Called(userID, name, scopes, expiresIn)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) List(userID uint) ([]models.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) Revoke(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
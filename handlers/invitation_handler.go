@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type InvitationHandler struct {
+	service services.InvitationServiceInterface
+}
+
+func NewInvitationHandler(s services.InvitationServiceInterface) *InvitationHandler {
+	return &InvitationHandler{service: s}
+}
+
+// Invite godoc
+// @Summary Invite someone to the organization
+// @Description Email an invitation to join the active organization with a role. Only owners can invite owners. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{email=string,role=string} true "Invitee email and role"
+// @Success 201 {object} object{id=int,email=string,role=string,invited_by=int,expires_at=string,expired=bool,created_at=string} "Invitation sent"
+// @Failure 400 {object} object{error=string} "Bad request - invalid email or unknown role"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission or not an owner"
+// @Failure 409 {object} object{error=string} "Already a member or already invited"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/invitations [post]
+func (h *InvitationHandler) Invite(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, err := h.service.Invite(principal.OrgID, principal.UserID, principal.OrgRole, req.Email, req.Role)
+	if err != nil {
+		h.handleError(c, "Failed to create invitation", err)
+		return
+	}
+
+	global.Logger.Info("Invitation created",
+		zap.Uint("organization_id", principal.OrgID),
+		zap.Uint("invitation_id", inv.ID),
+		zap.String("role", inv.Role),
+		zap.Uint("invited_by", principal.UserID),
+	)
+	c.JSON(http.StatusCreated, invitationResponse(inv))
+}
+
+// List godoc
+// @Summary List pending invitations
+// @Description List the invitations of the active organization that were neither accepted nor revoked, newest first. Expired ones are included and marked so they can be resent. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{invitations=[]object{id=int,email=string,role=string,invited_by=int,expires_at=string,expired=bool,created_at=string}} "Invitations"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - no active organization or missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/invitations [get]
+func (h *InvitationHandler) List(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	invitations, err := h.service.ListPending(principal.OrgID)
+	if err != nil {
+		h.handleError(c, "Failed to list invitations", err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(invitations))
+	for i := range invitations {
+		items = append(items, invitationResponse(&invitations[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": items})
+}
+
+// Resend godoc
+// @Summary Resend an invitation
+// @Description Email a new link for a pending or expired invitation and restart its expiry. The link sent earlier stops working. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} object{id=int,email=string,role=string,invited_by=int,expires_at=string,expired=bool,created_at=string} "Invitation sent"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - no active organization or missing permission"
+// @Failure 404 {object} object{error=string} "Invitation not found, accepted or revoked"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/invitations/{id}/resend [post]
+func (h *InvitationHandler) Resend(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	inv, err := h.service.Resend(principal.OrgID, id)
+	if err != nil {
+		h.handleError(c, "Failed to resend invitation", err)
+		return
+	}
+
+	global.Logger.Info("Invitation resent", zap.Uint("organization_id", principal.OrgID), zap.Uint("invitation_id", id))
+	c.JSON(http.StatusOK, invitationResponse(inv))
+}
+
+// Revoke godoc
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation of the active organization; its link stops working. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} object{message=string} "Invitation revoked"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - no active organization or missing permission"
+// @Failure 404 {object} object{error=string} "Invitation not found, accepted or revoked"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/invitations/{id} [delete]
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := invitationIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Revoke(principal.OrgID, id); err != nil {
+		h.handleError(c, "Failed to revoke invitation", err)
+		return
+	}
+
+	global.Logger.Info("Invitation revoked",
+		zap.Uint("organization_id", principal.OrgID),
+		zap.Uint("invitation_id", id),
+		zap.Uint("revoked_by", principal.UserID),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "invitation revoked"})
+}
+
+// Lookup godoc
+// @Summary Look up an invitation
+// @Description Describe the invitation of an emailed token. account_exists tells whether the invitee should log in and call /invitations/accept or register through /invitations/register.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param request body object{token=string} true "Invitation token"
+// @Success 200 {object} object{organization=object{id=int,name=string},email=string,role=string,expires_at=string,account_exists=bool} "Invitation"
+// @Failure 400 {object} object{error=string} "Bad request - invalid or expired invitation"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /invitations/lookup [post]
+func (h *InvitationHandler) Lookup(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, accountExists, err := h.service.Lookup(req.Token)
+	if err != nil {
+		h.handleError(c, "Failed to look up invitation", err)
+		return
+	}
+
+	resp := gin.H{"email": inv.Email, "role": inv.Role, "expires_at": inv.ExpiresAt, "account_exists": accountExists}
+	if inv.Organization != nil {
+		resp["organization"] = gin.H{"id": inv.Organization.ID, "name": inv.Organization.Name}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Accept godoc
+// @Summary Accept an invitation
+// @Description Join the organization of an invitation with the current account, whose email must be the invited one. Switch to the organization with /organizations/{id}/switch.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{token=string} true "Invitation token"
+// @Success 200 {object} object{organization_id=int,role=string} "Invitation accepted"
+// @Failure 400 {object} object{error=string} "Bad request - invalid or expired invitation"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "The invitation was sent to another email address"
+// @Failure 409 {object} object{error=string} "Already a member"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /invitations/accept [post]
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, err := h.service.Accept(req.Token, principal.UserID)
+	if err != nil {
+		h.handleError(c, "Failed to accept invitation", err)
+		return
+	}
+
+	global.Logger.Info("Invitation accepted",
+		zap.Uint("organization_id", inv.OrganizationID),
+		zap.Uint("invitation_id", inv.ID),
+		zap.Uint("user_id", principal.UserID),
+	)
+	c.JSON(http.StatusOK, gin.H{"organization_id": inv.OrganizationID, "role": inv.Role})
+}
+
+// Register godoc
+// @Summary Register through an invitation
+// @Description Create an account for the invited email and join the organization of the invitation. The email counts as verified because the invitation link was mailed to it.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Param request body object{token=string,name=string,password=string} true "Invitation token and account data"
+// @Success 201 {object} object{id=int,name=string,email=string,organization_id=int,role=string} "User registered"
+// @Failure 400 {object} object{error=string,violations=[]services.PasswordViolation} "Bad request - invalid invitation or password policy violations"
+// @Failure 409 {object} object{error=string} "Conflict - an account with the email exists"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /invitations/register [post]
+func (h *InvitationHandler) Register(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, inv, err := h.service.Register(req.Token, req.Name, req.Password)
+	if err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		h.handleError(c, "Invited registration failed", err)
+		return
+	}
+
+	global.Logger.Info("User registered through invitation",
+		zap.Uint("user_id", u.ID),
+		zap.Uint("organization_id", inv.OrganizationID),
+		zap.Uint("invitation_id", inv.ID),
+	)
+	c.JSON(http.StatusCreated, gin.H{"id": u.ID, "name": u.Name, "email": u.Email, "organization_id": inv.OrganizationID, "role": inv.Role})
+}
+
+func (h *InvitationHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInvitation), errors.Is(err, services.ErrInvalidOrgRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvitationEmailMismatch), errors.Is(err, services.ErrOrgOwnerRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyMember), errors.Is(err, services.ErrInvitationPending), errors.Is(err, services.ErrAccountExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		global.Logger.Error(msg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+// invitationIDParam parses the :id path parameter and answers 400 if it is
+// invalid
+func invitationIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// invitationResponse builds the JSON representation of an invitation for
+// the organization's admins
+func invitationResponse(inv *models.Invitation) gin.H {
+	return gin.H{
+		"id":         inv.ID,
+		"email":      inv.Email,
+		"role":       inv.Role,
+		"invited_by": inv.InvitedBy,
+		"expires_at": inv.ExpiresAt,
+		"expired":    !time.Now().Before(inv.ExpiresAt),
+		"created_at": inv.CreatedAt,
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp/middlewares"
+	"temp/models"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInvitationHandler_Invite(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           gin.H
+		err            error
+		expectedStatus int
+	}{
+		{name: "invitation sent", body: gin.H{"email": "bob@example.com", "role": models.OrgRoleMember}, expectedStatus: http.StatusCreated},
+		{name: "invalid email", body: gin.H{"email": "bob", "role": models.OrgRoleMember}, expectedStatus: http.StatusBadRequest},
+		{name: "admin invites owner", body: gin.H{"email": "bob@example.com", "role": models.OrgRoleOwner}, err: services.ErrOrgOwnerRequired, expectedStatus: http.StatusForbidden},
+		{name: "already invited", body: gin.H{"email": "bob@example.com", "role": models.OrgRoleMember}, err: services.ErrInvitationPending, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockInvitationService{}
+			if tt.err != nil {
+				mockService.On("Invite", uint(3), uint(1), models.OrgRoleAdmin, "bob@example.com", tt.body["role"]).Return(nil, tt.err)
+			} else {
+				mockService.On("Invite", uint(3), uint(1), models.OrgRoleAdmin, "bob@example.com", tt.body["role"]).Return(&models.Invitation{
+					ID: 5, OrganizationID: 3, Email: "bob@example.com", Role: models.OrgRoleMember, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+			}
+
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("POST", "/organization/invitations", tt.body))
+			middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 3, OrgRole: models.OrgRoleAdmin})
+
+			NewInvitationHandler(mockService).Invite(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "bob@example.com", body["email"])
+				assert.Equal(t, false, body["expired"])
+				assert.NotContains(t, w.Body.String(), "hash")
+			}
+		})
+	}
+}
+
+func TestInvitationHandler_Accept(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "accepted", expectedStatus: http.StatusOK},
+		{name: "other account", err: services.ErrInvitationEmailMismatch, expectedStatus: http.StatusForbidden},
+		{name: "expired", err: services.ErrInvalidInvitation, expectedStatus: http.StatusBadRequest},
+		{name: "already a member", err: services.ErrAlreadyMember, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockInvitationService{}
+			if tt.err != nil {
+				mockService.On("Accept", "token", uint(8)).Return(nil, tt.err)
+			} else {
+				mockService.On("Accept", "token", uint(8)).Return(&models.Invitation{ID: 5, OrganizationID: 3, Role: models.OrgRoleAdmin}, nil)
+			}
+
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("POST", "/invitations/accept", gin.H{"token": "token"}))
+			middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 8, SessionID: "sid"})
+
+			NewInvitationHandler(mockService).Accept(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestInvitationHandler_Register(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "registered", expectedStatus: http.StatusCreated},
+		{name: "account exists", err: services.ErrAccountExists, expectedStatus: http.StatusConflict},
+		{
+			name:           "weak password",
+			err:            &services.PasswordPolicyError{Violations: []services.PasswordViolation{{Rule: services.PasswordRuleMinLength, Message: "must be at least 8 characters long"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockInvitationService{}
+			if tt.err != nil {
+				mockService.On("Register", "token", "Bob", "password123").Return(nil, nil, tt.err)
+			} else {
+				mockService.On("Register", "token", "Bob", "password123").Return(
+					&models.User{ID: 8, Name: "Bob", Email: "bob@example.com"},
+					&models.Invitation{ID: 5, OrganizationID: 3, Role: models.OrgRoleMember},
+					nil,
+				)
+			}
+
+			body := gin.H{"token": "token", "name": "Bob", "password": "password123"}
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("POST", "/invitations/register", body))
+
+			NewInvitationHandler(mockService).Register(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var resp map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, float64(3), resp["organization_id"])
+			}
+		})
+	}
+}
+
+// MockInvitationService is a mock implementation of InvitationServiceInterface
+type MockInvitationService struct {
+	mock.Mock
+}
+
+// Ensure MockInvitationService implements InvitationServiceInterface interface
+var _ services.InvitationServiceInterface = (*MockInvitationService)(nil)
+
+func (m *MockInvitationService) Invite(orgID, invitedBy uint, actorRole, email, role string) (*models.Invitation, error) {
+	args := m.Called(orgID, invitedBy, actorRole, email, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) ListPending(orgID uint) ([]models.Invitation, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) Resend(orgID, id uint) (*models.Invitation, error) {
+	args := m.Called(orgID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) Revoke(orgID, id uint) error {
+	args := m.Called(orgID, id)
+	return args.Error(0)
+}
+
+func (m *MockInvitationService) Lookup(token string) (*models.Invitation, bool, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.Invitation), args.Bool(1), args.Error(2)
+}
+
+func (m *MockInvitationService) Accept(token string, userID uint) (*models.Invitation, error) {
+	args := m.Called(token, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) Register(token, name, password string) (*models.User, *models.Invitation, error) {
+	args := m.Called(token, name, password)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.User), args.Get(1).(*models.Invitation), args.Error(2)
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"temp/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeyHandler struct {
+	keys *utils.KeyManager
+}
+
+func NewKeyHandler(keys *utils.KeyManager) *KeyHandler {
+	return &KeyHandler{keys: keys}
+}
+
+// JWKS publishes the public signing keys as a JSON Web Key Set (RFC 7517)
+// so other services can verify access tokens without the signing key.
+// It is served outside /api/v1 at /.well-known/jwks.json.
+func (h *KeyHandler) JWKS(c *gin.Context) {
+	// New keys are published for utils.KeyActivationDelay before they sign,
+	// so verifiers may cache the set for that long
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(utils.KeyActivationDelay.Seconds())))
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/repositories"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MFAHandler struct {
+	service services.MFAServiceInterface
+}
+
+func NewMFAHandler(s services.MFAServiceInterface) *MFAHandler {
+	return &MFAHandler{service: s}
+}
+
+// CompleteLogin godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the mfa_token returned by /login and a TOTP or recovery code for tokens. The mfa_token can only be tried once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{mfa_token=string,code=string} true "MFA token and TOTP or recovery code"
+// @Success 200 {object} object{token=string,refresh_token=string,token_type=string,expires_in=int,user=object{id=int,email=string,name=string}} "Login successful"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid mfa token or code"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /login/mfa [post]
+func (h *MFAHandler) CompleteLogin(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.CompleteLogin(req.MFAToken, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMFAToken) || errors.Is(err, services.ErrInvalidMFACode) {
+			global.Logger.Warn("Two-factor login rejected", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Two-factor login failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+
+	global.Logger.Info("User logged in with two-factor authentication",
+		zap.Uint("user_id", result.User.ID),
+		zap.String("email", result.User.Email),
+	)
+	c.JSON(http.StatusOK, loginResponse(c, result))
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret and its otpauth:// provisioning URI for an authenticator app. Two-factor authentication is enabled once a code is confirmed.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{secret=string,otpauth_uri=string} "Secret generated"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 409 {object} object{error=string} "Two-factor authentication is already enabled"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /mfa/totp/enroll [post]
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(principal.UserID)
+	if err != nil {
+		h.handleError(c, principal.UserID, "TOTP enrollment failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": enrollment.Secret, "otpauth_uri": enrollment.URI})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enable two-factor authentication with a code from the authenticator app. The response lists recovery codes that are shown only once. Requires a recent login or /reauthenticate.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{code=string} true "TOTP code"
+// @Success 200 {object} object{message=string,recovery_codes=[]string} "Two-factor authentication enabled"
+// @Failure 400 {object} object{error=string} "Bad request - invalid code or enrollment not started"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 409 {object} object{error=string} "Two-factor authentication is already enabled"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(principal.UserID, req.Code)
+	if err != nil {
+		h.handleError(c, principal.UserID, "TOTP confirmation failed", err)
+		return
+	}
+
+	global.Logger.Info("Two-factor authentication enabled", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled", "recovery_codes": codes})
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn two-factor authentication off. Requires the account password, a current TOTP or recovery code and a recent login or /reauthenticate. A wrong password or code counts towards the login lockout.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{password=string,code=string} true "Account password and a TOTP or recovery code"
+// @Success 200 {object} object{message=string} "Two-factor authentication disabled"
+// @Failure 400 {object} object{error=string} "Bad request - validation error, incorrect password or code, or two-factor authentication not enabled"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 423 {object} object{error=string,retry_after=int} "Account temporarily locked"
+// @Failure 429 {object} object{error=string,retry_after=int} "Too many failed attempts from this IP address"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /mfa/totp/disable [post]
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.DisableTOTP(principal.UserID, req.Password, req.Code, c.ClientIP()); err != nil {
+		if writeRetryError(c, err) {
+			return
+		}
+		h.handleError(c, principal.UserID, "Disabling TOTP failed", err)
+		return
+	}
+
+	global.Logger.Info("Two-factor authentication disabled", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// handleError maps MFA service errors to responses
+func (h *MFAHandler) handleError(c *gin.Context, userID uint, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFANotEnrolled),
+		errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrInvalidMFACode),
+		errors.Is(err, services.ErrIncorrectPassword):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		global.Logger.Error(msg, zap.Uint("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
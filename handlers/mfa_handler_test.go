@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"temp/models"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMFAHandler_CompleteLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockMFAService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful login",
+			requestBody: gin.H{"mfa_token": "challenge", "code": "123456"},
+			setupMock: func(mockService *MockMFAService) {
+				result := &services.LoginResult{
+					User:   &models.User{ID: 1, Email: "test@example.com"},
+					Tokens: &services.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900},
+				}
+				mockService.On("CompleteLogin", "challenge", "123456", mock.Anything, mock.Anything).Return(result, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid code",
+			requestBody: gin.H{"mfa_token": "challenge", "code": "000000"},
+			setupMock: func(mockService *MockMFAService) {
+				mockService.On("CompleteLogin", "challenge", "000000", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidMFACode)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing code",
+			requestBody:    gin.H{"mfa_token": "challenge"},
+			setupMock:      func(mockService *MockMFAService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockMFAService{}
+			tt.setupMock(mockService)
+
+			handler := NewMFAHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/login/mfa", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.CompleteLogin(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response gin.H
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "access", response["token"])
+				assert.Equal(t, "refresh", response["refresh_token"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMFAHandler_EnrollTOTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMock      func(*MockMFAService)
+		expectedStatus int
+	}{
+		{
+			name: "secret generated",
+			setupMock: func(mockService *MockMFAService) {
+				mockService.On("EnrollTOTP", uint(1)).Return(&services.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/x"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "already enabled",
+			setupMock: func(mockService *MockMFAService) {
+				mockService.On("EnrollTOTP", uint(1)).Return(nil, services.ErrMFAAlreadyEnabled)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockMFAService{}
+			tt.setupMock(mockService)
+
+			handler := NewMFAHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/mfa/totp/enroll", nil)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.EnrollTOTP(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMFAHandler_ConfirmTOTP(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMock      func(*MockMFAService)
+		expectedStatus int
+	}{
+		{
+			name: "enabled with recovery codes",
+			setupMock: func(mockService *MockMFAService) {
+				mockService.On("ConfirmTOTP", uint(1), "123456", mock.Anything, mock.Anything).Return([]string{"abcde-fghij"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid code",
+			setupMock: func(mockService *MockMFAService) {
+				mockService.On("ConfirmTOTP", uint(1), "123456", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidMFACode)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockMFAService{}
+			tt.setupMock(mockService)
+
+			handler := NewMFAHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/mfa/totp/confirm", gin.H{"code": "123456"})
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.ConfirmTOTP(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMFAHandler_DisableTOTP(t *testing.T) {
+	mockService := &MockMFAService{}
+	mockService.On("DisableTOTP", uint(1), "wrongpassword", "123456", mock.Anything).Return(services.ErrIncorrectPassword)
+
+	handler := NewMFAHandler(mockService)
+	req := testutils.CreateTestRequest("POST", "/mfa/totp/disable", gin.H{"password": "wrongpassword", "code": "123456"})
+	c, w := testutils.CreateTestContext(req)
+	testutils.SetUserInContext(c, 1)
+
+	handler.DisableTOTP(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+// MockMFAService is a mock implementation of MFAServiceInterface interface
+type MockMFAService struct {
+	mock.Mock
+}
+
+// Ensure MockMFAService implements MFAServiceInterface interface
+var _ services.MFAServiceInterface = (*MockMFAService)(nil)
+
+func (m *MockMFAService) EnrollTOTP(userID uint) (*services.TOTPEnrollment, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) ConfirmTOTP(userID uint, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) DisableTOTP(userID uint, password, code, clientIP string) error {
+	args := m.Called(userID, password, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Challenge(u *models.User) (string, int64, error) {
+	args := m.Called(u)
+	return args.String(0), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMFAService) CompleteLogin(challenge, code, clientIP, userAgent string) (*services.LoginResult, error) {
+	args := m.Called(challenge, code, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+
+func (m *MockMFAService) VerifyCode(u *models.User, code string) error {
+	args := m.Called(u, code)
+	return args.Error(0)
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OAuthHandler serves the endpoints of the OAuth2 authorization server:
+// the protocol endpoints called by client applications, the consent API
+// behind the frontend's authorization page and client registration
+type OAuthHandler struct {
+	server  services.OAuthServerServiceInterface
+	clients services.OAuthClientServiceInterface
+}
+
+func NewOAuthHandler(server services.OAuthServerServiceInterface, clients services.OAuthClientServiceInterface) *OAuthHandler {
+	return &OAuthHandler{server: server, clients: clients}
+}
+
+// Discovery publishes the OpenID Connect discovery document. It is served
+// outside /api/v1 at /.well-known/openid-configuration.
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, h.server.Metadata())
+}
+
+// AuthorizePrompt godoc
+// @Summary Check an authorization request
+// @Description Validate the authorization request an application sent the user to the authorization page with, and return the application and scopes to show on the consent screen. When consent_required is false the page can approve the request right away. Errors that carry redirect_to must be reported to the application by redirecting there.
+// @Tags OAuth
+// @Produce json
+// @Security BearerAuth
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes (default: every scope of the client)"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Value copied into the ID token"
+// @Param code_challenge query string true "PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} object{client=object{client_id=string,name=string},scopes=[]string,consent_required=bool} "Valid request"
+// @Failure 400 {object} object{error=string,error_description=string,redirect_to=string} "Invalid request"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /oauth/authorize [get]
+func (h *OAuthHandler) AuthorizePrompt(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req services.AuthorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prompt, err := h.server.Authorize(principal.UserID, req)
+	if err != nil {
+		h.handleAuthorizationError(c, req, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client":           gin.H{"client_id": prompt.Client.ClientID, "name": prompt.Client.Name},
+		"scopes":           prompt.Scopes,
+		"consent_required": prompt.ConsentRequired,
+	})
+}
+
+// Approve godoc
+// @Summary Answer an authorization request
+// @Description Record whether the user allows the application the requested scopes, and return the URI to send the user back to the application with. It carries an authorization code, or the access_denied error when the user declined.
+// @Tags OAuth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{response_type=string,client_id=string,redirect_uri=string,scope=string,state=string,nonce=string,code_challenge=string,code_challenge_method=string,approve=bool} true "The authorization request and the user's decision"
+// @Success 200 {object} object{redirect_to=string} "Redirect back to the application"
+// @Failure 400 {object} object{error=string,error_description=string,redirect_to=string} "Invalid request"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /oauth/authorize [post]
+func (h *OAuthHandler) Approve(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		services.AuthorizationRequest
+		Approve *bool `json:"approve" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redirect, err := h.server.Approve(principal.UserID, req.AuthorizationRequest, *req.Approve)
+	if err != nil {
+		h.handleAuthorizationError(c, req.AuthorizationRequest, err)
+		return
+	}
+
+	global.Logger.Info("OAuth authorization answered",
+		zap.Uint("user_id", principal.UserID),
+		zap.String("client_id", req.ClientID),
+		zap.Bool("approved", *req.Approve),
+	)
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirect})
+}
+
+// Token is the token endpoint (RFC 6749 section 3.2). It exchanges an
+// authorization code with its PKCE verifier or a refresh token, or issues a
+// token to the client itself for client_credentials. It is served outside
+// /api/v1 at /oauth/token.
+func (h *OAuthHandler) Token(c *gin.Context) {
+	var req struct {
+		GrantType    string `form:"grant_type"`
+		Code         string `form:"code"`
+		RedirectURI  string `form:"redirect_uri"`
+		CodeVerifier string `form:"code_verifier"`
+		RefreshToken string `form:"refresh_token"`
+		Scope        string `form:"scope"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		h.handleProtocolError(c, services.ErrOAuthInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(c)
+
+	resp, err := h.server.Token(services.TokenRequest{
+		GrantType:    req.GrantType,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         req.Code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: req.CodeVerifier,
+		RefreshToken: req.RefreshToken,
+		Scope:        req.Scope,
+	})
+	if err != nil {
+		h.handleProtocolError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// Introspect tells confidential clients whether a token is active
+// (RFC 7662). It is served outside /api/v1 at /oauth/introspect.
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	var req struct {
+		Token         string `form:"token"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+	if err := c.ShouldBind(&req); err != nil || req.Token == "" {
+		h.handleProtocolError(c, services.ErrOAuthInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(c)
+
+	info, err := h.server.Introspect(clientID, clientSecret, req.Token, req.TokenTypeHint)
+	if err != nil {
+		h.handleProtocolError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
+
+// Revoke revokes a token issued to the calling client (RFC 7009); unknown
+// tokens are ignored. It is served outside /api/v1 at /oauth/revoke.
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	var req struct {
+		Token         string `form:"token"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+	if err := c.ShouldBind(&req); err != nil || req.Token == "" {
+		h.handleProtocolError(c, services.ErrOAuthInvalidRequest)
+		return
+	}
+	clientID, clientSecret := clientCredentials(c)
+
+	if err := h.server.Revoke(clientID, clientSecret, req.Token, req.TokenTypeHint); err != nil {
+		h.handleProtocolError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// UserInfo returns the claims about the user that the scopes of the access
+// token allow. It is served outside /api/v1 at /oauth/userinfo and
+// requires the openid scope.
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	scopes := models.OAuthScopes
+	if principal.IsOAuthClient() {
+		scopes = principal.Scopes
+	}
+
+	info, err := h.server.UserInfo(principal.UserID, scopes)
+	if err != nil {
+		global.Logger.Error("Failed to load user info", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// ListConsents godoc
+// @Summary List connected applications
+// @Description List the applications the current user has allowed access to their account, with the scopes allowed
+// @Tags OAuth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{consents=[]services.AppConsent} "Connected applications"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key or OAuth token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /oauth/consents [get]
+func (h *OAuthHandler) ListConsents(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	consents, err := h.server.ListConsents(principal.UserID)
+	if err != nil {
+		global.Logger.Error("Failed to list OAuth consents", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list consents"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"consents": consents})
+}
+
+// RevokeConsent godoc
+// @Summary Disconnect an application
+// @Description Withdraw the current user's consent to an application and revoke its refresh tokens. The application has to ask for consent again.
+// @Tags OAuth
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} object{message=string} "Consent revoked"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key or OAuth token"
+// @Failure 404 {object} object{error=string} "Consent not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /oauth/consents/{client_id} [delete]
+func (h *OAuthHandler) RevokeConsent(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	clientID := c.Param("client_id")
+	if err := h.server.RevokeConsent(principal.UserID, clientID); err != nil {
+		if errors.Is(err, services.ErrConsentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to revoke OAuth consent", zap.Uint("user_id", principal.UserID), zap.String("client_id", clientID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke consent"})
+		return
+	}
+
+	global.Logger.Info("OAuth consent revoked", zap.Uint("user_id", principal.UserID), zap.String("client_id", clientID))
+	c.JSON(http.StatusOK, gin.H{"message": "consent revoked"})
+}
+
+// CreateClient godoc
+// @Summary Register an OAuth client
+// @Description Register an application with the authorization server. Confidential clients get a client_secret, returned only once; public clients (single page and native apps) rely on PKCE. Redirect URIs must use https, http on a loopback address or a private-use scheme, and are matched exactly. Trusted clients are not asked for consent. Requires the clients:write permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{name=string,redirect_uris=[]string,scopes=[]string,grant_types=[]string,confidential=bool,trusted=bool} true "Client registration"
+// @Success 201 {object} object{client_secret=string,client=object{id=int,client_id=string,name=string,redirect_uris=[]string,scopes=[]string,grant_types=[]string,confidential=bool,trusted=bool,created_at=string}} "Client registered"
+// @Failure 400 {object} object{error=string} "Bad request - invalid registration"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/oauth-clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req struct {
+		Name         string   `json:"name" binding:"required,max=100"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		GrantTypes   []string `json:"grant_types" binding:"required,min=1"`
+		Confidential bool     `json:"confidential"`
+		Trusted      bool     `json:"trusted"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, secret, err := h.clients.Register(services.OAuthClientRegistration{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+		Confidential: req.Confidential,
+		Trusted:      req.Trusted,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidOAuthClient) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to register OAuth client", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register client"})
+		return
+	}
+
+	global.Logger.Info("OAuth client registered", zap.String("client_id", client.ClientID), zap.String("name", client.Name))
+	c.JSON(http.StatusCreated, gin.H{"client_secret": secret, "client": oauthClientResponse(client)})
+}
+
+// ListClients godoc
+// @Summary List OAuth clients
+// @Description List the applications registered with the authorization server. Requires the clients:read permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{clients=[]object{id=int,client_id=string,name=string,redirect_uris=[]string,scopes=[]string,grant_types=[]string,confidential=bool,trusted=bool,created_at=string}} "Clients"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/oauth-clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.clients.List()
+	if err != nil {
+		global.Logger.Error("Failed to list OAuth clients", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list clients"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(clients))
+	for i := range clients {
+		items = append(items, oauthClientResponse(&clients[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": items})
+}
+
+// DeleteClient godoc
+// @Summary Delete an OAuth client
+// @Description Delete a registered application together with the consents given to it and its refresh tokens. Access tokens it holds stay valid until they expire. Requires the clients:write permission.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client record ID"
+// @Success 200 {object} object{message=string} "Client deleted"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
+// @Failure 404 {object} object{error=string} "Client not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /admin/oauth-clients/{id} [delete]
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	if err := h.clients.Delete(uint(id)); err != nil {
+		if errors.Is(err, services.ErrOAuthClientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to delete OAuth client", zap.Uint64("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete client"})
+		return
+	}
+
+	global.Logger.Info("OAuth client deleted", zap.Uint64("id", id))
+	c.JSON(http.StatusOK, gin.H{"message": "client deleted"})
+}
+
+// handleAuthorizationError maps errors of the consent API. Requests with
+// an unknown client or redirect URI are reported to the user only; other
+// errors carry the URI that reports them to the client.
+func (h *OAuthHandler) handleAuthorizationError(c *gin.Context, req services.AuthorizationRequest, err error) {
+	var oauthErr *services.OAuthError
+	switch {
+	case errors.Is(err, services.ErrInvalidAuthorizationRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &oauthErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":             oauthErr.Code,
+			"error_description": oauthErr.Description,
+			"redirect_to":       req.ErrorRedirect(oauthErr),
+		})
+	default:
+		global.Logger.Error("OAuth authorization failed", zap.String("client_id", req.ClientID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+// handleProtocolError writes an error response of the token, introspection
+// and revocation endpoints (RFC 6749 section 5.2)
+func (h *OAuthHandler) handleProtocolError(c *gin.Context, err error) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		global.Logger.Error("OAuth token request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if errors.Is(err, services.ErrOAuthInvalidClient) {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		status = http.StatusUnauthorized
+	}
+	body := gin.H{"error": oauthErr.Code}
+	if oauthErr.Description != "" {
+		body["error_description"] = oauthErr.Description
+	}
+	c.JSON(status, body)
+}
+
+// clientCredentials returns the client_id and client_secret from HTTP
+// Basic authentication, where both are form encoded (RFC 6749 section
+// 2.3.1), or else from the request body
+func clientCredentials(c *gin.Context) (string, string) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		clientID, err1 := url.QueryUnescape(id)
+		clientSecret, err2 := url.QueryUnescape(secret)
+		if err1 == nil && err2 == nil {
+			return clientID, clientSecret
+		}
+		return "", ""
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret")
+}
+
+func oauthClientResponse(client *models.OAuthClient) gin.H {
+	return gin.H{
+		"id":            client.ID,
+		"client_id":     client.ClientID,
+		"name":          client.Name,
+		"redirect_uris": client.RedirectURIs,
+		"scopes":        client.Scopes,
+		"grant_types":   client.GrantTypes,
+		"confidential":  client.IsConfidential(),
+		"trusted":       client.Trusted,
+		"created_at":    client.CreatedAt,
+	}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"temp/middlewares"
+	"temp/models"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOAuthHandler_AuthorizePrompt(t *testing.T) {
+	client := &models.OAuthClient{ClientID: "wiki", Name: "Wiki"}
+
+	tests := []struct {
+		name             string
+		setupMock        func(*MockOAuthServerService)
+		expectedStatus   int
+		expectedRedirect string
+	}{
+		{
+			name: "consent required",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Authorize", uint(1), mock.AnythingOfType("services.AuthorizationRequest")).Return(&services.AuthorizationPrompt{Client: client, Scopes: []string{"openid"}, ConsentRequired: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "unknown client is not redirected",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Authorize", uint(1), mock.AnythingOfType("services.AuthorizationRequest")).Return(nil, services.ErrInvalidAuthorizationRequest)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid scope is redirected to the client",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Authorize", uint(1), mock.AnythingOfType("services.AuthorizationRequest")).Return(nil, &services.OAuthError{Code: "invalid_scope", Description: "unknown scope"})
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedRedirect: "https://wiki.example.com/callback?error=invalid_scope&error_description=unknown+scope&state=xyz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := &MockOAuthServerService{}
+			tt.setupMock(mockServer)
+
+			handler := NewOAuthHandler(mockServer, &MockOAuthClientService{})
+			req := testutils.CreateTestRequest("GET", "/oauth/authorize?response_type=code&client_id=wiki&redirect_uri=https%3A%2F%2Fwiki.example.com%2Fcallback&state=xyz", nil)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.AuthorizePrompt(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, true, body["consent_required"])
+			}
+			if tt.expectedRedirect != "" {
+				assert.Equal(t, tt.expectedRedirect, body["redirect_to"])
+			} else {
+				assert.NotContains(t, body, "redirect_to")
+			}
+			mockServer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOAuthHandler_Approve(t *testing.T) {
+	mockServer := &MockOAuthServerService{}
+	mockServer.On("Approve", uint(1), mock.MatchedBy(func(r services.AuthorizationRequest) bool {
+		return r.ClientID == "wiki" && r.CodeChallenge == "challenge"
+	}), true).Return("https://wiki.example.com/callback?code=c&state=xyz", nil)
+
+	handler := NewOAuthHandler(mockServer, &MockOAuthClientService{})
+	req := testutils.CreateTestRequest("POST", "/oauth/authorize", map[string]interface{}{
+		"response_type":         "code",
+		"client_id":             "wiki",
+		"redirect_uri":          "https://wiki.example.com/callback",
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+		"approve":               true,
+	})
+	c, w := testutils.CreateTestContext(req)
+	testutils.SetUserInContext(c, 1)
+
+	handler.Approve(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "code=c")
+	mockServer.AssertExpectations(t)
+}
+
+func TestOAuthHandler_Token(t *testing.T) {
+	tests := []struct {
+		name           string
+		basicAuth      bool
+		setupMock      func(*MockOAuthServerService)
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:      "client secret in basic auth",
+			basicAuth: true,
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Token", services.TokenRequest{GrantType: "authorization_code", ClientID: "wiki", ClientSecret: "s3cr:t", Code: "code", CodeVerifier: "verifier"}).
+					Return(&services.OAuthTokenResponse{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "client secret in body",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Token", services.TokenRequest{GrantType: "authorization_code", ClientID: "wiki", ClientSecret: "s3cr:t", Code: "code", CodeVerifier: "verifier"}).
+					Return(&services.OAuthTokenResponse{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "client authentication failed",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Token", mock.Anything).Return(nil, services.ErrOAuthInvalidClient)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  "invalid_client",
+		},
+		{
+			name: "invalid grant",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Token", mock.Anything).Return(nil, &services.OAuthError{Code: "invalid_grant", Description: "the code is invalid or expired"})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_grant",
+		},
+		{
+			name: "internal error",
+			setupMock: func(mockServer *MockOAuthServerService) {
+				mockServer.On("Token", mock.Anything).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "server_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := &MockOAuthServerService{}
+			tt.setupMock(mockServer)
+
+			form := url.Values{"grant_type": {"authorization_code"}, "code": {"code"}, "code_verifier": {"verifier"}}
+			if !tt.basicAuth {
+				form.Set("client_id", "wiki")
+				form.Set("client_secret", "s3cr:t")
+			}
+			req, _ := http.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basicAuth {
+				req.SetBasicAuth("wiki", url.QueryEscape("s3cr:t"))
+			}
+			c, w := testutils.CreateTestContext(req)
+
+			NewOAuthHandler(mockServer, &MockOAuthClientService{}).Token(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if tt.expectedError != "" {
+				assert.Equal(t, tt.expectedError, body["error"])
+			} else {
+				assert.Equal(t, "access", body["access_token"])
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			}
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+			mockServer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOAuthHandler_UserInfo(t *testing.T) {
+	mockServer := &MockOAuthServerService{}
+	mockServer.On("UserInfo", uint(1), []string{"openid", "email"}).Return(map[string]interface{}{"sub": "1", "email": "test@example.com"}, nil)
+
+	req := testutils.CreateTestRequest("GET", "/oauth/userinfo", nil)
+	c, w := testutils.CreateTestContext(req)
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, ClientID: "wiki", Scopes: []string{"openid", "email"}})
+
+	NewOAuthHandler(mockServer, &MockOAuthClientService{}).UserInfo(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "test@example.com")
+	mockServer.AssertExpectations(t)
+}
+
+func TestOAuthHandler_RevokeConsent(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "consent revoked", expectedStatus: http.StatusOK},
+		{name: "consent not found", err: services.ErrConsentNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := &MockOAuthServerService{}
+			mockServer.On("RevokeConsent", uint(1), "wiki").Return(tt.err)
+
+			req := testutils.CreateTestRequest("DELETE", "/oauth/consents/wiki", nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "client_id", Value: "wiki"}}
+			testutils.SetUserInContext(c, 1)
+
+			NewOAuthHandler(mockServer, &MockOAuthClientService{}).RevokeConsent(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockServer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOAuthHandler_CreateClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    map[string]interface{}
+		setupMock      func(*MockOAuthClientService)
+		expectedStatus int
+	}{
+		{
+			name: "confidential client registered",
+			requestBody: map[string]interface{}{
+				"name":          "Wiki",
+				"redirect_uris": []string{"https://wiki.example.com/callback"},
+				"grant_types":   []string{"authorization_code"},
+				"confidential":  true,
+			},
+			setupMock: func(mockClients *MockOAuthClientService) {
+				mockClients.On("Register", mock.MatchedBy(func(reg services.OAuthClientRegistration) bool {
+					return reg.Name == "Wiki" && reg.Confidential
+				})).Return(&models.OAuthClient{ID: 1, ClientID: "wiki", Name: "Wiki", SecretHash: "hash"}, "secret", nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "invalid registration",
+			requestBody: map[string]interface{}{
+				"name":        "Wiki",
+				"grant_types": []string{"password"},
+			},
+			setupMock: func(mockClients *MockOAuthClientService) {
+				mockClients.On("Register", mock.Anything).Return(nil, "", services.ErrInvalidOAuthClient)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing grant types",
+			requestBody:    map[string]interface{}{"name": "Wiki"},
+			setupMock:      func(mockClients *MockOAuthClientService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClients := &MockOAuthClientService{}
+			tt.setupMock(mockClients)
+
+			req := testutils.CreateTestRequest("POST", "/admin/oauth-clients", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			NewOAuthHandler(&MockOAuthServerService{}, mockClients).CreateClient(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "secret", body["client_secret"])
+				assert.NotContains(t, w.Body.String(), "hash")
+			}
+			mockClients.AssertExpectations(t)
+		})
+	}
+}
+
+// MockOAuthServerService is a mock implementation of OAuthServerServiceInterface
+type MockOAuthServerService struct {
+	mock.Mock
+}
+
+// Ensure MockOAuthServerService implements OAuthServerServiceInterface interface
+var _ services.OAuthServerServiceInterface = (*MockOAuthServerService)(nil)
+
+func (m *MockOAuthServerService) Metadata() *services.ProviderMetadata {
+	args := m.Called()
+	return args.Get(0).(*services.ProviderMetadata)
+}
+
+func (m *MockOAuthServerService) Authorize(userID uint, req services.AuthorizationRequest) (*services.AuthorizationPrompt, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.AuthorizationPrompt), args.Error(1)
+}
+
+func (m *MockOAuthServerService) Approve(userID uint, req services.AuthorizationRequest, approved bool) (string, error) {
+	args := m.Called(userID, req, approved)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOAuthServerService) Token(req services.TokenRequest) (*services.OAuthTokenResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.OAuthTokenResponse), args.Error(1)
+}
+
+func (m *MockOAuthServerService) Introspect(clientID, clientSecret, token, tokenTypeHint string) (*services.TokenIntrospection, error) {
+	args := m.Called(clientID, clientSecret, token, tokenTypeHint)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenIntrospection), args.Error(1)
+}
+
+func (m *MockOAuthServerService) Revoke(clientID, clientSecret, token, tokenTypeHint string) error {
+	args := m.Called(clientID, clientSecret, token, tokenTypeHint)
+	return args.Error(0)
+}
+
+func (m *MockOAuthServerService) UserInfo(userID uint, scopes []string) (map[string]interface{}, error) {
+	args := m.Called(userID, scopes)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]interface{}), args.Error(1)
+}
+
+func (m *MockOAuthServerService) ListConsents(userID uint) ([]services.AppConsent, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]services.AppConsent), args.Error(1)
+}
+
+func (m *MockOAuthServerService) RevokeConsent(userID uint, clientID string) error {
+	args := m.Called(userID, clientID)
+	return args.Error(0)
+}
+
+// MockOAuthClientService is a mock implementation of OAuthClientServiceInterface
+type MockOAuthClientService struct {
+	mock.Mock
+}
+
+// Ensure MockOAuthClientService implements OAuthClientServiceInterface interface
+var _ services.OAuthClientServiceInterface = (*MockOAuthClientService)(nil)
+
+func (m *MockOAuthClientService) Register(reg services.OAuthClientRegistration) (*models.OAuthClient, string, error) {
+	args := m.Called(reg)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*models.OAuthClient), args.String(1), args.Error(2)
+}
+
+func (m *MockOAuthClientService) List() ([]models.OAuthClient, error) {
+	args := m.Called()
+	return args.Get(0).([]models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientService) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientService) Find(clientID string) (*models.OAuthClient, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientService) Authenticate(clientID, secret string) (*models.OAuthClient, error) {
+	args := m.Called(clientID, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
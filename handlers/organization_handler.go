@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OrganizationHandler struct {
+	service services.OrganizationServiceInterface
+}
+
+func NewOrganizationHandler(s services.OrganizationServiceInterface) *OrganizationHandler {
+	return &OrganizationHandler{service: s}
+}
+
+// Create godoc
+// @Summary Create an organization
+// @Description Create an organization with the current user as its owner. Switch to it with /organizations/{id}/switch.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{name=string} true "Organization name"
+// @Success 201 {object} object{id=int,name=string,role=string,created_at=string} "Organization created"
+// @Failure 400 {object} object{error=string} "Bad request - missing or too long name"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organizations [post]
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	o, err := h.service.Create(principal.UserID, req.Name)
+	if err != nil {
+		h.handleError(c, "Failed to create organization", err)
+		return
+	}
+
+	global.Logger.Info("Organization created", zap.Uint("user_id", principal.UserID), zap.Uint("organization_id", o.ID))
+	c.JSON(http.StatusCreated, gin.H{"id": o.ID, "name": o.Name, "role": models.OrgRoleOwner, "created_at": o.CreatedAt})
+}
+
+// List godoc
+// @Summary List my organizations
+// @Description List the organizations the current user belongs to with their role in each, oldest membership first. The one the session acts in is marked active.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{organizations=[]object{id=int,name=string,role=string,active=bool,joined_at=string}} "Organizations"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organizations [get]
+func (h *OrganizationHandler) List(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	memberships, err := h.service.ListForUser(principal.UserID)
+	if err != nil {
+		h.handleError(c, "Failed to list organizations", err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(memberships))
+	for _, m := range memberships {
+		item := gin.H{"id": m.OrganizationID, "role": m.Role, "active": m.OrganizationID == principal.OrgID, "joined_at": m.CreatedAt}
+		if m.Organization != nil {
+			item["name"] = m.Organization.Name
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": items})
+}
+
+// Switch godoc
+// @Summary Switch organization
+// @Description Make the current session act in another organization of the user. The response carries an access token for it; the session's refresh token keeps working and yields tokens for the new organization. In the cookie auth mode the session cookie switches and no token is returned.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {object} object{organization_id=int,token=string,token_type=string,expires_in=int} "Switched"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Not a member of the organization"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organizations/{id}/switch [post]
+func (h *OrganizationHandler) Switch(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	orgID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || orgID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return
+	}
+
+	pair, err := h.service.Switch(principal.UserID, principal.SessionID, uint(orgID))
+	if err != nil {
+		h.handleError(c, "Failed to switch organization", err)
+		return
+	}
+
+	global.Logger.Info("Organization switched", zap.Uint("user_id", principal.UserID), zap.Uint64("organization_id", orgID))
+	resp := gin.H{"organization_id": orgID}
+	if config, ok := middlewares.GetSessionCookieConfig(c); !ok || !config.OmitTokens {
+		resp["token"] = pair.AccessToken
+		resp["token_type"] = pair.TokenType
+		resp["expires_in"] = pair.ExpiresIn
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Members godoc
+// @Summary List members
+// @Description List the members of the active organization, ordered by user ID. Requires the org:members:read organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of members to skip"
+// @Success 200 {object} object{members=[]object{id=int,name=string,email=string,display_name=string,avatar_url=string,role=string,joined_at=string},total=int} "Members"
+// @Failure 400 {object} object{error=string} "Bad request - invalid paging"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - no active organization or missing permission"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/members [get]
+func (h *OrganizationHandler) Members(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Limit  int `form:"limit" binding:"omitempty,min=1,max=100"`
+		Offset int `form:"offset" binding:"omitempty,min=0"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, total, err := h.service.Members(principal.OrgID, req.Limit, req.Offset)
+	if err != nil {
+		h.handleError(c, "Failed to list members", err)
+		return
+	}
+
+	members := make([]gin.H, 0, len(users))
+	for i := range users {
+		members = append(members, memberResponse(&users[i], principal.OrgID))
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members, "total": total})
+}
+
+// Member godoc
+// @Summary Get a member
+// @Description Get a member of the active organization. Users outside the organization are not found. Requires the org:members:read organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{id=int,name=string,email=string,display_name=string,avatar_url=string,role=string,joined_at=string} "Member"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - no active organization or missing permission"
+// @Failure 404 {object} object{error=string} "Member not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/members/{id} [get]
+func (h *OrganizationHandler) Member(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.service.Member(principal.OrgID, id)
+	if err != nil {
+		h.handleError(c, "Failed to load member", err)
+		return
+	}
+	c.JSON(http.StatusOK, memberResponse(u, principal.OrgID))
+}
+
+// UpdateMember godoc
+// @Summary Change a member's role
+// @Description Change the role of a member of the active organization to owner, admin or member. Only owners can grant or change the owner role, and the last owner cannot be demoted. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body object{role=string} true "New role"
+// @Success 200 {object} object{message=string} "Role changed"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id or unknown role"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission or not an owner"
+// @Failure 404 {object} object{error=string} "Member not found"
+// @Failure 409 {object} object{error=string} "The organization would have no owner"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/members/{id} [put]
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(principal.OrgID, principal.OrgRole, id, req.Role); err != nil {
+		h.handleError(c, "Failed to change member role", err)
+		return
+	}
+
+	global.Logger.Info("Member role changed",
+		zap.Uint("organization_id", principal.OrgID),
+		zap.Uint("user_id", id),
+		zap.String("role", req.Role),
+		zap.Uint("changed_by", principal.UserID),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "role changed"})
+}
+
+// RemoveMember godoc
+// @Summary Remove a member
+// @Description Remove a user from the active organization. Only owners can remove owners, and the last owner cannot be removed. The user's tokens for the organization stop working once they are refreshed. Requires the org:members:write organization permission.
+// @Tags Organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} object{message=string} "Member removed"
+// @Failure 400 {object} object{error=string} "Bad request - invalid id"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission or not an owner"
+// @Failure 404 {object} object{error=string} "Member not found"
+// @Failure 409 {object} object{error=string} "The organization would have no owner"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /organization/members/{id} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(principal.OrgID, principal.OrgRole, id); err != nil {
+		h.handleError(c, "Failed to remove member", err)
+		return
+	}
+
+	global.Logger.Info("Member removed",
+		zap.Uint("organization_id", principal.OrgID),
+		zap.Uint("user_id", id),
+		zap.Uint("removed_by", principal.UserID),
+	)
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+func (h *OrganizationHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidOrganization), errors.Is(err, services.ErrInvalidOrgRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotOrgMember), errors.Is(err, services.ErrOrgOwnerRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, repositories.ErrUserNotFound), errors.Is(err, repositories.ErrMembershipNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+	case errors.Is(err, repositories.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+	default:
+		global.Logger.Error(msg, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+// memberResponse builds the JSON representation of an organization member
+func memberResponse(u *models.User, orgID uint) gin.H {
+	res := gin.H{
+		"id":           u.ID,
+		"name":         u.Name,
+		"email":        u.Email,
+		"display_name": u.DisplayName,
+		"avatar_url":   u.AvatarURL,
+	}
+	if m := u.Membership(orgID); m != nil {
+		res["role"] = m.Role
+		res["joined_at"] = m.CreatedAt
+	}
+	return res
+}
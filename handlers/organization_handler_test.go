@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrganizationHandler_List(t *testing.T) {
+	mockService := &MockOrganizationService{}
+	mockService.On("ListForUser", uint(1)).Return([]models.Membership{
+		{OrganizationID: 3, UserID: 1, Role: models.OrgRoleOwner, Organization: &models.Organization{ID: 3, Name: "Acme"}, CreatedAt: time.Now()},
+		{OrganizationID: 4, UserID: 1, Role: models.OrgRoleMember, Organization: &models.Organization{ID: 4, Name: "Globex"}, CreatedAt: time.Now()},
+	}, nil)
+
+	c, w := testutils.CreateTestContext(testutils.CreateTestRequest("GET", "/organizations", nil))
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 4, OrgRole: models.OrgRoleMember})
+
+	NewOrganizationHandler(mockService).List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Organizations []struct {
+			ID     uint   `json:"id"`
+			Name   string `json:"name"`
+			Role   string `json:"role"`
+			Active bool   `json:"active"`
+		} `json:"organizations"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Organizations, 2)
+	assert.Equal(t, "Acme", body.Organizations[0].Name)
+	assert.Equal(t, models.OrgRoleOwner, body.Organizations[0].Role)
+	assert.False(t, body.Organizations[0].Active)
+	assert.True(t, body.Organizations[1].Active)
+}
+
+func TestOrganizationHandler_Switch(t *testing.T) {
+	tests := []struct {
+		name           string
+		param          string
+		err            error
+		expectedStatus int
+	}{
+		{name: "switched", param: "4", expectedStatus: http.StatusOK},
+		{name: "not a member", param: "5", err: services.ErrNotOrgMember, expectedStatus: http.StatusForbidden},
+		{name: "revoked session", param: "4", err: services.ErrSessionNotFound, expectedStatus: http.StatusUnauthorized},
+		{name: "invalid id", param: "acme", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockOrganizationService{}
+			if tt.expectedStatus == http.StatusOK {
+				mockService.On("Switch", uint(1), "sid", uint(4)).Return(&services.TokenPair{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			} else if tt.err != nil {
+				mockService.On("Switch", uint(1), "sid", mock.Anything).Return(nil, tt.err)
+			}
+
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("POST", "/organizations/"+tt.param+"/switch", nil))
+			c.Params = gin.Params{{Key: "id", Value: tt.param}}
+			middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 3})
+
+			NewOrganizationHandler(mockService).Switch(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "access", body["token"])
+				assert.Equal(t, float64(4), body["organization_id"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationHandler_UpdateMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		role           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "role changed", role: models.OrgRoleAdmin, expectedStatus: http.StatusOK},
+		{name: "unknown role", role: "superuser", err: services.ErrInvalidOrgRole, expectedStatus: http.StatusBadRequest},
+		{name: "not an owner", role: models.OrgRoleOwner, err: services.ErrOrgOwnerRequired, expectedStatus: http.StatusForbidden},
+		{name: "not a member", role: models.OrgRoleAdmin, err: repositories.ErrMembershipNotFound, expectedStatus: http.StatusNotFound},
+		{name: "last owner", role: models.OrgRoleMember, err: repositories.ErrLastOwner, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockOrganizationService{}
+			mockService.On("UpdateMemberRole", uint(3), models.OrgRoleAdmin, uint(2), tt.role).Return(tt.err)
+
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("PUT", "/organization/members/2", gin.H{"role": tt.role}))
+			c.Params = gin.Params{{Key: "id", Value: "2"}}
+			middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 3, OrgRole: models.OrgRoleAdmin})
+
+			NewOrganizationHandler(mockService).UpdateMember(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationHandler_Member(t *testing.T) {
+	mockService := &MockOrganizationService{}
+	mockService.On("Member", uint(3), uint(2)).Return(&models.User{
+		ID:          2,
+		Email:       "bob@example.com",
+		Memberships: []models.Membership{{OrganizationID: 3, UserID: 2, Role: models.OrgRoleMember}},
+	}, nil)
+	mockService.On("Member", uint(3), uint(9)).Return(nil, repositories.ErrUserNotFound)
+	handler := NewOrganizationHandler(mockService)
+
+	c, w := testutils.CreateTestContext(testutils.CreateTestRequest("GET", "/organization/members/2", nil))
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 3, OrgRole: models.OrgRoleMember})
+	handler.Member(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, models.OrgRoleMember, body["role"])
+
+	// Users outside the organization are not found
+	c, w = testutils.CreateTestContext(testutils.CreateTestRequest("GET", "/organization/members/9", nil))
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "sid", OrgID: 3, OrgRole: models.OrgRoleMember})
+	handler.Member(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// MockOrganizationService is a mock implementation of OrganizationServiceInterface
+type MockOrganizationService struct {
+	mock.Mock
+}
+
+// Ensure MockOrganizationService implements OrganizationServiceInterface interface
+var _ services.OrganizationServiceInterface = (*MockOrganizationService)(nil)
+
+func (m *MockOrganizationService) Create(userID uint, name string) (*models.Organization, error) {
+	args := m.Called(userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationService) ListForUser(userID uint) ([]models.Membership, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationService) Switch(userID uint, sessionID string, orgID uint) (*services.TokenPair, error) {
+	args := m.Called(userID, sessionID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockOrganizationService) Members(orgID uint, limit, offset int) ([]models.User, int64, error) {
+	args := m.Called(orgID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockOrganizationService) Member(orgID, userID uint) (*models.User, error) {
+	args := m.Called(orgID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockOrganizationService) UpdateMemberRole(orgID uint, actorRole string, userID uint, role string) error {
+	args := m.Called(orgID, actorRole, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationService) RemoveMember(orgID uint, actorRole string, userID uint) error {
+	args := m.Called(orgID, actorRole, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SessionHandler struct {
+	tokens services.TokenServiceInterface
+}
+
+func NewSessionHandler(tokens services.TokenServiceInterface) *SessionHandler {
+	return &SessionHandler{tokens: tokens}
+}
+
+// List godoc
+// @Summary List active sessions
+// @Description List the devices the current user is logged in on, most recently used first, with the user agent and IP address of the login, the IP address of the latest request and when the session was last used. The session making the request is marked current.
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{sessions=[]object{id=string,device=string,user_agent=string,ip=string,created_at=string,last_seen_at=string,expires_at=string,current=bool}} "Active sessions"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key or OAuth token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /sessions [get]
+func (h *SessionHandler) List(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	sessions, err := h.tokens.Sessions(principal.UserID)
+	if err != nil {
+		global.Logger.Error("Failed to list sessions", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(sessions))
+	for i := range sessions {
+		items = append(items, sessionResponse(&sessions[i], principal.SessionID))
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": items})
+}
+
+// Revoke godoc
+// @Summary Revoke a session
+// @Description Log the current user out on one device. The session's refresh token stops working and its access token is rejected from the next request on. Revoking the current session works like /logout.
+// @Tags Sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} object{message=string} "Session revoked"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - called with an API key or OAuth token"
+// @Failure 404 {object} object{error=string} "Session not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /sessions/{id} [delete]
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.tokens.RevokeSession(principal.UserID, id); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to revoke session", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	global.Logger.Info("Session revoked", zap.Uint("user_id", principal.UserID), zap.Bool("current", id == principal.SessionID))
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+func sessionResponse(s *models.Session, currentID string) gin.H {
+	return gin.H{
+		"id":           s.ID,
+		"device":       s.Device,
+		"user_agent":   s.UserAgent,
+		"ip":           s.IP,
+		"created_at":   s.CreatedAt,
+		"last_seen_at": s.LastSeenAt,
+		"expires_at":   s.ExpiresAt,
+		"current":      s.ID == currentID,
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"temp/middlewares"
+	"temp/models"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSessionHandler_List(t *testing.T) {
+	mockTokens := &MockTokenService{}
+	mockTokens.On("Sessions", uint(1)).Return([]models.Session{
+		{ID: "laptop", UserID: 1, Device: "Firefox on Linux", IP: "203.0.113.7", LastSeenAt: time.Now()},
+		{ID: "phone", UserID: 1, Device: "Safari on iPhone", IP: "198.51.100.4", LastSeenAt: time.Now().Add(-time.Hour)},
+	}, nil)
+
+	req := testutils.CreateTestRequest("GET", "/sessions", nil)
+	c, w := testutils.CreateTestContext(req)
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "phone"})
+
+	NewSessionHandler(mockTokens).List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Sessions []struct {
+			ID      string `json:"id"`
+			Device  string `json:"device"`
+			Current bool   `json:"current"`
+		} `json:"sessions"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Sessions, 2)
+	assert.Equal(t, "Firefox on Linux", body.Sessions[0].Device)
+	assert.False(t, body.Sessions[0].Current)
+	assert.True(t, body.Sessions[1].Current)
+	// The access token ID is not shown
+	assert.NotContains(t, w.Body.String(), "access_token_id")
+	mockTokens.AssertExpectations(t)
+}
+
+func TestSessionHandler_Revoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "session revoked", expectedStatus: http.StatusOK},
+		{name: "unknown session", err: services.ErrSessionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "store unavailable", err: errors.New("redis down"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := &MockTokenService{}
+			mockTokens.On("RevokeSession", uint(1), "phone").Return(tt.err)
+
+			req := testutils.CreateTestRequest("DELETE", "/sessions/phone", nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "id", Value: "phone"}}
+			testutils.SetUserInContext(c, 1)
+
+			NewSessionHandler(mockTokens).Revoke(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginResponse_SessionCookies(t *testing.T) {
+	config := middlewares.SessionCookieConfig{Name: "session", CSRFCookieName: "csrf_token", Path: "/", Secure: true, SameSite: http.SameSiteLaxMode}
+	omitTokens := config
+	omitTokens.OmitTokens = true
+
+	tests := []struct {
+		name          string
+		config        *middlewares.SessionCookieConfig
+		expectCookies bool
+		expectTokens  bool
+	}{
+		{name: "bearer tokens only", expectTokens: true},
+		{name: "cookie and bearer tokens", config: &config, expectCookies: true, expectTokens: true},
+		{name: "cookie only", config: &omitTokens, expectCookies: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testutils.CreateTestContext(testutils.CreateTestRequest("POST", "/login", nil))
+			if tt.config != nil {
+				middlewares.SessionCookies(*tt.config)(c)
+			}
+			result := &services.LoginResult{
+				User: &models.User{ID: 1, Email: "test@example.com"},
+				Tokens: &services.TokenPair{
+					AccessToken:   "access",
+					RefreshToken:  "refresh",
+					TokenType:     "Bearer",
+					SessionCookie: "1.session.secret",
+					CSRFToken:     "csrf",
+					SessionExpiry: time.Now().Add(time.Hour),
+				},
+			}
+
+			body := loginResponse(c, result)
+
+			assert.Equal(t, tt.expectTokens, body["token"] != nil)
+			assert.Equal(t, tt.expectCookies, body["csrf_token"] != nil)
+			assert.NotNil(t, body["user"])
+
+			cookies := w.Result().Cookies()
+			if !tt.expectCookies {
+				assert.Empty(t, cookies)
+				return
+			}
+			assert.Len(t, cookies, 2)
+			assert.Equal(t, "1.session.secret", cookies[0].Value)
+			assert.True(t, cookies[0].HttpOnly)
+			assert.True(t, cookies[0].Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+			// Scripts read the CSRF token from its cookie
+			assert.Equal(t, "csrf", cookies[1].Value)
+			assert.False(t, cookies[1].HttpOnly)
+		})
+	}
+}
+
+// MockTokenService is a mock implementation of TokenServiceInterface
+type MockTokenService struct {
+	mock.Mock
+}
+
+// Ensure MockTokenService implements TokenServiceInterface interface
+var _ services.TokenServiceInterface = (*MockTokenService)(nil)
+
+func (m *MockTokenService) IssueTokens(u *models.User, amr []string, clientIP, userAgent string) (*services.TokenPair, error) {
+	args := m.Called(u, amr, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Elevate(u *models.User, sessionID string, amr []string) (*services.TokenPair, error) {
+	args := m.Called(u, sessionID, amr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) SwitchOrganization(u *models.User, sessionID string, orgID uint) (*services.TokenPair, error) {
+	args := m.Called(u, sessionID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Refresh(refreshToken string) (*services.TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Revoke(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	args := m.Called(userID, sessionID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) RevokeAll(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) Sessions(userID uint) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeSession(userID uint, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) TouchSession(userID uint, sessionID, clientIP string) (bool, error) {
+	args := m.Called(userID, sessionID, clientIP)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenService) AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error) {
+	args := m.Called(value, clientIP)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Session), args.Get(1).(*models.User), args.Error(2)
+}
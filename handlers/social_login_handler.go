@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"temp/global"
+	"temp/services"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SocialLoginHandler struct {
+	users  services.UserServiceInterface
+	social services.SocialLoginServiceInterface
+}
+
+func NewSocialLoginHandler(users services.UserServiceInterface, social services.SocialLoginServiceInterface) *SocialLoginHandler {
+	return &SocialLoginHandler{users: users, social: social}
+}
+
+// Providers godoc
+// @Summary List identity providers
+// @Description List the external identity providers users can log in with
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} object{providers=[]string} "Configured providers"
+// @Router /auth/providers [get]
+func (h *SocialLoginHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.social.Providers()})
+}
+
+// Login godoc
+// @Summary Log in with an identity provider
+// @Description Redirect to the login page of an external identity provider. The provider redirects back to its configured redirect_url with a code and state, which are passed on to /auth/{provider}/callback.
+// @Tags Authentication
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} object{error=string} "Unknown provider"
+// @Failure 502 {object} object{error=string} "Identity provider unavailable"
+// @Router /auth/{provider}/login [get]
+func (h *SocialLoginHandler) Login(c *gin.Context) {
+	authURL, err := h.social.Begin(c.Param("provider"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Finish an identity provider login
+// @Description Exchange the code and state an identity provider redirected back with for the tokens a password login returns. The first login links the identity to the account with the same verified email, or creates a new account. When two-factor authentication is enabled the response carries mfa_required and an mfa_token to exchange at /login/mfa instead of tokens.
+// @Tags Authentication
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} object{token=string,refresh_token=string,token_type=string,expires_in=int,user=object{id=int,email=string,name=string},mfa_required=bool,mfa_token=string} "Login successful or second factor required"
+// @Failure 400 {object} object{error=string} "Bad request - missing code or state, or no email shared by the provider"
+// @Failure 401 {object} object{error=string} "Unauthorized - login cancelled, expired or refused by the provider"
+// @Failure 403 {object} object{error=string} "Forbidden - email not verified, account disabled or password reset required"
+// @Failure 404 {object} object{error=string} "Unknown provider"
+// @Failure 409 {object} object{error=string} "Conflict - an account with this email exists and cannot be linked automatically"
+// @Failure 502 {object} object{error=string} "Identity provider unavailable"
+// @Router /auth/{provider}/callback [get]
+func (h *SocialLoginHandler) Callback(c *gin.Context) {
+	// The provider reports a cancelled or refused login instead of a code
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login refused by the identity provider: " + reason})
+		return
+	}
+
+	var req struct {
+		Code  string `form:"code" binding:"required"`
+		State string `form:"state" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider := c.Param("provider")
+	result, err := h.users.LoginWithProvider(provider, req.State, req.Code, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if result.Tokens == nil {
+		global.Logger.Info("Identity provider login accepted, waiting for second factor", zap.Uint("user_id", result.User.ID))
+		c.JSON(http.StatusOK, mfaChallengeResponse(result))
+		return
+	}
+
+	global.Logger.Info("User logged in with identity provider", zap.Uint("user_id", result.User.ID), zap.String("provider", provider))
+	c.JSON(http.StatusOK, loginResponse(c, result))
+}
+
+// handleError maps social login errors to responses
+func (h *SocialLoginHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownIdentityProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidOAuthState), errors.Is(err, services.ErrIdentityProviderRejected), errors.Is(err, services.ErrInvalidIDToken):
+		global.Logger.Warn("Identity provider login rejected", zap.String("provider", c.Param("provider")), zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed"})
+	case errors.Is(err, services.ErrIdentityEmailMissing):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIdentityLinkRefused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAccountDisabled), errors.Is(err, services.ErrPasswordResetRequired), errors.Is(err, services.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIdentityProviderUnavailable):
+		global.Logger.Error("Identity provider unavailable", zap.String("provider", c.Param("provider")), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		global.Logger.Error("Identity provider login failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
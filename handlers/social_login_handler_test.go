@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"temp/models"
+	"temp/services"
+	"temp/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSocialLoginHandler_Login(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMock      func(*MockSocialLoginService)
+		expectedStatus int
+	}{
+		{
+			name: "redirects to the provider",
+			setupMock: func(mockSocial *MockSocialLoginService) {
+				mockSocial.On("Begin", "google").Return("https://accounts.example.com/authorize?state=s", nil)
+			},
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name: "unknown provider",
+			setupMock: func(mockSocial *MockSocialLoginService) {
+				mockSocial.On("Begin", "google").Return("", services.ErrUnknownIdentityProvider)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "provider unavailable",
+			setupMock: func(mockSocial *MockSocialLoginService) {
+				mockSocial.On("Begin", "google").Return("", fmt.Errorf("%w: discovery failed", services.ErrIdentityProviderUnavailable))
+			},
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSocial := &MockSocialLoginService{}
+			tt.setupMock(mockSocial)
+
+			handler := NewSocialLoginHandler(&MockUserService{}, mockSocial)
+			req := testutils.CreateTestRequest("GET", "/auth/google/login", nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "provider", Value: "google"}}
+
+			handler.Login(c)
+			c.Writer.WriteHeaderNow()
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusFound {
+				assert.Equal(t, "https://accounts.example.com/authorize?state=s", w.Header().Get("Location"))
+			}
+			mockSocial.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSocialLoginHandler_Callback(t *testing.T) {
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:  "successful login",
+			query: "?code=code&state=state",
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithProvider", "google", "state", "code", mock.Anything, mock.Anything).
+					Return(&services.LoginResult{User: user, Tokens: &services.TokenPair{AccessToken: "access", RefreshToken: "refresh"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "expired state",
+			query: "?code=code&state=state",
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithProvider", "google", "state", "code", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidOAuthState)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:  "account cannot be linked",
+			query: "?code=code&state=state",
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithProvider", "google", "state", "code", mock.Anything, mock.Anything).Return(nil, services.ErrIdentityLinkRefused)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:  "disabled account",
+			query: "?code=code&state=state",
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithProvider", "google", "state", "code", mock.Anything, mock.Anything).Return(nil, services.ErrAccountDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "login cancelled at the provider",
+			query:          "?error=access_denied&state=state",
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing code",
+			query:          "?state=state",
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewSocialLoginHandler(mockService, &MockSocialLoginService{})
+			req := testutils.CreateTestRequest("GET", "/auth/google/callback"+tt.query, nil)
+			c, w := testutils.CreateTestContext(req)
+			c.Params = gin.Params{{Key: "provider", Value: "google"}}
+
+			handler.Callback(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "access", response["token"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// MockSocialLoginService is a mock implementation of SocialLoginServiceInterface interface
+type MockSocialLoginService struct {
+	mock.Mock
+}
+
+// Ensure MockSocialLoginService implements SocialLoginServiceInterface interface
+var _ services.SocialLoginServiceInterface = (*MockSocialLoginService)(nil)
+
+func (m *MockSocialLoginService) Providers() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *MockSocialLoginService) Begin(provider string) (string, error) {
+	args := m.Called(provider)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSocialLoginService) Complete(provider, state, code string) (*models.User, error) {
+	args := m.Called(provider, state, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
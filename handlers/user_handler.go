@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"temp/global"
+	"temp/middlewares"
+	"temp/models"
+	"temp/repositories"
 	"temp/services"
 
 	"github.com/gin-gonic/gin"
@@ -28,14 +33,14 @@ func NewUserHandler(s services.UserServiceInterface) *UserHandler {
 // @Produce json
 // @Param request body object{name=string,email=string,password=string} true "User registration data"
 // @Success 201 {object} object{id=int,name=string,email=string} "User successfully registered"
-// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 400 {object} object{error=string,violations=[]services.PasswordViolation} "Bad request - validation error or password policy violations"
 // @Failure 409 {object} object{error=string} "Conflict - user already exists"
 // @Router /register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	var req struct {
 		Name     string `json:"name" binding:"required"`
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,6 +51,9 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	u, err := h.service.Register(req.Name, req.Email, req.Password)
 	if err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
 		if err.Error() == "user with this email already exists" {
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
@@ -65,14 +73,17 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. When two-factor authentication is enabled the response carries mfa_required and an mfa_token to exchange at /login/mfa instead of tokens. In the cookie auth mode the session is set as an HttpOnly cookie and the response carries a csrf_token to send in X-CSRF-Token instead of tokens.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body object{email=string,password=string} true "User login credentials"
-// @Success 200 {object} object{token=string,user=object{id=int,email=string,name=string}} "Login successful"
+// @Success 200 {object} object{token=string,refresh_token=string,token_type=string,expires_in=int,user=object{id=int,email=string,name=string},mfa_required=bool,mfa_token=string} "Login successful or second factor required"
 // @Failure 400 {object} object{error=string} "Bad request - validation error"
 // @Failure 401 {object} object{error=string} "Unauthorized - invalid credentials"
+// @Failure 403 {object} object{error=string} "Forbidden - email not verified, account disabled or password reset required"
+// @Failure 423 {object} object{error=string,retry_after=int} "Locked - too many failed attempts, see Retry-After"
+// @Failure 429 {object} object{error=string,retry_after=int} "Too many requests - retry delayed, see Retry-After"
 // @Router /login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req struct {
@@ -86,22 +97,440 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, u, err := h.service.Authenticate(req.Email, req.Password)
+	result, err := h.service.Authenticate(req.Email, req.Password, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		global.Logger.Warn("Login failed", 
 			zap.String("email", req.Email),
+			zap.String("ip", c.ClientIP()),
 			zap.Error(err),
 		)
+		if writeRetryError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrEmailNotVerified) ||
+			errors.Is(err, services.ErrAccountDisabled) ||
+			errors.Is(err, services.ErrPasswordResetRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
+	if result.Tokens == nil {
+		global.Logger.Info("Password accepted, waiting for second factor", zap.Uint("user_id", result.User.ID))
+		c.JSON(http.StatusOK, mfaChallengeResponse(result))
+		return
+	}
+
 	global.Logger.Info("User logged in successfully",
-		zap.Uint("user_id", u.ID),
-		zap.String("email", u.Email),
+		zap.Uint("user_id", result.User.ID),
+		zap.String("email", result.User.Email),
 	)
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "user": gin.H{"id": u.ID, "email": u.Email, "name": u.Name}})
+	c.JSON(http.StatusOK, loginResponse(c, result))
+}
+
+// RequestMagicLink godoc
+// @Summary Request a login link
+// @Description Email a single-use link that logs the user in without a password. The response is the same whether or not the email is registered. Requests are limited per email address.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{email=string} true "Account email"
+// @Success 200 {object} object{message=string} "Login link sent if the account exists"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 429 {object} object{error=string,retry_after=int} "Too many requests - too many links requested, see Retry-After"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /login/magic-link [post]
+func (h *UserHandler) RequestMagicLink(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RequestMagicLink(req.Email, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if writeRetryError(c, err) {
+			return
+		}
+		global.Logger.Error("Login link request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send login link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists, a login link has been sent"})
+}
+
+// MagicLinkLogin godoc
+// @Summary Log in with a login link
+// @Description Exchange the token from a login link email for the tokens a password login returns. When two-factor authentication is enabled the response carries mfa_required and an mfa_token to exchange at /login/mfa instead of tokens.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{token=string} true "Token from the login link"
+// @Success 200 {object} object{token=string,refresh_token=string,token_type=string,expires_in=int,user=object{id=int,email=string,name=string},mfa_required=bool,mfa_token=string} "Login successful or second factor required"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid, used or expired link, or link requested from another device"
+// @Failure 403 {object} object{error=string} "Forbidden - email not verified, account disabled or password reset required"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /login/magic-link/verify [post]
+func (h *UserHandler) MagicLinkLogin(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.LoginWithMagicLink(req.Token, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMagicLink), errors.Is(err, services.ErrMagicLinkClient):
+			global.Logger.Warn("Login link rejected", zap.String("ip", c.ClientIP()), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAccountDisabled), errors.Is(err, services.ErrPasswordResetRequired), errors.Is(err, services.ErrEmailNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			global.Logger.Error("Login link login failed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		}
+		return
+	}
+
+	if result.Tokens == nil {
+		global.Logger.Info("Login link accepted, waiting for second factor", zap.Uint("user_id", result.User.ID))
+		c.JSON(http.StatusOK, mfaChallengeResponse(result))
+		return
+	}
+
+	global.Logger.Info("User logged in with login link", zap.Uint("user_id", result.User.ID))
+	c.JSON(http.StatusOK, loginResponse(c, result))
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token. The refresh token is rotated on every use and reusing an old one revokes the whole session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{refresh_token=string} true "Refresh token"
+// @Success 200 {object} object{token=string,refresh_token=string,token_type=string,expires_in=int} "Tokens refreshed"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid, expired or reused refresh token"
+// @Router /token/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.service.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			global.Logger.Warn("Token refresh rejected", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Token refresh failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(pair))
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Redeem the single-use token from a verification email
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{token=string} true "Verification token"
+// @Success 200 {object} object{message=string} "Email verified successfully"
+// @Failure 400 {object} object{error=string} "Bad request - invalid, used or expired token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /verify-email [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Email verification failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link to an unverified account. The response is the same whether or not the email is registered.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{email=string} true "Account email"
+// @Success 200 {object} object{message=string} "Verification email sent if the account needs one"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /resend-verification [post]
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResendVerification(req.Email); err != nil {
+		global.Logger.Error("Resending verification email failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists and is not verified, a verification email has been sent"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the email is registered.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{email=string} true "Account email"
+// @Success 200 {object} object{message=string} "Reset email sent if the account exists"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /forgot-password [post]
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ForgotPassword(req.Email); err != nil {
+		global.Logger.Error("Password reset request failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send reset email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists, a password reset email has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the token from a reset email. Every existing session of the user is revoked.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{token=string,new_password=string} true "Reset token and new password"
+// @Success 200 {object} object{message=string} "Password reset successfully"
+// @Failure 400 {object} object{error=string,violations=[]services.PasswordViolation} "Bad request - invalid, used or expired token, or password policy violations"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /reset-password [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResetPassword(req.Token, req.NewPassword); err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Password reset failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+}
+
+// UnlockAccount godoc
+// @Summary Unlock a locked account
+// @Description Lift a login lockout with the token from an unlock email
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body object{token=string} true "Unlock token"
+// @Success 200 {object} object{message=string} "Account unlocked"
+// @Failure 400 {object} object{error=string} "Bad request - invalid, used or expired token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /unlock-account [post]
+func (h *UserHandler) UnlockAccount(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UnlockAccount(req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidUnlockToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Account unlock failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// Logout godoc
+// @Summary Log out the current session
+// @Description Revoke the presented access token and the refresh tokens of its session, and clear session cookies
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{message=string} "Logged out successfully"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	if err := h.service.Logout(principal.UserID, principal.SessionID, principal.TokenID, principal.ExpiresAt); err != nil {
+		global.Logger.Error("Logout failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	middlewares.ClearSessionCookies(c)
+	global.Logger.Info("User logged out", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// LogoutAll godoc
+// @Summary Log out everywhere
+// @Description Revoke every session of the authenticated user on all devices
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{message=string} "Logged out of all sessions"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /logout/all [post]
+func (h *UserHandler) LogoutAll(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	if err := h.service.LogoutAll(principal.UserID); err != nil {
+		global.Logger.Error("Logout everywhere failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	middlewares.ClearSessionCookies(c)
+	global.Logger.Info("User logged out of all sessions", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions"})
+}
+
+// Reauthenticate godoc
+// @Summary Reauthenticate
+// @Description Confirm the identity of the logged in user with the password, a TOTP or recovery code, or both, before a sensitive operation such as changing the password or creating an API key. Users with two-factor authentication must give a code. The session counts as recently authenticated from now on and the response carries an access token showing it; its refresh token keeps working. In the cookie auth mode the session cookie is elevated and no token is returned. Failed attempts count towards the login lockout.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{password=string,code=string} true "Password, TOTP or recovery code"
+// @Success 200 {object} object{message=string,token=string,token_type=string,expires_in=int} "Reauthenticated"
+// @Failure 400 {object} object{error=string} "Bad request - neither password nor code given"
+// @Failure 401 {object} object{error=string} "Wrong password or code, code missing with two-factor authentication enabled, or the session has ended"
+// @Failure 423 {object} object{error=string,retry_after=int} "Account temporarily locked"
+// @Failure 429 {object} object{error=string,retry_after=int} "Too many failed attempts from this IP address"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /reauthenticate [post]
+func (h *UserHandler) Reauthenticate(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Password == "" && req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password or code is required"})
+		return
+	}
+
+	pair, err := h.service.Reauthenticate(principal.UserID, principal.SessionID, req.Password, req.Code, c.ClientIP())
+	if err != nil {
+		if writeRetryError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrMFACodeRequired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSessionNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+		default:
+			global.Logger.Error("Reauthentication failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reauthenticate"})
+		}
+		return
+	}
+
+	global.Logger.Info("User reauthenticated", zap.Uint("user_id", principal.UserID))
+	resp := gin.H{"message": "reauthenticated"}
+	if config, ok := middlewares.GetSessionCookieConfig(c); !ok || !config.OmitTokens {
+		resp["token"] = pair.AccessToken
+		resp["token_type"] = pair.TokenType
+		resp["expires_in"] = pair.ExpiresIn
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // Profile godoc
@@ -111,18 +540,30 @@ func (h *UserHandler) Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} object{message=string,user_id=int} "Profile retrieved successfully"
+// @Success 200 {object} object{id=int,name=string,email=string,email_verified_at=string,display_name=string,avatar_url=string,bio=string,locale=string,timezone=string,mfa_enabled=bool,roles=[]string,created_at=string,updated_at=string} "Profile retrieved successfully"
 // @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /profile [get]
 func (h *UserHandler) Profile(c *gin.Context) {
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	u, err := h.service.GetProfile(principal.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Failed to load profile", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load profile"})
 		return
 	}
 
-	global.Logger.Info("Profile accessed", zap.Uint("user_id", userID))
-	c.JSON(http.StatusOK, gin.H{"message": "this is a protected route", "user_id": float64(userID)})
+	c.JSON(http.StatusOK, userResponse(u))
 }
 
 // UpdateProfile godoc
@@ -132,20 +573,28 @@ func (h *UserHandler) Profile(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body object{name=string} true "Profile update data"
-// @Success 200 {object} object{message=string} "Profile updated successfully"
+// @Param request body object{name=string,display_name=string,avatar_url=string,bio=string,locale=string,timezone=string} true "Profile fields to change; omitted fields are left unchanged"
+// @Success 200 {object} object{message=string,user=object{id=int,name=string,email=string,display_name=string,avatar_url=string,bio=string,locale=string,timezone=string,updated_at=string}} "Profile updated successfully"
 // @Failure 400 {object} object{error=string} "Bad request - validation error"
 // @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /profile [put]
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
 		return
 	}
 
+	// Empty strings clear the optional fields
 	var req struct {
-		Name string `json:"name" binding:"required"`
+		Name        *string `json:"name" binding:"omitempty,min=2,max=100"`
+		DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+		AvatarURL   *string `json:"avatar_url" binding:"omitempty,max=500,eq=|http_url"`
+		Bio         *string `json:"bio" binding:"omitempty,max=500"`
+		Locale      *string `json:"locale" binding:"omitempty,max=35,eq=|bcp47_language_tag"`
+		Timezone    *string `json:"timezone" binding:"omitempty,max=64,eq=|timezone"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -153,32 +602,52 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	global.Logger.Info("Profile updated", zap.Uint("user_id", userID))
-	c.JSON(http.StatusOK, gin.H{"message": "profile updated successfully"})
+	u, err := h.service.UpdateProfile(principal.UserID, services.ProfileUpdate{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Bio:         req.Bio,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Profile update failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+		return
+	}
+
+	global.Logger.Info("Profile updated", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "profile updated successfully", "user": userResponse(u)})
 }
 
 // ChangePassword godoc
 // @Summary Change user password
-// @Description Change the password of the authenticated user
+// @Description Change the password of the authenticated user. Requires a recent login or /reauthenticate.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body object{old_password=string,new_password=string} true "Password change data"
-// @Success 200 {object} object{message=string} "Password changed successfully"
-// @Failure 400 {object} object{error=string} "Bad request - validation error"
-// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Success 200 {object} object{message=string,user=object{id=int,name=string,email=string,updated_at=string}} "Password changed successfully"
+// @Failure 400 {object} object{error=string,violations=[]services.PasswordViolation} "Bad request - validation error, incorrect current password or password policy violations"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
 // @Router /change-password [post]
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	userID := getUserID(c)
-	if userID == 0 {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID"})
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
 		return
 	}
 
 	var req struct {
 		OldPassword string `json:"old_password" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -186,19 +655,121 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	global.Logger.Info("Password changed", zap.Uint("user_id", userID))
-	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+	u, err := h.service.ChangePassword(principal.UserID, req.OldPassword, req.NewPassword)
+	if err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			global.Logger.Error("Password change failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		}
+		return
+	}
+
+	global.Logger.Info("Password changed", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully", "user": userResponse(u)})
+}
+
+// ChangeEmail godoc
+// @Summary Change email address
+// @Description Move the account of the authenticated user to a new email address. The new address is unverified until the user follows the link emailed to it. Requires a recent login or /reauthenticate.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{email=string} true "New email address"
+// @Success 200 {object} object{message=string,user=object{id=int,name=string,email=string,updated_at=string}} "Email changed, verification sent"
+// @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 409 {object} object{error=string} "Conflict - email already registered"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /change-email [post]
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	u, err := h.service.ChangeEmail(principal.UserID, req.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			global.Logger.Error("Email change failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change email"})
+		}
+		return
+	}
+
+	global.Logger.Info("Email changed", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "email changed; please verify the new address", "user": userResponse(u)})
+}
+
+// DeleteAccount godoc
+// @Summary Delete own account
+// @Description End all sessions of the authenticated user and delete the account with its tokens, API keys and memberships. Requires a recent login or /reauthenticate.
+// @Tags User
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} object{message=string} "Account deleted"
+// @Failure 401 {object} object{error=string,error_description=string,max_age=int} "Unauthorized - invalid or missing token, or reauthentication required"
+// @Failure 404 {object} object{error=string} "User not found"
+// @Failure 500 {object} object{error=string} "Internal server error"
+// @Router /account [delete]
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+		return
+	}
+
+	if err := h.service.DeleteAccount(principal.UserID); err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		global.Logger.Error("Account deletion failed", zap.Uint("user_id", principal.UserID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+		return
+	}
+
+	middlewares.ClearSessionCookies(c)
+	global.Logger.Info("Account deleted", zap.Uint("user_id", principal.UserID))
+	c.JSON(http.StatusOK, gin.H{"message": "account deleted"})
 }
 
 // SetRedisKey godoc
 // @Summary Set Redis key-value pair
-// @Description Store a key-value pair in Redis cache
+// @Description Store a key-value pair in Redis cache. Requires the redis:write permission.
 // @Tags Redis
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param request body object{key=string,value=string} true "Redis key-value data"
 // @Success 200 {object} object{message=string,key=string,value=string} "Key set successfully"
 // @Failure 400 {object} object{error=string} "Bad request - validation error"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
 // @Failure 500 {object} object{error=string} "Internal server error - Redis operation failed"
 // @Router /set-redis-key [post]
 func (h *UserHandler) SetRedisKey(c *gin.Context) {
@@ -228,12 +799,15 @@ func (h *UserHandler) SetRedisKey(c *gin.Context) {
 
 // GetRedisKey godoc
 // @Summary Get Redis value by key
-// @Description Retrieve a value from Redis cache by key
+// @Description Retrieve a value from Redis cache by key. Requires the redis:read permission.
 // @Tags Redis
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param key path string true "Redis key"
 // @Success 200 {object} object{key=string,value=string} "Value retrieved successfully"
+// @Failure 401 {object} object{error=string} "Unauthorized - invalid or missing token"
+// @Failure 403 {object} object{error=string} "Forbidden - missing permission"
 // @Failure 404 {object} object{error=string} "Key not found"
 // @Failure 500 {object} object{error=string} "Internal server error - Redis operation failed"
 // @Router /get-redis-key/{key} [get]
@@ -259,25 +833,87 @@ func (h *UserHandler) GetRedisKey(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"key": key, "value": value})
 }
 
-// Helper function to extract user ID from context
-func getUserID(c *gin.Context) uint {
-	userIDRaw, exists := c.Get("user_id")
-	if !exists {
-		return 0
-	}
-
-	switch v := userIDRaw.(type) {
-	case uint:
-		return v
-	case float64:
-		return uint(v)
-	case string:
-		parsed, err := strconv.ParseUint(v, 10, 64)
-		if err != nil {
-			return 0
+// tokenResponse builds the JSON body shared by login and refresh responses
+func tokenResponse(pair *services.TokenPair) gin.H {
+	return gin.H{
+		"token":         pair.AccessToken,
+		"refresh_token": pair.RefreshToken,
+		"token_type":    pair.TokenType,
+		"expires_in":    pair.ExpiresIn,
+	}
+}
+
+// loginResponse builds the JSON body of a completed login. Where cookie
+// sessions are enabled it also sets the session cookies and returns the
+// CSRF token, leaving out the bearer tokens if so configured.
+func loginResponse(c *gin.Context, result *services.LoginResult) gin.H {
+	resp := tokenResponse(result.Tokens)
+	pair := result.Tokens
+	if middlewares.SetSessionCookies(c, pair.SessionCookie, pair.CSRFToken, pair.SessionExpiry) {
+		if config, _ := middlewares.GetSessionCookieConfig(c); config.OmitTokens {
+			resp = gin.H{}
 		}
-		return uint(parsed)
-	default:
-		return 0
+		resp["csrf_token"] = pair.CSRFToken
+	}
+	resp["user"] = gin.H{"id": result.User.ID, "email": result.User.Email, "name": result.User.Name}
+	return resp
+}
+
+// mfaChallengeResponse builds the JSON body of a login that waits for the
+// second factor
+func mfaChallengeResponse(result *services.LoginResult) gin.H {
+	return gin.H{
+		"mfa_required": true,
+		"mfa_token":    result.MFAToken,
+		"expires_in":   result.MFAExpiresIn,
+	}
+}
+
+// writeRetryError answers a *services.RetryError with 423 for locked
+// accounts and 429 otherwise, along with Retry-After. It reports false
+// when err is not a retry error.
+func writeRetryError(c *gin.Context, err error) bool {
+	var retry *services.RetryError
+	if !errors.As(err, &retry) {
+		return false
+	}
+	status := http.StatusTooManyRequests
+	if errors.Is(err, services.ErrAccountLocked) {
+		status = http.StatusLocked
+	}
+	// Round up so a client waiting exactly Retry-After is let through
+	seconds := int64((retry.RetryAfter + time.Second - 1) / time.Second)
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(status, gin.H{"error": retry.Err.Error(), "retry_after": seconds})
+	return true
+}
+
+// userResponse builds the public JSON representation of a user
+func userResponse(u *models.User) gin.H {
+	return gin.H{
+		"id":                u.ID,
+		"name":              u.Name,
+		"email":             u.Email,
+		"email_verified_at": u.EmailVerifiedAt,
+		"display_name":      u.DisplayName,
+		"avatar_url":        u.AvatarURL,
+		"bio":               u.Bio,
+		"locale":            u.Locale,
+		"timezone":          u.Timezone,
+		"mfa_enabled":       u.MFAEnabledAt != nil,
+		"roles":             u.RoleNames(),
+		"created_at":        u.CreatedAt,
+		"updated_at":        u.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
+
+// writePasswordPolicyError answers with the rules a refused password
+// breaks. It reports false when err is not a password policy error.
+func writePasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrPasswordPolicy.Error(), "violations": policyErr.Violations})
+	return true
+}
@@ -2,17 +2,30 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 	"testing"
+	"time"
+	"temp/global"
+	"temp/middlewares"
 	"temp/models"
+	"temp/repositories"
 	"temp/services"
 	"temp/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 )
 
+func TestMain(m *testing.M) {
+	// Handlers log through the global logger
+	global.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
 func TestUserHandler_Register(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -57,6 +70,29 @@ func TestUserHandler_Register(t *testing.T) {
 				"error": mock.AnythingOfType("string"),
 			},
 		},
+		{
+			name: "password violates the policy",
+			requestBody: gin.H{
+				"name":     "Test User",
+				"email":    "test@example.com",
+				"password": "testuser",
+			},
+			setupMock: func(mockService *MockUserService) {
+				err := &services.PasswordPolicyError{Violations: []services.PasswordViolation{
+					{Rule: services.PasswordRuleDigit, Message: "must contain a digit"},
+					{Rule: services.PasswordRulePersonalInfo, Message: "must not contain your name or email"},
+				}}
+				mockService.On("Register", "Test User", "test@example.com", "testuser").Return(nil, err)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: gin.H{
+				"error": "password does not meet the password policy",
+				"violations": []interface{}{
+					map[string]interface{}{"rule": "digit", "message": "must contain a digit"},
+					map[string]interface{}{"rule": "personal_info", "message": "must not contain your name or email"},
+				},
+			},
+		},
 		{
 			name: "service error",
 			requestBody: gin.H{
@@ -95,6 +131,9 @@ func TestUserHandler_Register(t *testing.T) {
 				assert.Equal(t, tt.expectedBody["id"], response["id"])
 				assert.Equal(t, tt.expectedBody["name"], response["name"])
 				assert.Equal(t, tt.expectedBody["email"], response["email"])
+			} else if violations, ok := tt.expectedBody["violations"]; ok {
+				assert.Equal(t, tt.expectedBody["error"], response["error"])
+				assert.Equal(t, violations, response["violations"])
 			} else {
 				assert.Contains(t, response, "error")
 			}
@@ -124,11 +163,13 @@ func TestUserHandler_Login(t *testing.T) {
 					Name:  "Test User",
 					Email: "test@example.com",
 				}
-				mockService.On("Authenticate", "test@example.com", "password123").Return("test-token", expectedUser, nil)
+				pair := &services.TokenPair{AccessToken: "test-token", RefreshToken: "test-refresh", TokenType: "Bearer", ExpiresIn: 900}
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(&services.LoginResult{User: expectedUser, Tokens: pair}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: gin.H{
-				"token": "test-token",
+				"token":         "test-token",
+				"refresh_token": "test-refresh",
 				"user": gin.H{
 					"id":    float64(1),
 					"email": "test@example.com",
@@ -143,13 +184,89 @@ func TestUserHandler_Login(t *testing.T) {
 				"password": "wrongpassword",
 			},
 			setupMock: func(mockService *MockUserService) {
-				mockService.On("Authenticate", "test@example.com", "wrongpassword").Return("", nil, assert.AnError)
+				mockService.On("Authenticate", "test@example.com", "wrongpassword", mock.Anything, mock.Anything).Return(nil, assert.AnError)
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: gin.H{
 				"error": "invalid credentials",
 			},
 		},
+		{
+			name: "unverified email",
+			requestBody: gin.H{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(nil, services.ErrEmailNotVerified)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: gin.H{
+				"error": "email not verified",
+			},
+		},
+		{
+			name: "disabled account",
+			requestBody: gin.H{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(nil, services.ErrAccountDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: gin.H{
+				"error": "account is disabled",
+			},
+		},
+		{
+			name: "locked account",
+			requestBody: gin.H{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			setupMock: func(mockService *MockUserService) {
+				err := &services.RetryError{Err: services.ErrAccountLocked, RetryAfter: 90 * time.Second}
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(nil, err)
+			},
+			expectedStatus: http.StatusLocked,
+			expectedBody: gin.H{
+				"error":       "account is temporarily locked",
+				"retry_after": float64(90),
+			},
+		},
+		{
+			name: "retry delayed",
+			requestBody: gin.H{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			setupMock: func(mockService *MockUserService) {
+				err := &services.RetryError{Err: services.ErrTooManyLoginAttempts, RetryAfter: 1500 * time.Millisecond}
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(nil, err)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody: gin.H{
+				"error":       "too many failed login attempts",
+				"retry_after": float64(2),
+			},
+		},
+		{
+			name: "second factor required",
+			requestBody: gin.H{
+				"email":    "test@example.com",
+				"password": "password123",
+			},
+			setupMock: func(mockService *MockUserService) {
+				result := &services.LoginResult{User: &models.User{ID: 1}, MFAToken: "challenge", MFAExpiresIn: 300}
+				mockService.On("Authenticate", "test@example.com", "password123", mock.Anything, mock.Anything).Return(result, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: gin.H{
+				"mfa_required": true,
+				"mfa_token":    "challenge",
+			},
+		},
 		{
 			name: "invalid request body",
 			requestBody: gin.H{
@@ -185,7 +302,16 @@ func TestUserHandler_Login(t *testing.T) {
 			
 			if tt.name == "successful login" {
 				assert.Equal(t, tt.expectedBody["token"], response["token"])
+				assert.Equal(t, tt.expectedBody["refresh_token"], response["refresh_token"])
 				assert.NotNil(t, response["user"])
+			} else if tt.name == "second factor required" {
+				assert.Equal(t, tt.expectedBody["mfa_required"], response["mfa_required"])
+				assert.Equal(t, tt.expectedBody["mfa_token"], response["mfa_token"])
+				assert.NotContains(t, response, "token")
+			} else if retryAfter, ok := tt.expectedBody["retry_after"]; ok {
+				assert.Equal(t, tt.expectedBody["error"], response["error"])
+				assert.Equal(t, retryAfter, response["retry_after"])
+				assert.Equal(t, fmt.Sprint(retryAfter), w.Header().Get("Retry-After"))
 			} else {
 				assert.Contains(t, response, "error")
 			}
@@ -195,102 +321,950 @@ func TestUserHandler_Login(t *testing.T) {
 	}
 }
 
-func TestUserHandler_Profile(t *testing.T) {
+func TestUserHandler_RefreshToken(t *testing.T) {
 	tests := []struct {
 		name           string
-		userID         interface{}
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
 		expectedStatus int
-		expectedBody   gin.H
 	}{
 		{
-			name:           "successful profile access",
-			userID:         uint(1),
-			expectedStatus: http.StatusOK,
-			expectedBody: gin.H{
-				"message":  "this is a protected route",
-				"user_id":  float64(1),
+			name:        "successful refresh",
+			requestBody: gin.H{"refresh_token": "old-refresh"},
+			setupMock: func(mockService *MockUserService) {
+				pair := &services.TokenPair{AccessToken: "new-token", RefreshToken: "new-refresh", TokenType: "Bearer", ExpiresIn: 900}
+				mockService.On("RefreshTokens", "old-refresh").Return(pair, nil)
 			},
+			expectedStatus: http.StatusOK,
 		},
 		{
-			name:           "no user in context",
-			userID:         nil,
-			expectedStatus: http.StatusUnauthorized,
-			expectedBody: gin.H{
-				"error": "no user in context",
+			name:        "reused refresh token",
+			requestBody: gin.H{"refresh_token": "old-refresh"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("RefreshTokens", "old-refresh").Return(nil, services.ErrRefreshTokenReused)
 			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing refresh token",
+			requestBody:    gin.H{},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/token/refresh", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.RefreshToken(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response gin.H
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "new-token", response["token"])
+				assert.Equal(t, "new-refresh", response["refresh_token"])
+			} else {
+				assert.Contains(t, response, "error")
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
 		{
-			name:           "user ID as string",
-			userID:         "123",
+			name:        "successful verification",
+			requestBody: gin.H{"token": "verify-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("VerifyEmail", "verify-token").Return(nil)
+			},
 			expectedStatus: http.StatusOK,
-			expectedBody: gin.H{
-				"message":  "this is a protected route",
-				"user_id":  float64(123),
+		},
+		{
+			name:        "invalid token",
+			requestBody: gin.H{"token": "verify-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("VerifyEmail", "verify-token").Return(services.ErrInvalidVerificationToken)
 			},
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:           "user ID as float64",
-			userID:         float64(456),
-			expectedStatus: http.StatusOK,
-			expectedBody: gin.H{
-				"message":  "this is a protected route",
-				"user_id":  float64(456),
+			name:        "service error",
+			requestBody: gin.H{"token": "verify-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("VerifyEmail", "verify-token").Return(assert.AnError)
 			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "missing token",
+			requestBody:    gin.H{},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
 			handler := NewUserHandler(mockService)
-			
-			req := testutils.CreateTestRequest("GET", "/profile", nil)
+			req := testutils.CreateTestRequest("POST", "/verify-email", tt.requestBody)
 			c, w := testutils.CreateTestContext(req)
 
-			if tt.userID != nil {
-				c.Set("user_id", tt.userID)
-			}
+			handler.VerifyEmail(c)
 
-			handler.Profile(c)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_ResendVerification(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "verification email sent",
+			requestBody: gin.H{"email": "test@example.com"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ResendVerification", "test@example.com").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid email",
+			requestBody:    gin.H{"email": "not-an-email"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/resend-verification", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.ResendVerification(c)
 
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			
-			var response gin.H
-			err := json.Unmarshal(w.Body.Bytes(), &response)
-			assert.NoError(t, err)
-			
-			if tt.name == "successful profile access" || tt.name == "user ID as string" || tt.name == "user ID as float64" {
-				assert.Equal(t, tt.expectedBody["message"], response["message"])
-				assert.Equal(t, tt.expectedBody["user_id"], response["user_id"])
-			} else {
-				assert.Equal(t, tt.expectedBody["error"], response["error"])
-			}
+			mockService.AssertExpectations(t)
 		})
 	}
 }
 
-// MockUserService is a mock implementation of UserServiceInterface interface
-type MockUserService struct {
-	mock.Mock
+func TestUserHandler_ForgotPassword(t *testing.T) {
+	mockService := &MockUserService{}
+	mockService.On("ForgotPassword", "test@example.com").Return(nil)
+
+	handler := NewUserHandler(mockService)
+	req := testutils.CreateTestRequest("POST", "/forgot-password", gin.H{"email": "test@example.com"})
+	c, w := testutils.CreateTestContext(req)
+
+	handler.ForgotPassword(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
 }
 
-// Ensure MockUserService implements UserServiceInterface interface
-var _ services.UserServiceInterface = (*MockUserService)(nil)
+func TestUserHandler_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful reset",
+			requestBody: gin.H{"token": "reset-token", "new_password": "newpassword123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ResetPassword", "reset-token", "newpassword123").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid token",
+			requestBody: gin.H{"token": "reset-token", "new_password": "newpassword123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ResetPassword", "reset-token", "newpassword123").Return(services.ErrInvalidResetToken)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "password violates the policy",
+			requestBody: gin.H{"token": "reset-token", "new_password": "123"},
+			setupMock: func(mockService *MockUserService) {
+				err := &services.PasswordPolicyError{Violations: []services.PasswordViolation{{Rule: services.PasswordRuleMinLength, Message: "must be at least 8 characters long"}}}
+				mockService.On("ResetPassword", "reset-token", "123").Return(err)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
 
-func (m *MockUserService) Register(name, email, password string) (*models.User, error) {
-	args := m.Called(name, email, password)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/reset-password", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.ResetPassword(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
 	}
-	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) Authenticate(email, password string) (string, *models.User, error) {
-	args := m.Called(email, password)
-	if args.Get(1) == nil {
-		return args.String(0), nil, args.Error(2)
+func TestUserHandler_UnlockAccount(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful unlock",
+			requestBody: gin.H{"token": "unlock-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("UnlockAccount", "unlock-token").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid token",
+			requestBody: gin.H{"token": "unlock-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("UnlockAccount", "unlock-token").Return(services.ErrInvalidUnlockToken)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing token",
+			requestBody:    gin.H{},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/unlock-account", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.UnlockAccount(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_RequestMagicLink(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "link sent",
+			requestBody: gin.H{"email": "test@example.com"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("RequestMagicLink", "test@example.com", mock.Anything, mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "too many links",
+			requestBody: gin.H{"email": "test@example.com"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("RequestMagicLink", "test@example.com", mock.Anything, mock.Anything).
+					Return(&services.RetryError{Err: services.ErrTooManyMagicLinks, RetryAfter: time.Minute})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "invalid email",
+			requestBody:    gin.H{"email": "not-an-email"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/login/magic-link", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.RequestMagicLink(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusTooManyRequests {
+				assert.Equal(t, "60", w.Header().Get("Retry-After"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_MagicLinkLogin(t *testing.T) {
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful login",
+			requestBody: gin.H{"token": "login-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithMagicLink", "login-token", mock.Anything, mock.Anything).
+					Return(&services.LoginResult{User: user, Tokens: &services.TokenPair{AccessToken: "access", RefreshToken: "refresh"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "link from another device",
+			requestBody: gin.H{"token": "login-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithMagicLink", "login-token", mock.Anything, mock.Anything).Return(nil, services.ErrMagicLinkClient)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "disabled account",
+			requestBody: gin.H{"token": "login-token"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("LoginWithMagicLink", "login-token", mock.Anything, mock.Anything).Return(nil, services.ErrAccountDisabled)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing token",
+			requestBody:    gin.H{},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/login/magic-link/verify", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+
+			handler.MagicLinkLogin(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "access", response["token"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_Logout(t *testing.T) {
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		name           string
+		authenticated  bool
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:          "successful logout",
+			authenticated: true,
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Logout", uint(1), "session-1", "jti-1", expiresAt).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no user in context",
+			authenticated:  false,
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "service error",
+			authenticated: true,
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Logout", uint(1), "session-1", "jti-1", expiresAt).Return(assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/logout", nil)
+			c, w := testutils.CreateTestContext(req)
+			if tt.authenticated {
+				middlewares.SetPrincipal(c, &middlewares.Principal{
+					UserID:    1,
+					SessionID: "session-1",
+					TokenID:   "jti-1",
+					ExpiresAt: expiresAt,
+				})
+			}
+
+			handler.Logout(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_LogoutAll(t *testing.T) {
+	mockService := &MockUserService{}
+	mockService.On("LogoutAll", uint(1)).Return(nil)
+
+	handler := NewUserHandler(mockService)
+	req := testutils.CreateTestRequest("POST", "/logout/all", nil)
+	c, w := testutils.CreateTestContext(req)
+	testutils.SetUserInContext(c, 1)
+
+	handler.LogoutAll(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_Reauthenticate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           gin.H
+		setupMock      func(*MockUserService)
+		expectedStatus int
+		expectToken    bool
+	}{
+		{
+			name: "password accepted",
+			body: gin.H{"password": "password123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Reauthenticate", uint(1), "session-1", "password123", "", mock.Anything).Return(&services.TokenPair{AccessToken: "elevated", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectToken:    true,
+		},
+		{
+			name: "wrong code",
+			body: gin.H{"code": "000000"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Reauthenticate", uint(1), "session-1", "", "000000", mock.Anything).Return(nil, services.ErrInvalidMFACode)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "locked out",
+			body: gin.H{"password": "password123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("Reauthenticate", uint(1), "session-1", "password123", "", mock.Anything).Return(nil, &services.RetryError{Err: services.ErrAccountLocked, RetryAfter: time.Minute})
+			},
+			expectedStatus: http.StatusLocked,
+		},
+		{
+			name:           "nothing given",
+			body:           gin.H{},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			req := testutils.CreateTestRequest("POST", "/reauthenticate", tt.body)
+			c, w := testutils.CreateTestContext(req)
+			middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, SessionID: "session-1"})
+
+			NewUserHandler(mockService).Reauthenticate(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectToken, body["token"] == "elevated")
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_Profile(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *middlewares.Principal
+		setupMock      func(*MockUserService)
+		expectedStatus int
+		expectedBody   gin.H
+	}{
+		{
+			name:      "successful profile access",
+			principal: &middlewares.Principal{UserID: 1},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("GetProfile", uint(1)).Return(&models.User{
+					ID:          1,
+					Name:        "Test User",
+					Email:       "test@example.com",
+					DisplayName: "Tester",
+					Timezone:    "Europe/Berlin",
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: gin.H{
+				"id":           float64(1),
+				"email":        "test@example.com",
+				"display_name": "Tester",
+				"timezone":     "Europe/Berlin",
+			},
+		},
+		{
+			name:      "deleted user",
+			principal: &middlewares.Principal{UserID: 1},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("GetProfile", uint(1)).Return(nil, repositories.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody: gin.H{
+				"error": "user not found",
+			},
+		},
+		{
+			name:           "no user in context",
+			principal:      nil,
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody: gin.H{
+				"error": "no user in context",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+			handler := NewUserHandler(mockService)
+
+			req := testutils.CreateTestRequest("GET", "/profile", nil)
+			c, w := testutils.CreateTestContext(req)
+
+			if tt.principal != nil {
+				middlewares.SetPrincipal(c, tt.principal)
+			}
+
+			handler.Profile(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response gin.H
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			for key, value := range tt.expectedBody {
+				assert.Equal(t, value, response[key])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_UpdateProfile(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful update",
+			requestBody: gin.H{"name": "New Name"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("UpdateProfile", uint(1), mock.MatchedBy(func(u services.ProfileUpdate) bool {
+					return *u.Name == "New Name" && u.Bio == nil
+				})).Return(&models.User{ID: 1, Name: "New Name", Email: "test@example.com"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "user not found",
+			requestBody: gin.H{"name": "New Name"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("UpdateProfile", uint(1), mock.AnythingOfType("services.ProfileUpdate")).Return(nil, repositories.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "clearing optional fields",
+			requestBody: gin.H{"avatar_url": "", "timezone": ""},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("UpdateProfile", uint(1), mock.MatchedBy(func(u services.ProfileUpdate) bool {
+					return *u.AvatarURL == "" && *u.Timezone == "" && u.Name == nil
+				})).Return(&models.User{ID: 1, Name: "New Name"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "name too short",
+			requestBody:    gin.H{"name": "A"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid avatar url",
+			requestBody:    gin.H{"avatar_url": "javascript:alert(1)"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown timezone",
+			requestBody:    gin.H{"timezone": "Mars/Olympus"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("PUT", "/profile", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.UpdateProfile(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response gin.H
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "New Name", response["user"].(map[string]interface{})["name"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_ChangeEmail(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful change",
+			requestBody: gin.H{"email": "new@example.com"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ChangeEmail", uint(1), "new@example.com").Return(&models.User{ID: 1, Email: "new@example.com"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid email",
+			requestBody:    gin.H{"email": "not-an-email"},
+			setupMock:      func(mockService *MockUserService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "email taken",
+			requestBody: gin.H{"email": "taken@example.com"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ChangeEmail", uint(1), "taken@example.com").Return(nil, services.ErrUserAlreadyExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/change-email", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.ChangeEmail(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserHandler_DeleteAccount(t *testing.T) {
+	mockService := &MockUserService{}
+	mockService.On("DeleteAccount", uint(1)).Return(nil)
+
+	handler := NewUserHandler(mockService)
+	req := testutils.CreateTestRequest("DELETE", "/account", nil)
+	c, w := testutils.CreateTestContext(req)
+	testutils.SetUserInContext(c, 1)
+
+	handler.DeleteAccount(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func(*MockUserService)
+		expectedStatus int
+	}{
+		{
+			name:        "successful change",
+			requestBody: gin.H{"old_password": "password123", "new_password": "newpassword123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ChangePassword", uint(1), "password123", "newpassword123").Return(&models.User{ID: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "incorrect current password",
+			requestBody: gin.H{"old_password": "wrongpassword", "new_password": "newpassword123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ChangePassword", uint(1), "wrongpassword", "newpassword123").Return(nil, services.ErrIncorrectPassword)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "reused password",
+			requestBody: gin.H{"old_password": "password123", "new_password": "password123"},
+			setupMock: func(mockService *MockUserService) {
+				err := &services.PasswordPolicyError{Violations: []services.PasswordViolation{{Rule: services.PasswordRuleReused, Message: "must not be one of your last 5 passwords"}}}
+				mockService.On("ChangePassword", uint(1), "password123", "password123").Return(nil, err)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "service error",
+			requestBody: gin.H{"old_password": "password123", "new_password": "newpassword123"},
+			setupMock: func(mockService *MockUserService) {
+				mockService.On("ChangePassword", uint(1), "password123", "newpassword123").Return(nil, assert.AnError)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockUserService{}
+			tt.setupMock(mockService)
+
+			handler := NewUserHandler(mockService)
+			req := testutils.CreateTestRequest("POST", "/change-password", tt.requestBody)
+			c, w := testutils.CreateTestContext(req)
+			testutils.SetUserInContext(c, 1)
+
+			handler.ChangePassword(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// MockUserService is a mock implementation of UserServiceInterface interface
+type MockUserService struct {
+	mock.Mock
+}
+
+// Ensure MockUserService implements UserServiceInterface interface
+var _ services.UserServiceInterface = (*MockUserService)(nil)
+
+func (m *MockUserService) Register(name, email, password string) (*models.User, error) {
+	args := m.Called(name, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) RegisterVerified(name, email, password string, create func(u *models.User) error) (*models.User, error) {
+	args := m.Called(name, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	u := args.Get(0).(*models.User)
+	if err := create(u); err != nil {
+		return nil, err
+	}
+	return u, args.Error(1)
+}
+
+func (m *MockUserService) Authenticate(email, password, clientIP, userAgent string) (*services.LoginResult, error) {
+	args := m.Called(email, password, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) RefreshTokens(refreshToken string) (*services.TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.TokenPair), args.Error(1)
+}
+
+func (m *MockUserService) Logout(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	args := m.Called(userID, sessionID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LogoutAll(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResendVerification(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForgotPassword(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetPassword(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UnlockAccount(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestMagicLink(email, clientIP, userAgent string) error {
+	args := m.Called(email, clientIP, userAgent)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LoginWithMagicLink(token, clientIP, userAgent string) (*services.LoginResult, error) {
+	args := m.Called(token, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) LoginWithProvider(provider, state, code, clientIP, userAgent string) (*services.LoginResult, error) {
+	args := m.Called(provider, state, code, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) GetProfile(userID uint) (*models.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(userID uint, update services.ProfileUpdate) (*models.User, error) {
+	args := m.Called(userID, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(userID uint, oldPassword, newPassword string) (*models.User, error) {
+	args := m.Called(userID, oldPassword, newPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangeEmail(userID uint, email string) (*models.User, error) {
+	args := m.Called(userID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) DeleteAccount(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) Reauthenticate(userID uint, sessionID, password, code, clientIP string) (*services.TokenPair, error) {
+	args := m.Called(userID, sessionID, password, code, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-	return args.String(0), args.Get(1).(*models.User), args.Error(2)
+	return args.Get(0).(*services.TokenPair), args.Error(1)
 }
 
 func TestNewUserHandler(t *testing.T) {
@@ -17,7 +17,7 @@
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and a JWT access token or an API key.
 
 package main
 
@@ -29,6 +29,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"temp/cli"
 	"temp/config"
@@ -38,6 +39,7 @@ import (
 	"temp/repositories"
 	"temp/routes"
 	"temp/services"
+	"temp/utils"
 
 	"go.uber.org/zap"
 )
@@ -91,13 +93,93 @@ func main() {
 
 	// Initialize repositories
 	global.Logger.Info("Initializing repositories, services, and handlers...")
-	userRepo := repositories.NewUserRepo()
-	if err := userRepo.Migrate(); err != nil {
+	roleRepo := repositories.NewRoleRepo()
+	if err := roleRepo.Migrate(); err != nil {
 		global.Logger.Fatal("Migration failed", zap.Error(err))
 	}
+	// Users are only visible per organization, except to the system paths
+	// that work across organizations: authentication and administration
+	authUserRepo := repositories.NewAuthUserRepo()
+	adminUserRepo := repositories.NewAdminUserRepo()
+	if err := adminUserRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	tokenRepo := repositories.NewTokenRepo()
+	if err := tokenRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	oneTimeTokenRepo := repositories.NewOneTimeTokenRepo()
+	if err := oneTimeTokenRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepo()
+	if err := recoveryCodeRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	apiKeyRepo := repositories.NewAPIKeyRepo()
+	if err := apiKeyRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepo()
+	if err := passwordHistoryRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	linkedIdentityRepo := repositories.NewLinkedIdentityRepo()
+	if err := linkedIdentityRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	oauthClientRepo := repositories.NewOAuthClientRepo()
+	if err := oauthClientRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	oauthConsentRepo := repositories.NewOAuthConsentRepo()
+	if err := oauthConsentRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	oauthTokenRepo := repositories.NewOAuthTokenRepo()
+	if err := oauthTokenRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	orgRepo := repositories.NewOrganizationRepo()
+	if err := orgRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	invitationRepo := repositories.NewInvitationRepo()
+	if err := invitationRepo.Migrate(); err != nil {
+		global.Logger.Fatal("Migration failed", zap.Error(err))
+	}
+	sessionStore := repositories.NewSessionStore()
+	tokenDenylist := repositories.NewTokenDenylist()
+	loginAttemptStore := repositories.NewLoginAttemptStore()
+	rateLimitStore := repositories.NewRateLimitStore()
+	oauthStateStore := repositories.NewOAuthStateStore()
+	authorizationCodeStore := repositories.NewAuthorizationCodeStore()
+
+	// Load JWT signing keys and rotate them in the background
+	keys, err := utils.NewKeyManager(
+		cfg.JWT.Algorithm,
+		cfg.JWT.KeysDir,
+		cfg.JWT.Secret,
+		time.Duration(cfg.JWT.KeyRotationHours)*time.Hour,
+		time.Duration(cfg.JWT.KeyRetentionHours)*time.Hour,
+	)
+	if err != nil {
+		global.Logger.Fatal("Failed to load JWT signing keys", zap.Error(err))
+	}
+	keys.StartRotation(time.Duration(cfg.JWT.KeyReloadMinutes) * time.Minute)
+
+	jwtManager, err := utils.NewTokenManager(
+		keys,
+		cfg.JWT.Issuer,
+		cfg.JWT.Audience,
+		time.Duration(cfg.JWT.ClockSkewSeconds)*time.Second,
+		cfg.JWT.AllowedAlgorithms,
+	)
+	if err != nil {
+		global.Logger.Fatal("Invalid JWT configuration", zap.Error(err))
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpirationHours)
 	emailService := services.NewEmailService(
 		cfg.Email.Enabled,
 		cfg.Email.SMTPHost,
@@ -105,14 +187,127 @@ func main() {
 		cfg.Email.Username,
 		cfg.Email.Password,
 		cfg.Email.From,
+		cfg.Email.AppURL,
 	)
+	// Highest work factors of stored hashes, and algorithm and parameters
+	// of new password hashes
+	utils.SetHashCostLimits(utils.HashCostLimits(cfg.PasswordHashing.Limits))
+	switch cfg.PasswordHashing.Algorithm {
+	case utils.HashBcrypt:
+		utils.SetPasswordHasher(&utils.BcryptHasher{Cost: cfg.PasswordHashing.Bcrypt.Cost})
+	case utils.HashScrypt:
+		utils.SetPasswordHasher(&utils.ScryptHasher{
+			LogN:       cfg.PasswordHashing.Scrypt.LogN,
+			R:          cfg.PasswordHashing.Scrypt.R,
+			P:          cfg.PasswordHashing.Scrypt.P,
+			SaltLength: utils.DefaultScrypt.SaltLength,
+			KeyLength:  utils.DefaultScrypt.KeyLength,
+		})
+	default:
+		utils.SetPasswordHasher(&utils.Argon2idHasher{
+			MemoryKiB:   cfg.PasswordHashing.Argon2id.MemoryKiB,
+			Iterations:  cfg.PasswordHashing.Argon2id.Iterations,
+			Parallelism: cfg.PasswordHashing.Argon2id.Parallelism,
+			SaltLength:  utils.DefaultArgon2id.SaltLength,
+			KeyLength:   utils.DefaultArgon2id.KeyLength,
+		})
+	}
+
+	// Breached-password list, checked when passwords are set
+	var breachedPasswords *utils.BloomFilter
+	if cfg.PasswordPolicy.BreachedFilterPath != "" {
+		breachedPasswords, err = utils.LoadBloomFilter(cfg.PasswordPolicy.BreachedFilterPath)
+		if err != nil {
+			global.Logger.Fatal("Failed to load breached password filter", zap.Error(err))
+		}
+	}
+	passwordPolicyService := services.NewPasswordPolicyService(passwordHistoryRepo, services.PasswordPolicy{
+		MinLength:          cfg.PasswordPolicy.MinLength,
+		MaxLength:          cfg.PasswordPolicy.MaxLength,
+		RequireUpper:       cfg.PasswordPolicy.RequireUpper,
+		RequireLower:       cfg.PasswordPolicy.RequireLower,
+		RequireDigit:       cfg.PasswordPolicy.RequireDigit,
+		RequireSymbol:      cfg.PasswordPolicy.RequireSymbol,
+		History:            cfg.PasswordPolicy.History,
+		RejectPersonalInfo: cfg.PasswordPolicy.RejectPersonalInfo,
+	}, breachedPasswords)
+	tokenService := services.NewTokenService(authUserRepo, tokenRepo, sessionStore, tokenDenylist, jwtManager, cfg.JWT.AccessExpirationMinutes, cfg.JWT.RefreshExpirationHours)
+	verificationService := services.NewVerificationService(authUserRepo, oneTimeTokenRepo, emailService, cfg.Auth.VerificationTokenTTLHours)
+	passwordResetService := services.NewPasswordResetService(authUserRepo, oneTimeTokenRepo, tokenService, emailService, passwordPolicyService, cfg.Auth.PasswordResetTTLMinutes)
+	lockoutService := services.NewLockoutService(loginAttemptStore, authUserRepo, oneTimeTokenRepo, emailService, services.LockoutPolicy{
+		AccountThreshold: cfg.Lockout.AccountThreshold,
+		IPThreshold:      cfg.Lockout.IPThreshold,
+		Window:           time.Duration(cfg.Lockout.WindowMinutes) * time.Minute,
+		LockoutDuration:  time.Duration(cfg.Lockout.LockoutMinutes) * time.Minute,
+		DelayAfter:       cfg.Lockout.DelayAfter,
+		BaseDelay:        time.Duration(cfg.Lockout.BaseDelaySeconds) * time.Second,
+		MaxDelay:         time.Duration(cfg.Lockout.MaxDelaySeconds) * time.Second,
+		UnlockTokenTTL:   time.Duration(cfg.Lockout.UnlockTokenTTLMinutes) * time.Minute,
+	})
+	mfaService := services.NewMFAService(authUserRepo, oneTimeTokenRepo, recoveryCodeRepo, tokenService, lockoutService, cfg.Auth.MFAIssuer, cfg.Auth.MFAChallengeTTLMinutes)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, authUserRepo)
+	roleService := services.NewRoleService(roleRepo, adminUserRepo)
+	if err := roleService.EnsureDefaultRoles(); err != nil {
+		global.Logger.Fatal("Failed to create default roles", zap.Error(err))
+	}
+	adminService := services.NewAdminService(adminUserRepo, tokenService, passwordResetService)
+	magicLinkService := services.NewMagicLinkService(authUserRepo, oneTimeTokenRepo, rateLimitStore, emailService, services.MagicLinkPolicy{
+		TTL:         time.Duration(cfg.MagicLink.TTLMinutes) * time.Minute,
+		MaxRequests: cfg.MagicLink.MaxRequests,
+		Window:      time.Duration(cfg.MagicLink.WindowMinutes) * time.Minute,
+		BindClient:  cfg.MagicLink.BindClient,
+	})
+	identityProviders := make(map[string]services.IdentityProvider)
+	for name, provider := range cfg.OAuth.Providers {
+		if !provider.Enabled {
+			continue
+		}
+		identityProviders[name] = services.NewOAuthProvider(services.OAuthProviderConfig{
+			ClientID:           provider.ClientID,
+			ClientSecret:       provider.ClientSecret,
+			RedirectURL:        provider.RedirectURL,
+			Scopes:             provider.Scopes,
+			Issuer:             provider.Issuer,
+			AuthURL:            provider.AuthURL,
+			TokenURL:           provider.TokenURL,
+			UserInfoURL:        provider.UserInfoURL,
+			SubjectClaim:       provider.Claims.Subject,
+			EmailClaim:         provider.Claims.Email,
+			EmailVerifiedClaim: provider.Claims.EmailVerified,
+			NameClaim:          provider.Claims.Name,
+			TrustEmail:         provider.TrustEmail,
+		})
+	}
+	socialLoginService := services.NewSocialLoginService(identityProviders, oauthStateStore, linkedIdentityRepo, authUserRepo, roleService, cfg.OAuth.StateTTLMinutes)
+	userService := services.NewUserService(authUserRepo, tokenService, verificationService, passwordResetService, mfaService, roleService, lockoutService, passwordPolicyService, magicLinkService, socialLoginService, cfg.Auth.RequireEmailVerification)
+	importService := services.NewUserImportService(adminUserRepo, roleService)
+	oauthClientService := services.NewOAuthClientService(oauthClientRepo)
+	oauthServerService := services.NewOAuthServerService(oauthClientService, oauthConsentRepo, authorizationCodeStore, oauthTokenRepo, authUserRepo, sessionStore, tokenDenylist, jwtManager, services.OAuthServerPolicy{
+		Issuer:           cfg.JWT.Issuer,
+		AuthorizationURL: strings.TrimSuffix(cfg.Email.AppURL, "/") + "/oauth/authorize",
+		SigningAlgorithm: cfg.JWT.Algorithm,
+		CodeTTL:          time.Duration(cfg.OAuthServer.AuthorizationCodeTTLSeconds) * time.Second,
+		AccessTTL:        time.Duration(cfg.OAuthServer.AccessTokenTTLMinutes) * time.Minute,
+		RefreshTTL:       time.Duration(cfg.OAuthServer.RefreshTokenTTLHours) * time.Hour,
+	})
+	orgService := services.NewOrganizationService(orgRepo, repositories.TenantUserRepos, authUserRepo, tokenService)
+	invitationService := services.NewInvitationService(invitationRepo, orgRepo, authUserRepo, userService, emailService, cfg.Organizations.InvitationTTLHours)
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
+	keyHandler := handlers.NewKeyHandler(keys)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	adminHandler := handlers.NewAdminHandler(adminService, importService)
+	socialLoginHandler := handlers.NewSocialLoginHandler(userService, socialLoginService)
+	oauthHandler := handlers.NewOAuthHandler(oauthServerService, oauthClientService)
+	sessionHandler := handlers.NewSessionHandler(tokenService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
 	global.Logger.Info("Repositories, services, and handlers initialized.")
 
 	// Create router with CORS configuration
-	r := routes.NewRouter(userHandler, cfg.JWT.Secret, cfg)
+	r := routes.NewRouter(userHandler, keyHandler, mfaHandler, apiKeyHandler, adminHandler, socialLoginHandler, oauthHandler, sessionHandler, orgHandler, invitationHandler, jwtManager, tokenDenylist, tokenService, apiKeyService, rateLimitStore, cfg)
 
 	// Setup graceful shutdown
 	setupGracefulShutdown()
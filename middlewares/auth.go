@@ -1,18 +1,118 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"temp/models"
+	"temp/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware returns a gin middleware that validates JWT tokens
-func AuthMiddleware(secret string) gin.HandlerFunc {
+const principalKey = "principal"
+
+// Principal is the authenticated caller of a request. Callers that
+// authenticated with an API key have APIKeyID set, are limited to Scopes
+// and hold no roles or permissions. The same applies to OAuth clients
+// acting for a user, which have ClientID set. AuthTime and AMR tell when
+// and how a logged in user last proved their identity, and OrgID and
+// OrgRole the organization the session acts in.
+type Principal struct {
+	UserID      uint
+	SessionID   string
+	TokenID     string
+	Roles       []string
+	Permissions []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	APIKeyID    uint
+	ClientID    string
+	Scopes      []string
+	AuthTime    time.Time
+	AMR         []string
+	OrgID       uint
+	OrgRole     string
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// HasPermission reports whether one of the caller's roles grants a permission
+func (p *Principal) HasPermission(permission string) bool {
+	for _, perm := range p.Permissions {
+		if perm == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOrgPermission reports whether the caller's role in the active
+// organization grants a permission
+func (p *Principal) HasOrgPermission(permission string) bool {
+	return p.OrgID != 0 && models.OrgRoleHasPermission(p.OrgRole, permission)
+}
+
+// IsOAuthClient reports whether the caller is an OAuth client acting for
+// the user with an access token issued by the authorization server
+func (p *Principal) IsOAuthClient() bool {
+	return p.ClientID != ""
+}
+
+// HasScope reports whether the caller may act within a scope. Login
+// sessions are not restricted by scopes.
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAPIKey() && !p.IsOAuthClient() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenDenylist reports whether an access token ID has been revoked
+type TokenDenylist interface {
+	Contains(jti string) (bool, error)
+}
+
+// SessionTracker reports whether the login session of an access token is
+// still active and records the request as the session's latest use.
+// AuthenticateCookie does the same for a session cookie and returns a nil
+// session when the cookie is not valid.
+type SessionTracker interface {
+	TouchSession(userID uint, sessionID, clientIP string) (bool, error)
+	AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error)
+}
+
+// APIKeyAuthenticator resolves an API key presented as a bearer token
+type APIKeyAuthenticator interface {
+	Authenticate(key string) (*models.APIKey, error)
+}
+
+// AuthMiddleware returns a gin middleware that validates JWT tokens or API
+// keys, rejects tokens that have been revoked or whose session has ended and
+// stores the caller's Principal. Where SessionCookies enabled cookie
+// sessions, requests without an Authorization header may present the
+// session cookie instead.
+func AuthMiddleware(tokens *utils.TokenManager, denylist TokenDenylist, sessions SessionTracker, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
+			if config, ok := GetSessionCookieConfig(c); ok {
+				if value, err := c.Cookie(config.Name); err == nil && value != "" {
+					authenticateCookie(c, sessions, config, value)
+					return
+				}
+			}
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
 			return
 		}
@@ -23,22 +123,238 @@ func AuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		tokStr := parts[1]
-		tok, err := jwt.Parse(tokStr, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, nil
+		if utils.IsAPIKey(parts[1]) {
+			key, err := apiKeys.Authenticate(parts[1])
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
 			}
-			return []byte(secret), nil
-		})
-		if err != nil || !tok.Valid {
+			SetPrincipal(c, &Principal{UserID: key.UserID, APIKeyID: key.ID, Scopes: key.Scopes})
+			c.Next()
+			return
+		}
+
+		claims, err := tokens.Parse(parts[1])
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if claims, ok := tok.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
+		revoked, err := denylist.Contains(claims.ID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			return
+		}
+
+		// Parse has already checked the subject
+		userID, _ := claims.UserID()
+
+		// OAuth access tokens are not tied to a login session
+		if claims.SessionID != "" {
+			active, err := sessions.TouchSession(userID, claims.SessionID, c.ClientIP())
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token"})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+				return
+			}
 		}
 
+		principal := &Principal{
+			UserID:      userID,
+			SessionID:   claims.SessionID,
+			TokenID:     claims.ID,
+			Roles:       claims.Roles,
+			Permissions: claims.Permissions,
+			ExpiresAt:   claims.ExpiresAt.Time,
+			ClientID:    claims.ClientID,
+			OrgID:       claims.OrgID,
+			OrgRole:     claims.OrgRole,
+		}
+		if claims.ClientID != "" {
+			principal.Scopes = strings.Fields(claims.Scope)
+		}
+		if claims.IssuedAt != nil {
+			principal.IssuedAt = claims.IssuedAt.Time
+		}
+		if claims.AuthTime != nil {
+			principal.AuthTime = claims.AuthTime.Time
+			principal.AMR = claims.AMR
+		}
+		SetPrincipal(c, principal)
+
 		c.Next()
 	}
 }
+
+// authenticateCookie authenticates a request with a session cookie. Since
+// browsers send the cookie with cross-site requests too, requests that may
+// change state must also carry the session's CSRF token in a header, which
+// other sites cannot read or set.
+func authenticateCookie(c *gin.Context, sessions SessionTracker, config *SessionCookieConfig, value string) {
+	session, u, err := sessions.AuthenticateCookie(value, c.ClientIP())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify session"})
+		return
+	}
+	if session == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid session"})
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		csrfToken := c.GetHeader(config.CSRFHeader)
+		if csrfToken == "" || subtle.ConstantTimeCompare([]byte(utils.HashToken(csrfToken)), []byte(session.CSRFHash)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "invalid csrf token"})
+			return
+		}
+	}
+
+	principal := &Principal{
+		UserID:      u.ID,
+		SessionID:   session.ID,
+		TokenID:     session.AccessTokenID,
+		Roles:       u.RoleNames(),
+		Permissions: u.PermissionNames(),
+		IssuedAt:    session.CreatedAt,
+		ExpiresAt:   session.AccessExpiresAt,
+		AuthTime:    session.AuthTime,
+		AMR:         session.AMR,
+	}
+	if membership := u.Membership(session.OrganizationID); membership != nil {
+		principal.OrgID = membership.OrganizationID
+		principal.OrgRole = membership.Role
+	}
+	SetPrincipal(c, principal)
+	c.Next()
+}
+
+// RequireScope returns a gin middleware that rejects API keys without the
+// given scope. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token lacks scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRecentAuth returns a gin middleware that admits logged in users
+// only if they authenticated within maxAge, by logging in or at
+// /reauthenticate. Others get a 401 challenge in the style of RFC 9470
+// telling them to reauthenticate. It must run after AuthMiddleware.
+func RequireRecentAuth(maxAge time.Duration) gin.HandlerFunc {
+	seconds := int64(maxAge / time.Second)
+	challenge := fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="A more recent authentication is required", max_age=%d`, seconds)
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+			return
+		}
+		if principal.AuthTime.IsZero() || time.Since(principal.AuthTime) > maxAge {
+			c.Header("WWW-Authenticate", challenge)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":             "insufficient_user_authentication",
+				"error_description": "reauthenticate to continue",
+				"max_age":           seconds,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission returns a gin middleware that rejects callers whose
+// roles do not grant the permission. Permissions come from the access
+// token, so role changes apply once the token is refreshed; cookie
+// sessions load them on every request. It must run after AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+			return
+		}
+		if !principal.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + permission})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOrgPermission returns a gin middleware that rejects callers
+// without an active organization or whose role there does not grant the
+// permission. Like other permissions the role comes from the access token.
+// It must run after AuthMiddleware.
+func RequireOrgPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+			return
+		}
+		if principal.OrgID == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "no active organization"})
+			return
+		}
+		if !principal.HasOrgPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing organization permission " + permission})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession returns a gin middleware that only admits callers who
+// logged in, keeping API keys and OAuth clients away from account and
+// credential management. It must run after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no user in context"})
+			return
+		}
+		if principal.IsAPIKey() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with an api key"})
+			return
+		}
+		if principal.IsOAuthClient() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with an oauth access token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetPrincipal stores the authenticated caller in the request context
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+}
+
+// GetPrincipal returns the authenticated caller stored by AuthMiddleware
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	v, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,316 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDenylist map[string]bool
+
+func (d fakeDenylist) Contains(jti string) (bool, error) {
+	return d[jti], nil
+}
+
+type fakeSessions map[string]bool
+
+func (s fakeSessions) TouchSession(userID uint, sessionID, clientIP string) (bool, error) {
+	return s[sessionID], nil
+}
+
+func (s fakeSessions) AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error) {
+	if value != "7.session-1.secret" {
+		return nil, nil, nil
+	}
+	session := &models.Session{ID: "session-1", UserID: 7, AccessTokenID: "active", CSRFHash: utils.HashToken("csrf-token")}
+	user := &models.User{ID: 7, Roles: []models.Role{{Name: "admin", Permissions: []models.Permission{{Name: models.PermissionUsersRead}}}}}
+	return session, user, nil
+}
+
+type fakeAPIKeys map[string]*models.APIKey
+
+func (k fakeAPIKeys) Authenticate(key string) (*models.APIKey, error) {
+	if apiKey, ok := k[key]; ok {
+		return apiKey, nil
+	}
+	return nil, errors.New("invalid api key")
+}
+
+var testAPIKeys = fakeAPIKeys{
+	"pat_00000001_secret": {ID: 3, UserID: 7, Scopes: []string{models.ScopeProfileRead}},
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, err := utils.NewKeyManager(utils.AlgorithmHS256, "", "test-secret", 0, 0)
+	assert.NoError(t, err)
+	tokens, err := utils.NewTokenManager(keys, "issuer", "audience", 0, []string{utils.AlgorithmHS256})
+	assert.NoError(t, err)
+
+	sign := func(jti, sessionID string) string {
+		signed, err := tokens.Sign(&utils.Claims{
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "7",
+				ID:        jti,
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		assert.NoError(t, err)
+		return signed
+	}
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+	}{
+		{name: "valid token", header: "Bearer " + sign("active", "session-1"), expectedStatus: http.StatusOK},
+		{name: "revoked token", header: "Bearer " + sign("revoked", "session-1"), expectedStatus: http.StatusUnauthorized},
+		{name: "revoked session", header: "Bearer " + sign("active", "session-2"), expectedStatus: http.StatusUnauthorized},
+		{name: "missing header", header: "", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic " + sign("active", "session-1"), expectedStatus: http.StatusUnauthorized},
+		{name: "malformed token", header: "Bearer not-a-token", expectedStatus: http.StatusUnauthorized},
+		{name: "api key", header: "Bearer pat_00000001_secret", expectedStatus: http.StatusOK},
+		{name: "unknown api key", header: "Bearer pat_00000002_secret", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal *Principal
+			r := gin.New()
+			r.Use(AuthMiddleware(tokens, fakeDenylist{"revoked": true}, fakeSessions{"session-1": true}, testAPIKeys))
+			r.GET("/protected", func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, uint(7), principal.UserID)
+				if principal.IsAPIKey() {
+					assert.Equal(t, uint(3), principal.APIKeyID)
+				} else {
+					assert.Equal(t, "session-1", principal.SessionID)
+					assert.Equal(t, "active", principal.TokenID)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_SessionCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := SessionCookieConfig{Name: "session", CSRFCookieName: "csrf_token", CSRFHeader: "X-CSRF-Token", Path: "/"}
+
+	tests := []struct {
+		name           string
+		method         string
+		cookie         string
+		csrfToken      string
+		disabled       bool
+		expectedStatus int
+	}{
+		{name: "read with cookie", method: "GET", cookie: "7.session-1.secret", expectedStatus: http.StatusOK},
+		{name: "write with csrf token", method: "POST", cookie: "7.session-1.secret", csrfToken: "csrf-token", expectedStatus: http.StatusOK},
+		{name: "write without csrf token", method: "POST", cookie: "7.session-1.secret", expectedStatus: http.StatusForbidden},
+		{name: "write with wrong csrf token", method: "DELETE", cookie: "7.session-1.secret", csrfToken: "other", expectedStatus: http.StatusForbidden},
+		{name: "invalid cookie", method: "GET", cookie: "7.session-1.guess", expectedStatus: http.StatusUnauthorized},
+		{name: "cookie sessions disabled", method: "GET", cookie: "7.session-1.secret", disabled: true, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal *Principal
+			r := gin.New()
+			if !tt.disabled {
+				r.Use(SessionCookies(config))
+			}
+			r.Use(AuthMiddleware(nil, fakeDenylist{}, fakeSessions{}, testAPIKeys))
+			r.Handle(tt.method, "/protected", func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/protected", nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: tt.cookie})
+			if tt.csrfToken != "" {
+				req.Header.Set("X-CSRF-Token", tt.csrfToken)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, uint(7), principal.UserID)
+				assert.Equal(t, "session-1", principal.SessionID)
+				assert.Equal(t, "active", principal.TokenID)
+				assert.True(t, principal.HasPermission(models.PermissionUsersRead))
+			}
+		})
+	}
+}
+
+func TestRequireScopeAndSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	session := &Principal{UserID: 7, SessionID: "session-1"}
+	apiKey := &Principal{UserID: 7, APIKeyID: 3, Scopes: []string{models.ScopeProfileRead}}
+	client := &Principal{UserID: 7, TokenID: "jti-1", ClientID: "wiki", Scopes: []string{models.ScopeOpenID, models.ScopeProfileRead}}
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		middleware     gin.HandlerFunc
+		expectedStatus int
+	}{
+		{name: "session has every scope", principal: session, middleware: RequireScope(models.ScopeProfileWrite), expectedStatus: http.StatusOK},
+		{name: "api key with scope", principal: apiKey, middleware: RequireScope(models.ScopeProfileRead), expectedStatus: http.StatusOK},
+		{name: "api key without scope", principal: apiKey, middleware: RequireScope(models.ScopeProfileWrite), expectedStatus: http.StatusForbidden},
+		{name: "session on session-only route", principal: session, middleware: RequireSession(), expectedStatus: http.StatusOK},
+		{name: "api key on session-only route", principal: apiKey, middleware: RequireSession(), expectedStatus: http.StatusForbidden},
+		{name: "oauth client with scope", principal: client, middleware: RequireScope(models.ScopeProfileRead), expectedStatus: http.StatusOK},
+		{name: "oauth client without scope", principal: client, middleware: RequireScope(models.ScopeProfileWrite), expectedStatus: http.StatusForbidden},
+		{name: "oauth client on session-only route", principal: client, middleware: RequireSession(), expectedStatus: http.StatusForbidden},
+		{name: "no principal", principal: nil, middleware: RequireSession(), expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					SetPrincipal(c, tt.principal)
+				}
+			})
+			r.GET("/protected", tt.middleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/protected", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		expectedStatus int
+	}{
+		{name: "role grants permission", principal: &Principal{UserID: 1, Roles: []string{models.RoleAdmin}, Permissions: []string{models.PermissionUsersRead}}, expectedStatus: http.StatusOK},
+		{name: "role lacks permission", principal: &Principal{UserID: 1, Roles: []string{models.RoleUser}}, expectedStatus: http.StatusForbidden},
+		{name: "api key", principal: &Principal{UserID: 1, APIKeyID: 3, Scopes: []string{models.ScopeProfileRead}}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				SetPrincipal(c, tt.principal)
+			})
+			r.GET("/admin", RequirePermission(models.PermissionUsersRead), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireOrgPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		expectedStatus int
+	}{
+		{name: "admin manages members", principal: &Principal{UserID: 1, OrgID: 3, OrgRole: models.OrgRoleAdmin}, expectedStatus: http.StatusOK},
+		{name: "member cannot manage members", principal: &Principal{UserID: 1, OrgID: 3, OrgRole: models.OrgRoleMember}, expectedStatus: http.StatusForbidden},
+		{name: "no active organization", principal: &Principal{UserID: 1, Roles: []string{models.RoleAdmin}}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				SetPrincipal(c, tt.principal)
+			})
+			r.DELETE("/organization/members/2", RequireOrgPermission(models.OrgPermissionMembersWrite), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("DELETE", "/organization/members/2", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		expectedStatus int
+	}{
+		{name: "recent login", principal: &Principal{UserID: 7, AuthTime: time.Now().Add(-time.Minute)}, expectedStatus: http.StatusOK},
+		{name: "old login", principal: &Principal{UserID: 7, AuthTime: time.Now().Add(-time.Hour)}, expectedStatus: http.StatusUnauthorized},
+		{name: "unknown auth time", principal: &Principal{UserID: 7}, expectedStatus: http.StatusUnauthorized},
+		{name: "no principal", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.principal != nil {
+					SetPrincipal(c, tt.principal)
+				}
+			})
+			r.POST("/change-password", RequireRecentAuth(10*time.Minute), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/change-password", nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.principal != nil && tt.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "max_age=600")
+				assert.Contains(t, w.Body.String(), `"max_age":600`)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Rate limit algorithms
+const (
+	RateLimitSlidingWindow = "sliding_window"
+	RateLimitTokenBucket   = "token_bucket"
+)
+
+// What requests are counted by
+const (
+	// RateLimitByIP counts requests per client IP
+	RateLimitByIP = "ip"
+	// RateLimitByUser counts requests per authenticated user, so the API
+	// keys of a user share the limit of their sessions
+	RateLimitByUser = "user"
+	// RateLimitByAPIKey counts requests per API key and per user for
+	// login sessions
+	RateLimitByAPIKey = "api_key"
+)
+
+// RateLimiter counts requests against rate limits
+type RateLimiter interface {
+	SlidingWindow(key string, limit int, window time.Duration) (*models.RateLimitResult, error)
+	TokenBucket(key string, limit int, window time.Duration) (*models.RateLimitResult, error)
+}
+
+// RateLimitPolicy describes one rate limit. Routes using a policy with the
+// same Name share its counters.
+type RateLimitPolicy struct {
+	Name      string
+	Algorithm string
+	Limit     int
+	Window    time.Duration
+	KeyBy     string
+}
+
+// RateLimit returns a gin middleware that refuses requests over the
+// policy's limit with 429. Every response carries the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, refused ones also
+// Retry-After. Policies keyed by user or API key must run after
+// AuthMiddleware; unauthenticated requests are counted by client IP.
+// Requests are let through when the limiter fails, so an outage of Redis
+// does not take the API down.
+func RateLimit(limiter RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	count := limiter.SlidingWindow
+	if policy.Algorithm == RateLimitTokenBucket {
+		count = limiter.TokenBucket
+	}
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit, int64(policy.Window.Seconds()))
+
+	return func(c *gin.Context) {
+		result, err := count(policy.Name+":"+rateLimitSubject(c, policy.KeyBy), policy.Limit, policy.Window)
+		if err != nil {
+			global.Logger.Warn("Rate limiter unavailable", zap.String("policy", policy.Name), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.Reset), 10))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded", "retry_after": retryAfter})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitSubject returns who a request is counted for
+func rateLimitSubject(c *gin.Context, keyBy string) string {
+	if keyBy != RateLimitByIP {
+		if principal, ok := GetPrincipal(c); ok {
+			if keyBy == RateLimitByAPIKey && principal.IsAPIKey() {
+				return "key:" + strconv.FormatUint(uint64(principal.APIKeyID), 10)
+			}
+			return "user:" + strconv.FormatUint(uint64(principal.UserID), 10)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds rounds up so a client waiting the advertised time is let through
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"temp/global"
+	"temp/models"
+	"temp/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) SlidingWindow(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	return nil, errors.New("redis unavailable")
+}
+
+func (failingLimiter) TokenBucket(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	return nil, errors.New("redis unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(limiter RateLimiter, policy RateLimitPolicy) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if c.GetHeader("X-Test-User") == "7" {
+				SetPrincipal(c, &Principal{UserID: 7, SessionID: "session-1"})
+			}
+			if c.GetHeader("X-Test-Key") == "3" {
+				SetPrincipal(c, &Principal{UserID: 7, APIKeyID: 3})
+			}
+		})
+		r.GET("/limited", RateLimit(limiter, policy), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+	request := func(r *gin.Engine, ip string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/limited", nil)
+		req.RemoteAddr = ip + ":1234"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sliding window by ip", func(t *testing.T) {
+		r := newRouter(repositories.NewMemoryRateLimitStore(), RateLimitPolicy{Name: "test", Algorithm: RateLimitSlidingWindow, Limit: 2, Window: time.Minute, KeyBy: RateLimitByIP})
+
+		w := request(r, "192.0.2.1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.1", nil).Code)
+
+		w = request(r, "192.0.2.1", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.2", nil).Code)
+	})
+
+	t.Run("token bucket by user", func(t *testing.T) {
+		r := newRouter(repositories.NewMemoryRateLimitStore(), RateLimitPolicy{Name: "test", Algorithm: RateLimitTokenBucket, Limit: 1, Window: time.Minute, KeyBy: RateLimitByUser})
+
+		user := map[string]string{"X-Test-User": "7"}
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.1", user).Code)
+		// The same user from another IP and through an API key shares the limit
+		assert.Equal(t, http.StatusTooManyRequests, request(r, "192.0.2.2", user).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(r, "192.0.2.2", map[string]string{"X-Test-Key": "3"}).Code)
+		// Anonymous requests are counted by IP
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.1", nil).Code)
+	})
+
+	t.Run("api keys are counted separately", func(t *testing.T) {
+		r := newRouter(repositories.NewMemoryRateLimitStore(), RateLimitPolicy{Name: "test", Algorithm: RateLimitSlidingWindow, Limit: 1, Window: time.Minute, KeyBy: RateLimitByAPIKey})
+
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.1", map[string]string{"X-Test-User": "7"}).Code)
+		assert.Equal(t, http.StatusOK, request(r, "192.0.2.1", map[string]string{"X-Test-Key": "3"}).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(r, "192.0.2.1", map[string]string{"X-Test-Key": "3"}).Code)
+	})
+
+	t.Run("requests pass when the limiter fails", func(t *testing.T) {
+		global.Logger = zap.NewNop()
+		r := newRouter(failingLimiter{}, RateLimitPolicy{Name: "test", Algorithm: RateLimitSlidingWindow, Limit: 1, Window: time.Minute, KeyBy: RateLimitByIP})
+
+		w := request(r, "192.0.2.1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,84 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sessionCookieKey = "session_cookie"
+
+// SessionCookieConfig configures the cookies of cookie sessions. The
+// session cookie is HttpOnly; the CSRF cookie is readable by scripts so
+// they can echo its value in CSRFHeader.
+type SessionCookieConfig struct {
+	Name           string
+	CSRFCookieName string
+	CSRFHeader     string
+	Domain         string
+	Path           string
+	Secure         bool
+	SameSite       http.SameSite
+	// OmitTokens keeps bearer tokens out of login responses, leaving the
+	// cookie as the only credential
+	OmitTokens bool
+}
+
+// SessionCookies returns a gin middleware that enables cookie sessions on
+// the routes it guards: logins set the session cookie and AuthMiddleware
+// accepts it in place of the Authorization header
+func SessionCookies(config SessionCookieConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(sessionCookieKey, &config)
+		c.Next()
+	}
+}
+
+// GetSessionCookieConfig returns the cookie configuration stored by
+// SessionCookies. It reports false when cookie sessions are disabled.
+func GetSessionCookieConfig(c *gin.Context) (*SessionCookieConfig, bool) {
+	v, exists := c.Get(sessionCookieKey)
+	if !exists {
+		return nil, false
+	}
+	config, ok := v.(*SessionCookieConfig)
+	return config, ok && config != nil
+}
+
+// SetSessionCookies sets the session and CSRF cookies of a new session. It
+// reports false, setting nothing, when cookie sessions are disabled.
+func SetSessionCookies(c *gin.Context, value, csrfToken string, expiresAt time.Time) bool {
+	config, ok := GetSessionCookieConfig(c)
+	if !ok {
+		return false
+	}
+	maxAge := int(time.Until(expiresAt).Seconds())
+	http.SetCookie(c.Writer, config.cookie(config.Name, value, maxAge, true))
+	http.SetCookie(c.Writer, config.cookie(config.CSRFCookieName, csrfToken, maxAge, false))
+	return true
+}
+
+// ClearSessionCookies removes the session and CSRF cookies, if cookie
+// sessions are enabled
+func ClearSessionCookies(c *gin.Context) {
+	config, ok := GetSessionCookieConfig(c)
+	if !ok {
+		return
+	}
+	http.SetCookie(c.Writer, config.cookie(config.Name, "", -1, true))
+	http.SetCookie(c.Writer, config.cookie(config.CSRFCookieName, "", -1, false))
+}
+
+func (config *SessionCookieConfig) cookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     config.Path,
+		Domain:   config.Domain,
+		MaxAge:   maxAge,
+		Secure:   config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: config.SameSite,
+	}
+}
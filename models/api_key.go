@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Scopes that can be granted to an API key
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+)
+
+// APIKeyScopes lists every scope an API key can be granted
+var APIKeyScopes = []string{ScopeProfileRead, ScopeProfileWrite}
+
+// APIKey is a long-lived credential a user creates for scripts and
+// integrations. Only the hash of the key is stored; Prefix stays readable
+// so users can tell their keys apart.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;size:255" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key has been granted a scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
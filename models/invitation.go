@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Invitation asks the owner of an email address to join an organization
+// with a role. Only the hash of the emailed token is stored. An invitation
+// is pending until it is accepted, revoked or expires.
+type Invitation struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	OrganizationID uint          `gorm:"not null;index" json:"organization_id"`
+	Email          string        `gorm:"size:255;not null;index" json:"email"`
+	Role           string        `gorm:"size:32;not null" json:"role"`
+	TokenHash      string        `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	InvitedBy      uint          `gorm:"not null" json:"invited_by"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	AcceptedAt     *time.Time    `json:"accepted_at,omitempty"`
+	AcceptedBy     *uint         `json:"accepted_by,omitempty"`
+	RevokedAt      *time.Time    `json:"revoked_at,omitempty"`
+	Organization   *Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// LinkedIdentity attaches an account at an external identity provider to a
+// user. Subject is the provider's stable ID of the account, so logins keep
+// working when the email address changes at the provider.
+type LinkedIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Provider    string     `gorm:"size:64;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string     `gorm:"size:100" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginAttempts counts the recent failed logins of an account or client IP
+type LoginAttempts struct {
+	Failures    int64     `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// AttemptLimits decides when the next login attempt on a key is allowed
+type AttemptLimits struct {
+	// Threshold attempts block the key until Window has passed since the
+	// last one; zero disables the block
+	Threshold int
+	// Window is how long the attempts of a key are remembered
+	Window time.Duration
+	// After DelayAfter attempts each retry has to wait BaseDelay, doubling
+	// with every further attempt up to MaxDelay
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// RetryAfter returns how long the next attempt has to wait, or zero if it
+// is allowed now
+func (l AttemptLimits) RetryAfter(a *LoginAttempts, now time.Time) time.Duration {
+	var wait time.Duration
+	if l.Threshold > 0 && a.Failures >= int64(l.Threshold) {
+		wait = a.LastFailure.Add(l.Window).Sub(now)
+	}
+	if d := a.LastFailure.Add(l.Delay(a.Failures)).Sub(now); d > wait {
+		wait = d
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Delay returns how long a client has to wait after its last attempt
+func (l AttemptLimits) Delay(failures int64) time.Duration {
+	if failures < int64(l.DelayAfter) || l.DelayAfter <= 0 {
+		return 0
+	}
+	d := l.BaseDelay
+	for i := int64(l.DelayAfter); i < failures && d < l.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > l.MaxDelay {
+		d = l.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// Scopes that can be requested by OAuth clients in addition to the API
+// key scopes
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OAuthScopes lists every scope an OAuth client can be granted
+var OAuthScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeProfileRead, ScopeProfileWrite}
+
+// Grant types of the token endpoint
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OAuthGrantTypes lists every grant type a client can be allowed to use
+var OAuthGrantTypes = []string{GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials}
+
+// OAuthClient is an application registered to log users in through this
+// service. Confidential clients authenticate with a secret, of which only
+// the hash is stored; public clients such as single page and mobile apps
+// have none and rely on PKCE alone. Trusted clients are first-party apps
+// that do not ask users for consent.
+type OAuthClient struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ClientID     string    `gorm:"uniqueIndex;size:64;not null" json:"client_id"`
+	SecretHash   string    `gorm:"size:64" json:"-"`
+	Name         string    `gorm:"size:100;not null" json:"name"`
+	RedirectURIs []string  `gorm:"serializer:json;type:text" json:"redirect_uris"`
+	Scopes       []string  `gorm:"serializer:json;size:255" json:"scopes"`
+	GrantTypes   []string  `gorm:"serializer:json;size:255" json:"grant_types"`
+	Trusted      bool      `gorm:"not null;default:false" json:"trusted"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IsConfidential reports whether the client authenticates with a secret
+func (c *OAuthClient) IsConfidential() bool {
+	return c.SecretHash != ""
+}
+
+// HasRedirectURI reports whether a redirect URI has been registered for
+// the client. URIs are compared as exact strings.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return containsString(c.RedirectURIs, uri)
+}
+
+// HasScope reports whether the client may request a scope
+func (c *OAuthClient) HasScope(scope string) bool {
+	return containsString(c.Scopes, scope)
+}
+
+// HasGrantType reports whether the client may use a grant type
+func (c *OAuthClient) HasGrantType(grantType string) bool {
+	return containsString(c.GrantTypes, grantType)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
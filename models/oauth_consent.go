@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// OAuthConsent records the scopes a user has allowed an OAuth client to
+// access. Users are asked again when a client requests more.
+type OAuthConsent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_consent_user_client" json:"user_id"`
+	ClientID  string    `gorm:"size:64;not null;uniqueIndex:idx_consent_user_client" json:"client_id"`
+	Scopes    []string  `gorm:"serializer:json;size:255" json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Covers reports whether the consent includes every scope
+func (c *OAuthConsent) Covers(scopes []string) bool {
+	for _, s := range scopes {
+		if !containsString(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
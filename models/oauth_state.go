@@ -0,0 +1,12 @@
+package models
+
+// OAuthState is kept between redirecting a user to an external identity
+// provider and the provider redirecting back. It is looked up by the state
+// parameter and can be used once.
+type OAuthState struct {
+	Provider string `json:"provider"`
+	// Nonce is echoed in the ID token of OpenID Connect providers
+	Nonce string `json:"nonce"`
+	// CodeVerifier is the PKCE secret the authorization code is redeemed with
+	CodeVerifier string `json:"code_verifier"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// AuthorizationCode is issued to an OAuth client when a user approves an
+// authorization request. It is kept until the client redeems it at the
+// token endpoint and can be used once.
+type AuthorizationCode struct {
+	ClientID    string   `json:"client_id"`
+	UserID      uint     `json:"user_id"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+	// CodeChallenge is the S256 PKCE challenge the code verifier must match
+	CodeChallenge string `json:"code_challenge"`
+	// Nonce is echoed in the ID token
+	Nonce string `json:"nonce"`
+}
+
+// OAuthRefreshToken is a refresh token issued to an OAuth client on behalf
+// of a user. Like RefreshToken it rotates on every use, and tokens rotated
+// from the same authorization share a FamilyID.
+type OAuthRefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	ClientID  string     `gorm:"index;size:64;not null" json:"client_id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"index;size:64;not null" json:"family_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Scopes    []string   `gorm:"serializer:json;size:255" json:"scopes"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Purposes of one-time tokens
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeMFAChallenge      = "mfa_challenge"
+	TokenPurposeAccountUnlock     = "account_unlock"
+	TokenPurposeMagicLink         = "magic_link"
+)
+
+// OneTimeToken is a single-use token mailed to a user, such as an email
+// verification or password reset link. Only the hash of the token is stored.
+type OneTimeToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Purpose   string     `gorm:"size:32;not null" json:"purpose"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	// Binding is the hash of the client the token is bound to; only that
+	// client can redeem it. Empty for tokens that work from anywhere.
+	Binding string `gorm:"size:64" json:"-"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// Roles of a member within an organization. Owners manage the organization
+// and its owners, admins manage the other members.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Permissions within the active organization
+const (
+	OrgPermissionMembersRead  = "org:members:read"
+	OrgPermissionMembersWrite = "org:members:write"
+)
+
+// OrgRolePermissions lists the permissions each organization role grants
+// in its organization
+var OrgRolePermissions = map[string][]string{
+	OrgRoleOwner:  {OrgPermissionMembersRead, OrgPermissionMembersWrite},
+	OrgRoleAdmin:  {OrgPermissionMembersRead, OrgPermissionMembersWrite},
+	OrgRoleMember: {OrgPermissionMembersRead},
+}
+
+// Organization is a tenant. Users belong to organizations through
+// memberships and act within one of them at a time.
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Membership makes a user a member of an organization with one of the
+// OrgRole values
+type Membership struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	OrganizationID uint          `gorm:"not null;uniqueIndex:idx_membership_org_user" json:"organization_id"`
+	UserID         uint          `gorm:"not null;uniqueIndex:idx_membership_org_user;index" json:"user_id"`
+	Role           string        `gorm:"size:32;not null" json:"role"`
+	Organization   *Organization `json:"organization,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// IsOrgRole reports whether role is one of the organization roles
+func IsOrgRole(role string) bool {
+	_, ok := OrgRolePermissions[role]
+	return ok
+}
+
+// OrgRoleHasPermission reports whether an organization role grants a
+// permission
+func OrgRoleHasPermission(role, permission string) bool {
+	for _, p := range OrgRolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Membership returns the user's membership in an organization, or nil when
+// the user is not a member. Memberships must have been loaded.
+func (u *User) Membership(orgID uint) *Membership {
+	for i := range u.Memberships {
+		if u.Memberships[i].OrganizationID == orgID {
+			return &u.Memberships[i]
+		}
+	}
+	return nil
+}
+
+// DefaultMembership returns the membership a new session starts in: the
+// oldest one. It returns nil for users without organizations.
+func (u *User) DefaultMembership() *Membership {
+	var first *Membership
+	for i := range u.Memberships {
+		m := &u.Memberships[i]
+		if first == nil || m.CreatedAt.Before(first.CreatedAt) || (m.CreatedAt.Equal(first.CreatedAt) && m.ID < first.ID) {
+			first = m
+		}
+	}
+	return first
+}
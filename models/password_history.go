@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordHistory records a password hash a user has had, so recently used
+// passwords can be refused when the password is changed
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"index;not null" json:"user_id"`
+	PasswordHash string    `gorm:"size:255;not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitResult is the outcome of counting one request against a rate limit
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the next request slot becomes available
+	Reset time.Duration
+	// RetryAfter is how long a refused client has to wait; zero when allowed
+	RetryAfter time.Duration
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken represents a single refresh token issued to a user.
+// Tokens rotated from the same login share a FamilyID so the whole chain
+// can be revoked when a token is replayed.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"index;size:64;not null" json:"family_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Built-in roles. Every registered user gets RoleUser; RoleAdmin holds every
+// permission and is granted through the create-admin command.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Permissions checked by the API
+const (
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+	PermissionRedisRead    = "redis:read"
+	PermissionRedisWrite   = "redis:write"
+	PermissionClientsRead  = "clients:read"
+	PermissionClientsWrite = "clients:write"
+)
+
+// DefaultRolePermissions lists the permissions each built-in role is
+// created with
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {PermissionUsersRead, PermissionUsersWrite, PermissionRedisRead, PermissionRedisWrite, PermissionClientsRead, PermissionClientsWrite},
+	RoleUser:  {},
+}
+
+// Role groups permissions and is assigned to users
+type Role struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Permission is a named capability such as users:read
+type Permission struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"uniqueIndex;size:100;not null" json:"name"`
+}
+
+// RoleNames returns the names of the roles assigned to the user
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, r := range u.Roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// PermissionNames returns the sorted permissions granted by all roles of
+// the user. Roles must have been loaded with their permissions.
+func (u *User) PermissionNames() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, r := range u.Roles {
+		for _, p := range r.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Authentication methods recorded in a session and in the amr claim of its
+// access tokens (RFC 8176). Recovery codes count as one-time passwords.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRMultiFactor = "mfa"
+	AMREmail       = "email"
+	AMRFederated   = "fed"
+)
+
+// Session represents one login of a user. Its ID is shared with the
+// refresh token family, and AccessTokenID tracks the jti of the most
+// recently issued access token so it can be revoked. Device, UserAgent and
+// IP describe the client that logged in; IP and LastSeenAt follow its
+// requests. CookieHash and CSRFHash are the hashes of the session cookie
+// and CSRF token handed out when cookie sessions are enabled. AuthTime and
+// AMR tell when and how the user last proved their identity, at login or
+// when reauthenticating. OrganizationID is the organization the session
+// acts in, if the user belongs to any.
+type Session struct {
+	ID              string    `json:"id"`
+	UserID          uint      `json:"user_id"`
+	AccessTokenID   string    `json:"access_token_id"`
+	AccessExpiresAt time.Time `json:"access_expires_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	Device          string    `json:"device"`
+	UserAgent       string    `json:"user_agent"`
+	IP              string    `json:"ip"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+	CookieHash      string    `json:"cookie_hash,omitempty"`
+	CSRFHash        string    `json:"csrf_hash,omitempty"`
+	AuthTime        time.Time `json:"auth_time"`
+	AMR             []string  `json:"amr,omitempty"`
+	OrganizationID  uint      `json:"organization_id,omitempty"`
+}
@@ -4,11 +4,40 @@ import (
 	"time"
 )
 
+// User statuses the admin user listing can filter by
+const (
+	UserStatusActive     = "active"
+	UserStatusDisabled   = "disabled"
+	UserStatusUnverified = "unverified"
+)
+
 // User represents a user in the system
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `json:"name" gorm:"size:100;not null" binding:"required,min=2,max=100"`
-	Email     string    `json:"email" gorm:"uniqueIndex;size:100;not null" binding:"required,email"`
-	Password  string    `json:"-" gorm:"not null"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Name            string     `json:"name" gorm:"size:100;not null" binding:"required,min=2,max=100"`
+	Email           string     `json:"email" gorm:"uniqueIndex;size:100;not null" binding:"required,email"`
+	Password        string     `json:"-" gorm:"not null"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// Optional profile fields
+	DisplayName string `json:"display_name" gorm:"size:100"`
+	AvatarURL   string `json:"avatar_url" gorm:"size:500"`
+	Bio         string `json:"bio" gorm:"size:500"`
+	Locale      string `json:"locale" gorm:"size:35"`
+	Timezone    string `json:"timezone" gorm:"size:64"`
+	// Two-factor authentication. The secret is stored while enrollment is
+	// pending and MFAEnabledAt is set once the user has confirmed a code.
+	TOTPSecret   string     `json:"-" gorm:"size:64"`
+	TOTPLastStep int64      `json:"-"`
+	MFAEnabledAt *time.Time `json:"mfa_enabled_at"`
+	// Account status managed by admins. Disabled users cannot log in and
+	// users flagged for a password reset have to set a new one first.
+	DisabledAt            *time.Time `json:"disabled_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	// Roles are loaded together with their permissions
+	Roles []Role `json:"roles,omitempty" gorm:"many2many:user_roles"`
+	// Organizations the user belongs to
+	Memberships []Membership `json:"-"`
+
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
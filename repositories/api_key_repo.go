@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepo handles DB operations for API keys
+type APIKeyRepo struct{}
+
+// Ensure APIKeyRepo implements APIKeyRepository interface
+var _ APIKeyRepository = (*APIKeyRepo)(nil)
+
+func NewAPIKeyRepo() *APIKeyRepo {
+	return &APIKeyRepo{}
+}
+
+func (r *APIKeyRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.APIKey{})
+}
+
+func (r *APIKeyRepo) Create(k *models.APIKey) error {
+	return global.DB.Create(k).Error
+}
+
+func (r *APIKeyRepo) FindByHash(hash string) (*models.APIKey, error) {
+	var k models.APIKey
+	res := global.DB.Where("key_hash = ?", hash).First(&k)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, res.Error
+	}
+	return &k, nil
+}
+
+// ListForUser returns the keys of a user that have not been revoked
+func (r *APIKeyRepo) ListForUser(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := global.DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Revoke revokes a key of the user. It reports false when the user has no
+// such active key.
+func (r *APIKeyRepo) Revoke(userID, id uint) (bool, error) {
+	res := global.DB.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// TouchLastUsed records a use of the key. Writes are skipped while the
+// stored time is newer than notBefore so busy keys do not update the row
+// on every request.
+func (r *APIKeyRepo) TouchLastUsed(id uint, at, notBefore time.Time) error {
+	return global.DB.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, notBefore).
+		Update("last_used_at", at).Error
+}
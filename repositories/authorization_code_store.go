@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrAuthorizationCodeNotFound = errors.New("authorization code not found")
+
+// NewAuthorizationCodeStore returns a Redis backed code store when Redis is
+// enabled and an in-memory one otherwise
+func NewAuthorizationCodeStore() AuthorizationCodeStore {
+	if global.Redis != nil {
+		return NewRedisAuthorizationCodeStore(global.Redis)
+	}
+	return NewMemoryAuthorizationCodeStore()
+}
+
+// RedisAuthorizationCodeStore keeps authorization codes under
+// oauth:code:<code hash> until they are redeemed or expire
+type RedisAuthorizationCodeStore struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisAuthorizationCodeStore implements AuthorizationCodeStore interface
+var _ AuthorizationCodeStore = (*RedisAuthorizationCodeStore)(nil)
+
+func NewRedisAuthorizationCodeStore(client redis.UniversalClient) *RedisAuthorizationCodeStore {
+	return &RedisAuthorizationCodeStore{client: client}
+}
+
+func (s *RedisAuthorizationCodeStore) Save(codeHash string, code *models.AuthorizationCode, ttl time.Duration) error {
+	value, err := json.Marshal(code)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), authorizationCodeKey(codeHash), value, ttl).Err()
+}
+
+func (s *RedisAuthorizationCodeStore) Take(codeHash string) (*models.AuthorizationCode, error) {
+	// GETDEL makes sure a code is only ever redeemed once
+	value, err := s.client.GetDel(context.Background(), authorizationCodeKey(codeHash)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrAuthorizationCodeNotFound
+		}
+		return nil, err
+	}
+
+	var code models.AuthorizationCode
+	if err := json.Unmarshal(value, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func authorizationCodeKey(codeHash string) string {
+	return fmt.Sprintf("oauth:code:%s", codeHash)
+}
+
+// MemoryAuthorizationCodeStore keeps authorization codes in process
+// memory. It is only suitable for single instance deployments.
+type MemoryAuthorizationCodeStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryAuthorizationCode
+}
+
+type memoryAuthorizationCode struct {
+	code      models.AuthorizationCode
+	expiresAt time.Time
+}
+
+// Ensure MemoryAuthorizationCodeStore implements AuthorizationCodeStore interface
+var _ AuthorizationCodeStore = (*MemoryAuthorizationCodeStore)(nil)
+
+func NewMemoryAuthorizationCodeStore() *MemoryAuthorizationCodeStore {
+	return &MemoryAuthorizationCodeStore{entries: make(map[string]memoryAuthorizationCode)}
+}
+
+func (s *MemoryAuthorizationCodeStore) Save(codeHash string, code *models.AuthorizationCode, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.entries[codeHash] = memoryAuthorizationCode{code: *code, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryAuthorizationCodeStore) Take(codeHash string) (*models.AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[codeHash]
+	if !ok {
+		return nil, ErrAuthorizationCodeNotFound
+	}
+	delete(s.entries, codeHash)
+	if time.Now().After(entry.expiresAt) {
+		return nil, ErrAuthorizationCodeNotFound
+	}
+	return &entry.code, nil
+}
@@ -1,6 +1,10 @@
 package repositories
 
-import "temp/models"
+import (
+	"time"
+
+	"temp/models"
+)
 
 // UserRepository defines the interface for user repository operations
 type UserRepository interface {
@@ -8,4 +12,183 @@ type UserRepository interface {
 	Create(u *models.User) error
 	FindByEmail(email string) (*models.User, error)
 	FindByID(id uint) (*models.User, error)
+	Update(u *models.User) error
+	AdvanceTOTPStep(id uint, step int64) (bool, error)
+	List(q UserQuery) ([]models.User, int64, error)
+	Delete(id uint) error
+}
+
+// RoleRepository defines the interface for role and permission repository operations
+type RoleRepository interface {
+	Migrate() error
+	EnsureRole(name string, permissions []string) error
+	FindByName(name string) (*models.Role, error)
+	AssignRole(userID uint, name string) error
+}
+
+// OrganizationRepository defines the interface for organization and membership repository operations
+type OrganizationRepository interface {
+	Migrate() error
+	Create(o *models.Organization, ownerID uint) error
+	FindByID(id uint) (*models.Organization, error)
+	ListForUser(userID uint) ([]models.Membership, error)
+	FindMembership(orgID, userID uint) (*models.Membership, error)
+	UpdateMemberRole(orgID, userID uint, role string) error
+	RemoveMember(orgID, userID uint) error
+}
+
+// TokenRepository defines the interface for refresh token repository operations
+type TokenRepository interface {
+	Migrate() error
+	Create(t *models.RefreshToken) error
+	FindByHash(hash string) (*models.RefreshToken, error)
+	Revoke(id uint) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeAllForUser(userID uint) error
+}
+
+// OneTimeTokenRepository defines the interface for single-use token repository operations
+type OneTimeTokenRepository interface {
+	Migrate() error
+	Create(t *models.OneTimeToken) error
+	FindByHash(hash, purpose string) (*models.OneTimeToken, error)
+	Consume(id uint) (bool, error)
+	ConsumeAllForUser(userID uint, purpose string) error
+}
+
+// RecoveryCodeRepository defines the interface for MFA recovery code repository operations
+type RecoveryCodeRepository interface {
+	Migrate() error
+	ReplaceForUser(userID uint, hashes []string) error
+	Consume(userID uint, hash string) (bool, error)
+	DeleteForUser(userID uint) error
+}
+
+// PasswordHistoryRepository defines the interface for password history repository operations
+type PasswordHistoryRepository interface {
+	Migrate() error
+	Add(userID uint, hash string, keep int) error
+	Recent(userID uint, limit int) ([]string, error)
+}
+
+// APIKeyRepository defines the interface for API key repository operations
+type APIKeyRepository interface {
+	Migrate() error
+	Create(k *models.APIKey) error
+	FindByHash(hash string) (*models.APIKey, error)
+	ListForUser(userID uint) ([]models.APIKey, error)
+	Revoke(userID, id uint) (bool, error)
+	TouchLastUsed(id uint, at, notBefore time.Time) error
+}
+
+// LinkedIdentityRepository defines the interface for external identity repository operations
+type LinkedIdentityRepository interface {
+	Migrate() error
+	Create(i *models.LinkedIdentity) error
+	Find(provider, subject string) (*models.LinkedIdentity, error)
+	ListForUser(userID uint) ([]models.LinkedIdentity, error)
+	TouchLastLogin(id uint, at time.Time) error
+}
+
+// TokenDenylist defines the interface for storing revoked access token IDs
+type TokenDenylist interface {
+	Add(jti string, ttl time.Duration) error
+	Contains(jti string) (bool, error)
+}
+
+// SessionStore defines the interface for storing active login sessions
+type SessionStore interface {
+	Save(s *models.Session) error
+	Get(userID uint, id string) (*models.Session, error)
+	// Touch records a request of the session's client and reports whether
+	// the session still exists
+	Touch(userID uint, id, ip string, at time.Time) (bool, error)
+	List(userID uint) ([]models.Session, error)
+	Delete(userID uint, id string) error
+	DeleteAll(userID uint) error
+}
+
+// LoginAttemptStore defines the interface for counting failed logins and
+// locking accounts
+type LoginAttemptStore interface {
+	Get(key string) (*models.LoginAttempts, error)
+	// Reserve counts an attempt on key before its credentials are checked
+	// unless limits refuse it, in one atomic step so parallel attempts
+	// cannot pass the limits together. A refused attempt is not counted
+	// and the returned duration tells how long to wait.
+	Reserve(key string, limits models.AttemptLimits) (time.Duration, error)
+	// Release takes back a reserved attempt that turned out to be valid
+	Release(key string) error
+	Reset(key string) error
+	Lock(key string, ttl time.Duration) error
+	LockedUntil(key string) (time.Time, error)
+	Unlock(key string) error
+}
+
+// RateLimitStore defines the interface for counting requests against rate
+// limits. Both methods count the request when it is allowed.
+type RateLimitStore interface {
+	// SlidingWindow allows limit requests within any period of window
+	SlidingWindow(key string, limit int, window time.Duration) (*models.RateLimitResult, error)
+	// TokenBucket allows bursts of up to limit requests and refills limit
+	// tokens per window
+	TokenBucket(key string, limit int, window time.Duration) (*models.RateLimitResult, error)
+}
+
+// OAuthStateStore defines the interface for keeping authorization requests
+// to external identity providers until the provider redirects back
+type OAuthStateStore interface {
+	Save(state string, data *models.OAuthState, ttl time.Duration) error
+	// Take returns and removes the data saved under state
+	Take(state string) (*models.OAuthState, error)
+}
+
+// OAuthClientRepository defines the interface for registered OAuth client repository operations
+type OAuthClientRepository interface {
+	Migrate() error
+	Create(c *models.OAuthClient) error
+	FindByClientID(clientID string) (*models.OAuthClient, error)
+	List() ([]models.OAuthClient, error)
+	Delete(id uint) error
+}
+
+// OAuthConsentRepository defines the interface for OAuth consent repository operations
+type OAuthConsentRepository interface {
+	Migrate() error
+	Find(userID uint, clientID string) (*models.OAuthConsent, error)
+	Save(c *models.OAuthConsent) error
+	ListForUser(userID uint) ([]models.OAuthConsent, error)
+	Delete(userID uint, clientID string) error
+}
+
+// OAuthTokenRepository defines the interface for refresh tokens issued to OAuth clients
+type OAuthTokenRepository interface {
+	Migrate() error
+	Create(t *models.OAuthRefreshToken) error
+	FindByHash(hash string) (*models.OAuthRefreshToken, error)
+	Revoke(id uint) (bool, error)
+	RevokeFamily(familyID string) error
+	RevokeForClient(userID uint, clientID string) error
+}
+
+// AuthorizationCodeStore defines the interface for keeping authorization
+// codes until OAuth clients redeem them
+type AuthorizationCodeStore interface {
+	Save(codeHash string, code *models.AuthorizationCode, ttl time.Duration) error
+	// Take returns and removes the code saved under codeHash
+	Take(codeHash string) (*models.AuthorizationCode, error)
+}
+
+// InvitationRepository defines the interface for organization invitation repository operations
+type InvitationRepository interface {
+	Migrate() error
+	Create(inv *models.Invitation) error
+	FindByID(orgID, id uint) (*models.Invitation, error)
+	FindByHash(hash string) (*models.Invitation, error)
+	FindPending(orgID uint, email string) (*models.Invitation, error)
+	ListPending(orgID uint) ([]models.Invitation, error)
+	Update(inv *models.Invitation) error
+	Revoke(orgID, id uint) error
+	Accept(inv *models.Invitation, userID uint) (bool, error)
+	AcceptNewUser(inv *models.Invitation, u *models.User) (bool, error)
 }
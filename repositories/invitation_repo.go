@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// errInvitationGone rolls back AcceptNewUser
+	errInvitationGone = errors.New("invitation is no longer pending")
+)
+
+// InvitationRepo handles DB operations for organization invitations
+type InvitationRepo struct{}
+
+// Ensure InvitationRepo implements InvitationRepository interface
+var _ InvitationRepository = (*InvitationRepo)(nil)
+
+func NewInvitationRepo() *InvitationRepo {
+	return &InvitationRepo{}
+}
+
+func (r *InvitationRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.Invitation{})
+}
+
+func (r *InvitationRepo) Create(inv *models.Invitation) error {
+	return global.DB.Create(inv).Error
+}
+
+// FindByID returns an invitation of an organization
+func (r *InvitationRepo) FindByID(orgID, id uint) (*models.Invitation, error) {
+	return r.first(global.DB.Where("organization_id = ? AND id = ?", orgID, id))
+}
+
+// FindByHash returns the invitation with the token hash and its
+// organization
+func (r *InvitationRepo) FindByHash(hash string) (*models.Invitation, error) {
+	return r.first(global.DB.Preload("Organization").Where("token_hash = ?", hash))
+}
+
+// FindPending returns the pending invitation of an email address to an
+// organization
+func (r *InvitationRepo) FindPending(orgID uint, email string) (*models.Invitation, error) {
+	return r.first(global.DB.Where("organization_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", orgID, email, time.Now()))
+}
+
+// ListPending returns the invitations of an organization that were neither
+// accepted nor revoked, newest first. Expired ones are included so they can
+// be resent.
+func (r *InvitationRepo) ListPending(orgID uint) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := global.DB.Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", orgID).
+		Order("created_at DESC, id DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *InvitationRepo) Update(inv *models.Invitation) error {
+	return global.DB.Omit("Organization").Save(inv).Error
+}
+
+// Revoke withdraws an invitation that was neither accepted nor revoked
+func (r *InvitationRepo) Revoke(orgID, id uint) error {
+	res := global.DB.Model(&models.Invitation{}).
+		Where("organization_id = ? AND id = ? AND accepted_at IS NULL AND revoked_at IS NULL", orgID, id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept marks a pending invitation as accepted by a user and makes the
+// user a member of its organization with its role. It reports false when
+// the invitation was accepted, revoked or expired in the meantime, so a
+// token cannot be used twice, not even by two concurrent requests.
+func (r *InvitationRepo) Accept(inv *models.Invitation, userID uint) (bool, error) {
+	accepted := false
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", inv.ID, now).
+			Updates(map[string]interface{}{"accepted_at": now, "accepted_by": userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(&models.Membership{OrganizationID: inv.OrganizationID, UserID: userID, Role: inv.Role}).Error; err != nil {
+			return err
+		}
+		accepted = true
+		return nil
+	})
+	return accepted, err
+}
+
+// AcceptNewUser creates a user for a pending invitation and accepts the
+// invitation for it like Accept, all in one transaction. It reports false
+// and creates nothing when the invitation is no longer pending.
+func (r *InvitationRepo) AcceptNewUser(inv *models.Invitation, u *models.User) (bool, error) {
+	err := global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		res := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", inv.ID, now).
+			Updates(map[string]interface{}{"accepted_at": now, "accepted_by": u.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errInvitationGone
+		}
+		return tx.Create(&models.Membership{OrganizationID: inv.OrganizationID, UserID: u.ID, Role: inv.Role}).Error
+	})
+	if errors.Is(err, errInvitationGone) {
+		u.ID = 0
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *InvitationRepo) first(db *gorm.DB) (*models.Invitation, error) {
+	var inv models.Invitation
+	if err := db.First(&inv).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
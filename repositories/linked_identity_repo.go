@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrIdentityNotFound = errors.New("linked identity not found")
+
+// LinkedIdentityRepo handles DB operations for identities at external providers
+type LinkedIdentityRepo struct{}
+
+// Ensure LinkedIdentityRepo implements LinkedIdentityRepository interface
+var _ LinkedIdentityRepository = (*LinkedIdentityRepo)(nil)
+
+func NewLinkedIdentityRepo() *LinkedIdentityRepo {
+	return &LinkedIdentityRepo{}
+}
+
+func (r *LinkedIdentityRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.LinkedIdentity{})
+}
+
+func (r *LinkedIdentityRepo) Create(i *models.LinkedIdentity) error {
+	return global.DB.Create(i).Error
+}
+
+func (r *LinkedIdentityRepo) Find(provider, subject string) (*models.LinkedIdentity, error) {
+	var i models.LinkedIdentity
+	res := global.DB.Where("provider = ? AND subject = ?", provider, subject).First(&i)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, res.Error
+	}
+	return &i, nil
+}
+
+func (r *LinkedIdentityRepo) ListForUser(userID uint) ([]models.LinkedIdentity, error) {
+	var identities []models.LinkedIdentity
+	err := global.DB.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+func (r *LinkedIdentityRepo) TouchLastLogin(id uint, at time.Time) error {
+	return global.DB.Model(&models.LinkedIdentity{}).Where("id = ?", id).Update("last_login_at", at).Error
+}
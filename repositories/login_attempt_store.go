@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewLoginAttemptStore returns a Redis backed attempt store when Redis is
+// enabled and an in-memory one otherwise
+func NewLoginAttemptStore() LoginAttemptStore {
+	if global.Redis != nil {
+		return NewRedisLoginAttemptStore(global.Redis)
+	}
+	return NewMemoryLoginAttemptStore()
+}
+
+// RedisLoginAttemptStore keeps attempt counters in hashes under
+// login:failures:<key> and lockouts under login:lock:<key>
+type RedisLoginAttemptStore struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisLoginAttemptStore implements LoginAttemptStore interface
+var _ LoginAttemptStore = (*RedisLoginAttemptStore)(nil)
+
+func NewRedisLoginAttemptStore(client redis.UniversalClient) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{client: client}
+}
+
+func (s *RedisLoginAttemptStore) Get(key string) (*models.LoginAttempts, error) {
+	values, err := s.client.HGetAll(context.Background(), failuresKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	attempts := &models.LoginAttempts{}
+	attempts.Failures, _ = strconv.ParseInt(values["count"], 10, 64)
+	if last, err := strconv.ParseInt(values["last"], 10, 64); err == nil {
+		attempts.LastFailure = time.UnixMilli(last)
+	}
+	return attempts, nil
+}
+
+// reserveAttemptScript counts an attempt unless the key is blocked or the
+// delay after its last attempt has not passed. Returns the ms to wait, or 0
+// if the attempt was counted.
+var reserveAttemptScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local threshold = tonumber(ARGV[3])
+local delayAfter = tonumber(ARGV[4])
+local baseDelay = tonumber(ARGV[5])
+local maxDelay = tonumber(ARGV[6])
+local state = redis.call('HMGET', KEYS[1], 'count', 'last')
+local count = tonumber(state[1]) or 0
+local last = tonumber(state[2]) or 0
+local wait = 0
+if threshold > 0 and count >= threshold then
+	wait = last + window - now
+end
+if delayAfter > 0 and count >= delayAfter then
+	local delay = math.min(baseDelay * 2 ^ (count - delayAfter), maxDelay)
+	wait = math.max(wait, last + delay - now)
+end
+if wait > 0 then
+	return math.ceil(wait)
+end
+redis.call('HINCRBY', KEYS[1], 'count', 1)
+redis.call('HSET', KEYS[1], 'last', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return 0
+`)
+
+// releaseAttemptScript takes back one counted attempt. A counter that has
+// expired in the meantime is left alone.
+var releaseAttemptScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], 'count', -1) <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Reserve counts an attempt. The counter expires window after the last
+// attempt.
+func (s *RedisLoginAttemptStore) Reserve(key string, limits models.AttemptLimits) (time.Duration, error) {
+	wait, err := reserveAttemptScript.Run(context.Background(), s.client, []string{failuresKey(key)},
+		time.Now().UnixMilli(), limits.Window.Milliseconds(), limits.Threshold,
+		limits.DelayAfter, limits.BaseDelay.Milliseconds(), limits.MaxDelay.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+func (s *RedisLoginAttemptStore) Release(key string) error {
+	return releaseAttemptScript.Run(context.Background(), s.client, []string{failuresKey(key)}).Err()
+}
+
+func (s *RedisLoginAttemptStore) Reset(key string) error {
+	return s.client.Del(context.Background(), failuresKey(key)).Err()
+}
+
+func (s *RedisLoginAttemptStore) Lock(key string, ttl time.Duration) error {
+	until := time.Now().Add(ttl)
+	return s.client.Set(context.Background(), lockKey(key), until.UnixMilli(), ttl).Err()
+}
+
+// LockedUntil returns when the lock on key ends, or the zero time if key
+// is not locked
+func (s *RedisLoginAttemptStore) LockedUntil(key string) (time.Time, error) {
+	until, err := s.client.Get(context.Background(), lockKey(key)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.UnixMilli(until), nil
+}
+
+func (s *RedisLoginAttemptStore) Unlock(key string) error {
+	return s.client.Del(context.Background(), lockKey(key)).Err()
+}
+
+func failuresKey(key string) string {
+	return "login:failures:" + key
+}
+
+func lockKey(key string) string {
+	return "login:lock:" + key
+}
+
+// MemoryLoginAttemptStore keeps failure counters and lockouts in process
+// memory. It is only suitable for single instance deployments.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	failures map[string]memoryAttempts
+	locks    map[string]time.Time
+}
+
+type memoryAttempts struct {
+	attempts  models.LoginAttempts
+	expiresAt time.Time
+}
+
+// Ensure MemoryLoginAttemptStore implements LoginAttemptStore interface
+var _ LoginAttemptStore = (*MemoryLoginAttemptStore)(nil)
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		failures: make(map[string]memoryAttempts),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryLoginAttemptStore) Get(key string) (*models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.failures[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(s.failures, key)
+		return &models.LoginAttempts{}, nil
+	}
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (s *MemoryLoginAttemptStore) Reserve(key string, limits models.AttemptLimits) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.failures {
+		if now.After(entry.expiresAt) {
+			delete(s.failures, k)
+		}
+	}
+
+	entry := s.failures[key]
+	if wait := limits.RetryAfter(&entry.attempts, now); wait > 0 {
+		return wait, nil
+	}
+	entry.attempts.Failures++
+	entry.attempts.LastFailure = now
+	entry.expiresAt = now.Add(limits.Window)
+	s.failures[key] = entry
+	return 0, nil
+}
+
+func (s *MemoryLoginAttemptStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.failures[key]
+	if !ok {
+		return nil
+	}
+	entry.attempts.Failures--
+	if entry.attempts.Failures <= 0 {
+		delete(s.failures, key)
+		return nil
+	}
+	s.failures[key] = entry
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) Lock(key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, until := range s.locks {
+		if now.After(until) {
+			delete(s.locks, k)
+		}
+	}
+	s.locks[key] = now.Add(ttl)
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) LockedUntil(key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok || time.Now().After(until) {
+		delete(s.locks, key)
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+func (s *MemoryLoginAttemptStore) Unlock(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locks, key)
+	return nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"errors"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+// OAuthClientRepo handles DB operations for registered OAuth clients
+type OAuthClientRepo struct{}
+
+// Ensure OAuthClientRepo implements OAuthClientRepository interface
+var _ OAuthClientRepository = (*OAuthClientRepo)(nil)
+
+func NewOAuthClientRepo() *OAuthClientRepo {
+	return &OAuthClientRepo{}
+}
+
+func (r *OAuthClientRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.OAuthClient{})
+}
+
+func (r *OAuthClientRepo) Create(c *models.OAuthClient) error {
+	return global.DB.Create(c).Error
+}
+
+func (r *OAuthClientRepo) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	var c models.OAuthClient
+	res := global.DB.Where("client_id = ?", clientID).First(&c)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthClientNotFound
+		}
+		return nil, res.Error
+	}
+	return &c, nil
+}
+
+func (r *OAuthClientRepo) List() ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	err := global.DB.Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+// Delete removes a client together with the consents given to it and the
+// refresh tokens issued to it
+func (r *OAuthClientRepo) Delete(id uint) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		var c models.OAuthClient
+		if err := tx.First(&c, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOAuthClientNotFound
+			}
+			return err
+		}
+		for _, model := range []interface{}{&models.OAuthConsent{}, &models.OAuthRefreshToken{}} {
+			if err := tx.Where("client_id = ?", c.ClientID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&c).Error
+	})
+}
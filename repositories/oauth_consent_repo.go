@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"errors"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrConsentNotFound = errors.New("consent not found")
+
+// OAuthConsentRepo handles DB operations for the consents users gave to OAuth clients
+type OAuthConsentRepo struct{}
+
+// Ensure OAuthConsentRepo implements OAuthConsentRepository interface
+var _ OAuthConsentRepository = (*OAuthConsentRepo)(nil)
+
+func NewOAuthConsentRepo() *OAuthConsentRepo {
+	return &OAuthConsentRepo{}
+}
+
+func (r *OAuthConsentRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.OAuthConsent{})
+}
+
+func (r *OAuthConsentRepo) Find(userID uint, clientID string) (*models.OAuthConsent, error) {
+	var c models.OAuthConsent
+	res := global.DB.Where("user_id = ? AND client_id = ?", userID, clientID).First(&c)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrConsentNotFound
+		}
+		return nil, res.Error
+	}
+	return &c, nil
+}
+
+// Save creates the consent or replaces the scopes of the existing one
+func (r *OAuthConsentRepo) Save(c *models.OAuthConsent) error {
+	return global.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(c).Error
+}
+
+func (r *OAuthConsentRepo) ListForUser(userID uint) ([]models.OAuthConsent, error) {
+	var consents []models.OAuthConsent
+	err := global.DB.Where("user_id = ?", userID).Order("created_at").Find(&consents).Error
+	return consents, err
+}
+
+func (r *OAuthConsentRepo) Delete(userID uint, clientID string) error {
+	res := global.DB.Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&models.OAuthConsent{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConsentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrOAuthStateNotFound = errors.New("oauth state not found")
+
+// NewOAuthStateStore returns a Redis backed state store when Redis is
+// enabled and an in-memory one otherwise
+func NewOAuthStateStore() OAuthStateStore {
+	if global.Redis != nil {
+		return NewRedisOAuthStateStore(global.Redis)
+	}
+	return NewMemoryOAuthStateStore()
+}
+
+// RedisOAuthStateStore keeps pending authorization requests under
+// oauth:state:<state> until they are taken or expire
+type RedisOAuthStateStore struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisOAuthStateStore implements OAuthStateStore interface
+var _ OAuthStateStore = (*RedisOAuthStateStore)(nil)
+
+func NewRedisOAuthStateStore(client redis.UniversalClient) *RedisOAuthStateStore {
+	return &RedisOAuthStateStore{client: client}
+}
+
+func (s *RedisOAuthStateStore) Save(state string, data *models.OAuthState, ttl time.Duration) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), oauthStateKey(state), value, ttl).Err()
+}
+
+func (s *RedisOAuthStateStore) Take(state string) (*models.OAuthState, error) {
+	// GETDEL makes sure a state is only ever redeemed once
+	value, err := s.client.GetDel(context.Background(), oauthStateKey(state)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrOAuthStateNotFound
+		}
+		return nil, err
+	}
+
+	var data models.OAuthState
+	if err := json.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func oauthStateKey(state string) string {
+	return fmt.Sprintf("oauth:state:%s", state)
+}
+
+// MemoryOAuthStateStore keeps pending authorization requests in process
+// memory. It is only suitable for single instance deployments.
+type MemoryOAuthStateStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryOAuthState
+}
+
+type memoryOAuthState struct {
+	data      models.OAuthState
+	expiresAt time.Time
+}
+
+// Ensure MemoryOAuthStateStore implements OAuthStateStore interface
+var _ OAuthStateStore = (*MemoryOAuthStateStore)(nil)
+
+func NewMemoryOAuthStateStore() *MemoryOAuthStateStore {
+	return &MemoryOAuthStateStore{entries: make(map[string]memoryOAuthState)}
+}
+
+func (s *MemoryOAuthStateStore) Save(state string, data *models.OAuthState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.entries[state] = memoryOAuthState{data: *data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryOAuthStateStore) Take(state string) (*models.OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[state]
+	if !ok {
+		return nil, ErrOAuthStateNotFound
+	}
+	delete(s.entries, state)
+	if time.Now().After(entry.expiresAt) {
+		return nil, ErrOAuthStateNotFound
+	}
+	return &entry.data, nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+// OAuthTokenRepo handles DB operations for refresh tokens issued to OAuth clients
+type OAuthTokenRepo struct{}
+
+// Ensure OAuthTokenRepo implements OAuthTokenRepository interface
+var _ OAuthTokenRepository = (*OAuthTokenRepo)(nil)
+
+func NewOAuthTokenRepo() *OAuthTokenRepo {
+	return &OAuthTokenRepo{}
+}
+
+func (r *OAuthTokenRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.OAuthRefreshToken{})
+}
+
+func (r *OAuthTokenRepo) Create(t *models.OAuthRefreshToken) error {
+	return global.DB.Create(t).Error
+}
+
+func (r *OAuthTokenRepo) FindByHash(hash string) (*models.OAuthRefreshToken, error) {
+	var t models.OAuthRefreshToken
+	res := global.DB.Where("token_hash = ?", hash).First(&t)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, res.Error
+	}
+	return &t, nil
+}
+
+// Revoke marks a single token as revoked. It reports false when the token
+// was already revoked, which lets callers detect concurrent reuse.
+func (r *OAuthTokenRepo) Revoke(id uint) (bool, error) {
+	res := global.DB.Model(&models.OAuthRefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token that descends from the same authorization
+func (r *OAuthTokenRepo) RevokeFamily(familyID string) error {
+	return global.DB.Model(&models.OAuthRefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeForClient revokes every outstanding token a client holds for a user
+func (r *OAuthTokenRepo) RevokeForClient(userID uint, clientID string) error {
+	return global.DB.Model(&models.OAuthRefreshToken{}).
+		Where("user_id = ? AND client_id = ? AND revoked_at IS NULL", userID, clientID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+// OneTimeTokenRepo handles DB operations for single-use tokens
+type OneTimeTokenRepo struct{}
+
+// Ensure OneTimeTokenRepo implements OneTimeTokenRepository interface
+var _ OneTimeTokenRepository = (*OneTimeTokenRepo)(nil)
+
+func NewOneTimeTokenRepo() *OneTimeTokenRepo {
+	return &OneTimeTokenRepo{}
+}
+
+func (r *OneTimeTokenRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.OneTimeToken{})
+}
+
+func (r *OneTimeTokenRepo) Create(t *models.OneTimeToken) error {
+	return global.DB.Create(t).Error
+}
+
+func (r *OneTimeTokenRepo) FindByHash(hash, purpose string) (*models.OneTimeToken, error) {
+	var t models.OneTimeToken
+	res := global.DB.Where("token_hash = ? AND purpose = ?", hash, purpose).First(&t)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, res.Error
+	}
+	return &t, nil
+}
+
+// Consume marks a token as used. It reports false when the token was
+// already used, so two concurrent requests cannot both redeem it.
+func (r *OneTimeTokenRepo) Consume(id uint) (bool, error) {
+	res := global.DB.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ConsumeAllForUser invalidates every outstanding token of a user for a purpose
+func (r *OneTimeTokenRepo) ConsumeAllForUser(userID uint, purpose string) error {
+	return global.DB.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
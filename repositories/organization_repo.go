@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"errors"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMembershipNotFound   = errors.New("membership not found")
+	ErrLastOwner            = errors.New("an organization needs at least one owner")
+)
+
+// OrganizationRepo handles DB operations for organizations and their
+// memberships
+type OrganizationRepo struct{}
+
+// Ensure OrganizationRepo implements OrganizationRepository interface
+var _ OrganizationRepository = (*OrganizationRepo)(nil)
+
+func NewOrganizationRepo() *OrganizationRepo {
+	return &OrganizationRepo{}
+}
+
+func (r *OrganizationRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.Organization{}, &models.Membership{})
+}
+
+// Create adds an organization with its first owner
+func (r *OrganizationRepo) Create(o *models.Organization, ownerID uint) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(o).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{OrganizationID: o.ID, UserID: ownerID, Role: models.OrgRoleOwner}).Error
+	})
+}
+
+func (r *OrganizationRepo) FindByID(id uint) (*models.Organization, error) {
+	var o models.Organization
+	res := global.DB.First(&o, id)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, res.Error
+	}
+	return &o, nil
+}
+
+// ListForUser returns the memberships of a user with their organizations,
+// oldest first
+func (r *OrganizationRepo) ListForUser(userID uint) ([]models.Membership, error) {
+	var memberships []models.Membership
+	err := global.DB.Preload("Organization").Where("user_id = ?", userID).Order("created_at, id").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *OrganizationRepo) FindMembership(orgID, userID uint) (*models.Membership, error) {
+	var m models.Membership
+	res := global.DB.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrMembershipNotFound
+		}
+		return nil, res.Error
+	}
+	return &m, nil
+}
+
+// UpdateMemberRole changes the role of a member. Demoting the last owner
+// fails with ErrLastOwner.
+func (r *OrganizationRepo) UpdateMemberRole(orgID, userID uint, role string) error {
+	return r.changeMember(orgID, userID, func(tx *gorm.DB, m *models.Membership) error {
+		return tx.Model(m).Update("role", role).Error
+	}, role == models.OrgRoleOwner)
+}
+
+// RemoveMember ends a membership. Removing the last owner fails with
+// ErrLastOwner.
+func (r *OrganizationRepo) RemoveMember(orgID, userID uint) error {
+	return r.changeMember(orgID, userID, func(tx *gorm.DB, m *models.Membership) error {
+		return tx.Delete(m).Error
+	}, false)
+}
+
+// changeMember applies change to a membership. The owners of the
+// organization are locked first, so concurrent changes cannot remove the
+// last one between the check and the write; keepsOwner tells that the
+// change leaves an owner in place.
+func (r *OrganizationRepo) changeMember(orgID, userID uint, change func(tx *gorm.DB, m *models.Membership) error, keepsOwner bool) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		var owners []models.Membership
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ? AND role = ?", orgID, models.OrgRoleOwner).
+			Find(&owners).Error; err != nil {
+			return err
+		}
+
+		var m models.Membership
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&m).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMembershipNotFound
+			}
+			return err
+		}
+		if m.Role == models.OrgRoleOwner && !keepsOwner && len(owners) == 1 {
+			return ErrLastOwner
+		}
+		return change(tx, &m)
+	})
+}
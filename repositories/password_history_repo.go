@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepo handles DB operations for previous password hashes
+type PasswordHistoryRepo struct{}
+
+// Ensure PasswordHistoryRepo implements PasswordHistoryRepository interface
+var _ PasswordHistoryRepository = (*PasswordHistoryRepo)(nil)
+
+func NewPasswordHistoryRepo() *PasswordHistoryRepo {
+	return &PasswordHistoryRepo{}
+}
+
+func (r *PasswordHistoryRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.PasswordHistory{})
+}
+
+// Add records a password hash of the user and forgets all but the newest
+// keep entries
+func (r *PasswordHistoryRepo) Add(userID uint, hash string, keep int) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+			return err
+		}
+		// The history is short, so the IDs are pruned in Go rather than
+		// with an OFFSET that MySQL only accepts together with LIMIT
+		var ids []uint
+		if err := tx.Model(&models.PasswordHistory{}).
+			Where("user_id = ?", userID).
+			Order("id DESC").
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) <= keep {
+			return nil
+		}
+		return tx.Delete(&models.PasswordHistory{}, ids[keep:]).Error
+	})
+}
+
+// Recent returns the newest limit password hashes of the user
+func (r *PasswordHistoryRepo) Recent(userID uint, limit int) ([]string, error) {
+	var hashes []string
+	err := global.DB.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
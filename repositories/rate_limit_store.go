@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRateLimitStore returns a Redis backed rate limit store when Redis is
+// enabled, so limits hold across instances, and an in-memory one otherwise
+func NewRateLimitStore() RateLimitStore {
+	if global.Redis != nil {
+		return NewRedisRateLimitStore(global.Redis)
+	}
+	return NewMemoryRateLimitStore()
+}
+
+// slidingWindowScript keeps the timestamps of the requests within the
+// window in a sorted set and adds the current one if there is room.
+// Returns {allowed, count, ms until the oldest request leaves the window}.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// tokenBucketScript refills the bucket for the time passed since the last
+// request and takes a token if one is left. Returns {allowed, tokens left,
+// ms until the next token}.
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) / interval)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil((capacity - tokens) * interval)))
+local reset = 0
+if tokens < capacity then
+	reset = math.ceil((1 - (tokens - math.floor(tokens))) * interval)
+end
+return {allowed, math.floor(tokens), reset}
+`)
+
+// RedisRateLimitStore keeps request counters under ratelimit:<key>
+type RedisRateLimitStore struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisRateLimitStore implements RateLimitStore interface
+var _ RateLimitStore = (*RedisRateLimitStore)(nil)
+
+func NewRedisRateLimitStore(client redis.UniversalClient) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+func (s *RedisRateLimitStore) SlidingWindow(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	now := time.Now()
+	// The member only has to be unique, the score carries the time
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+	values, err := slidingWindowScript.Run(context.Background(), s.client, []string{rateLimitKey(key)},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return rateLimitResult(values, limit)
+}
+
+func (s *RedisRateLimitStore) TokenBucket(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	interval := float64(window.Milliseconds()) / float64(limit)
+	values, err := tokenBucketScript.Run(context.Background(), s.client, []string{rateLimitKey(key)},
+		time.Now().UnixMilli(), limit, strconv.FormatFloat(interval, 'f', -1, 64)).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	result, err := rateLimitResult(values, limit)
+	if err != nil {
+		return nil, err
+	}
+	// The script returns the tokens left rather than the requests made
+	result.Remaining = int(values[1])
+	return result, nil
+}
+
+// rateLimitResult converts an {allowed, count, reset ms} script reply
+func rateLimitResult(values []int64, limit int) (*models.RateLimitResult, error) {
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit reply %v", values)
+	}
+	result := &models.RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: max(0, limit-int(values[1])),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+	return result, nil
+}
+
+func rateLimitKey(key string) string {
+	return "ratelimit:" + key
+}
+
+// MemoryRateLimitStore keeps request counters in process memory. Limits are
+// per instance, which is only suitable for development and single-instance
+// deployments.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryWindow struct {
+	requests  []time.Time
+	expiresAt time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// Ensure MemoryRateLimitStore implements RateLimitStore interface
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*memoryWindow),
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+func (s *MemoryRateLimitStore) SlidingWindow(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	w, ok := s.windows[key]
+	if !ok {
+		w = &memoryWindow{}
+		s.windows[key] = w
+	}
+	// Drop the requests that have left the window
+	start := 0
+	for start < len(w.requests) && !w.requests[start].After(now.Add(-window)) {
+		start++
+	}
+	w.requests = w.requests[start:]
+
+	result := &models.RateLimitResult{Limit: limit, Reset: window}
+	if len(w.requests) < limit {
+		w.requests = append(w.requests, now)
+		result.Allowed = true
+	}
+	result.Remaining = limit - len(w.requests)
+	if len(w.requests) > 0 {
+		result.Reset = w.requests[0].Add(window).Sub(now)
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+	w.expiresAt = now.Add(window)
+	return result, nil
+}
+
+func (s *MemoryRateLimitStore) TokenBucket(key string, limit int, window time.Duration) (*models.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	interval := window / time.Duration(limit)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit), updatedAt: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.updatedAt))/float64(interval))
+	b.updatedAt = now
+
+	result := &models.RateLimitResult{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	}
+	result.Remaining = int(b.tokens)
+	if b.tokens < float64(limit) {
+		// Time until the fraction of the next token is refilled
+		result.Reset = time.Duration((1 - (b.tokens - math.Floor(b.tokens))) * float64(interval))
+	}
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+	b.expiresAt = now.Add(time.Duration((float64(limit) - b.tokens) * float64(interval)))
+	return result, nil
+}
+
+// sweep drops expired counters at most once a minute so the maps do not
+// grow with every client ever seen
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for k, w := range s.windows {
+		if now.After(w.expiresAt) {
+			delete(s.windows, k)
+		}
+	}
+	for k, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore_SlidingWindow(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	for i := 0; i < 3; i++ {
+		result, err := store.SlidingWindow("ip:192.0.2.1", 3, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := store.SlidingWindow("ip:192.0.2.1", 3, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 50*time.Millisecond)
+
+	// Other keys are counted separately
+	result, err = store.SlidingWindow("ip:192.0.2.2", 3, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Requests leave the window
+	time.Sleep(60 * time.Millisecond)
+	result, err = store.SlidingWindow("ip:192.0.2.1", 3, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryRateLimitStore_TokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	// A full bucket allows a burst of limit requests
+	for i := 0; i < 2; i++ {
+		result, err := store.TokenBucket("user:1", 2, 100*time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := store.TokenBucket("user:1", 2, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0 && result.RetryAfter <= 50*time.Millisecond)
+
+	// One token is refilled every window / limit
+	time.Sleep(60 * time.Millisecond)
+	result, err = store.TokenBucket("user:1", 2, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepo handles DB operations for MFA recovery codes
+type RecoveryCodeRepo struct{}
+
+// Ensure RecoveryCodeRepo implements RecoveryCodeRepository interface
+var _ RecoveryCodeRepository = (*RecoveryCodeRepo)(nil)
+
+func NewRecoveryCodeRepo() *RecoveryCodeRepo {
+	return &RecoveryCodeRepo{}
+}
+
+func (r *RecoveryCodeRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.RecoveryCode{})
+}
+
+// ReplaceForUser deletes the existing codes of a user and stores new ones
+func (r *RecoveryCodeRepo) ReplaceForUser(userID uint, hashes []string) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks an unused code of the user as used. It reports false when
+// no such code exists.
+func (r *RecoveryCodeRepo) Consume(userID uint, hash string) (bool, error) {
+	res := global.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *RecoveryCodeRepo) DeleteForUser(userID uint) error {
+	return global.DB.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
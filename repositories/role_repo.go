@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"errors"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+// RoleRepo handles DB operations for roles, permissions and role assignments
+type RoleRepo struct{}
+
+// Ensure RoleRepo implements RoleRepository interface
+var _ RoleRepository = (*RoleRepo)(nil)
+
+func NewRoleRepo() *RoleRepo {
+	return &RoleRepo{}
+}
+
+// Migrate creates the role and permission tables. It has to run before the
+// users are migrated because user_roles references roles.
+func (r *RoleRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.Permission{}, &models.Role{})
+}
+
+// EnsureRole creates a role and its permissions if they do not exist yet.
+// Permissions that were granted to an existing role are kept.
+func (r *RoleRepo) EnsureRole(name string, permissions []string) error {
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		role := models.Role{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		for _, p := range permissions {
+			perm := models.Permission{Name: p}
+			if err := tx.Where("name = ?", p).FirstOrCreate(&perm).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&role).Association("Permissions").Append(&perm); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *RoleRepo) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	res := global.DB.Preload("Permissions").Where("name = ?", name).First(&role)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, res.Error
+	}
+	return &role, nil
+}
+
+// AssignRole grants a role to a user. Assigning a role twice is a no-op.
+func (r *RoleRepo) AssignRole(userID uint, name string) error {
+	role, err := r.FindByName(name)
+	if err != nil {
+		return err
+	}
+	return global.DB.Model(&models.User{ID: userID}).Association("Roles").Append(role)
+}
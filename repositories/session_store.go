@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// NewSessionStore returns a Redis backed session store when Redis is enabled
+// and an in-memory one otherwise
+func NewSessionStore() SessionStore {
+	if global.Redis != nil {
+		return NewRedisSessionStore(global.Redis)
+	}
+	return NewMemorySessionStore()
+}
+
+// RedisSessionStore keeps the sessions of a user in a single Redis hash
+// stored under user:token:<id>, keyed by session ID
+type RedisSessionStore struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisSessionStore implements SessionStore interface
+var _ SessionStore = (*RedisSessionStore)(nil)
+
+func NewRedisSessionStore(client redis.UniversalClient) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+func (s *RedisSessionStore) Save(session *models.Session) error {
+	ctx := context.Background()
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// Sessions share the lifetime of the hash, so it must last as long as
+	// the longest lived one. Saving a session again, as reauthentication
+	// and switching organizations do, never shortens it.
+	ttl := time.Until(session.ExpiresAt).Milliseconds()
+	if ttl < 1 {
+		ttl = 1
+	}
+	return saveSessionScript.Run(ctx, s.client, []string{sessionKey(session.UserID)}, session.ID, data, ttl).Err()
+}
+
+// saveSessionScript stores a session and extends the expiry of the hash to
+// ARGV[3] milliseconds unless it already lives longer
+var saveSessionScript = redis.NewScript(`
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 or ttl < tonumber(ARGV[3]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+func (s *RedisSessionStore) Get(userID uint, id string) (*models.Session, error) {
+	data, err := s.client.HGet(context.Background(), sessionKey(userID), id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// touchSessionScript updates the client IP and last use of a session in
+// place, so it neither revives a revoked session nor overwrites a token
+// rotation that happened since the session was read. Returns 0 when the
+// session does not exist.
+var touchSessionScript = redis.NewScript(`
+local data = redis.call('HGET', KEYS[1], ARGV[1])
+if not data then
+	return 0
+end
+local session = cjson.decode(data)
+session['ip'] = ARGV[2]
+session['last_seen_at'] = ARGV[3]
+redis.call('HSET', KEYS[1], ARGV[1], cjson.encode(session))
+return 1
+`)
+
+func (s *RedisSessionStore) Touch(userID uint, id, ip string, at time.Time) (bool, error) {
+	n, err := touchSessionScript.Run(context.Background(), s.client, []string{sessionKey(userID)}, id, ip, at.Format(time.RFC3339Nano)).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *RedisSessionStore) List(userID uint) ([]models.Session, error) {
+	ctx := context.Background()
+	key := sessionKey(userID)
+	values, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]models.Session, 0, len(values))
+	for id, data := range values {
+		var session models.Session
+		if err := json.Unmarshal([]byte(data), &session); err != nil || now.After(session.ExpiresAt) {
+			// Drop corrupt and expired entries as we go
+			s.client.HDel(ctx, key, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *RedisSessionStore) Delete(userID uint, id string) error {
+	return s.client.HDel(context.Background(), sessionKey(userID), id).Err()
+}
+
+func (s *RedisSessionStore) DeleteAll(userID uint) error {
+	return s.client.Del(context.Background(), sessionKey(userID)).Err()
+}
+
+func sessionKey(userID uint) string {
+	return fmt.Sprintf("user:token:%d", userID)
+}
+
+// MemorySessionStore keeps sessions in process memory.
+// It is only suitable for single instance deployments.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[uint]map[string]models.Session
+}
+
+// Ensure MemorySessionStore implements SessionStore interface
+var _ SessionStore = (*MemorySessionStore)(nil)
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[uint]map[string]models.Session)}
+}
+
+func (s *MemorySessionStore) Save(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions[session.UserID] == nil {
+		s.sessions[session.UserID] = make(map[string]models.Session)
+	}
+	s.sessions[session.UserID][session.ID] = *session
+	return nil
+}
+
+func (s *MemorySessionStore) Get(userID uint, id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[userID][id]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *MemorySessionStore) Touch(userID uint, id, ip string, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[userID][id]
+	if !ok || at.After(session.ExpiresAt) {
+		return false, nil
+	}
+	session.IP = ip
+	session.LastSeenAt = at
+	s.sessions[userID][id] = session
+	return true, nil
+}
+
+func (s *MemorySessionStore) List(userID uint) ([]models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]models.Session, 0, len(s.sessions[userID]))
+	for id, session := range s.sessions[userID] {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions[userID], id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *MemorySessionStore) Delete(userID uint, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions[userID], id)
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteAll(userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, userID)
+	return nil
+}
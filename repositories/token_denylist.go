@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"temp/global"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewTokenDenylist returns a Redis backed denylist when Redis is enabled and
+// an in-memory one otherwise
+func NewTokenDenylist() TokenDenylist {
+	if global.Redis != nil {
+		return NewRedisTokenDenylist(global.Redis)
+	}
+	return NewMemoryTokenDenylist()
+}
+
+// RedisTokenDenylist stores revoked token IDs in Redis until they expire
+type RedisTokenDenylist struct {
+	client redis.UniversalClient
+}
+
+// Ensure RedisTokenDenylist implements TokenDenylist interface
+var _ TokenDenylist = (*RedisTokenDenylist)(nil)
+
+func NewRedisTokenDenylist(client redis.UniversalClient) *RedisTokenDenylist {
+	return &RedisTokenDenylist{client: client}
+}
+
+func (d *RedisTokenDenylist) Add(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(context.Background(), denylistKey(jti), 1, ttl).Err()
+}
+
+func (d *RedisTokenDenylist) Contains(jti string) (bool, error) {
+	err := d.client.Get(context.Background(), denylistKey(jti)).Err()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func denylistKey(jti string) string {
+	return fmt.Sprintf("token:denylist:%s", jti)
+}
+
+// MemoryTokenDenylist keeps revoked token IDs in process memory.
+// It is only suitable for single instance deployments.
+type MemoryTokenDenylist struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// Ensure MemoryTokenDenylist implements TokenDenylist interface
+var _ TokenDenylist = (*MemoryTokenDenylist)(nil)
+
+func NewMemoryTokenDenylist() *MemoryTokenDenylist {
+	return &MemoryTokenDenylist{entries: make(map[string]time.Time)}
+}
+
+func (d *MemoryTokenDenylist) Add(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range d.entries {
+		if now.After(exp) {
+			delete(d.entries, id)
+		}
+	}
+	d.entries[jti] = now.Add(ttl)
+	return nil
+}
+
+func (d *MemoryTokenDenylist) Contains(jti string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	exp, ok := d.entries[jti]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(exp) {
+		delete(d.entries, jti)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"temp/models"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTokenDenylist(t *testing.T) {
+	denylist := NewMemoryTokenDenylist()
+
+	assert.NoError(t, denylist.Add("revoked", time.Minute))
+	assert.NoError(t, denylist.Add("expired", time.Millisecond))
+	// Tokens that have already expired never need to be denylisted
+	assert.NoError(t, denylist.Add("ignored", 0))
+
+	time.Sleep(5 * time.Millisecond)
+
+	revoked, err := denylist.Contains("revoked")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	expired, err := denylist.Contains("expired")
+	assert.NoError(t, err)
+	assert.False(t, expired)
+
+	ignored, err := denylist.Contains("ignored")
+	assert.NoError(t, err)
+	assert.False(t, ignored)
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	store := NewMemorySessionStore()
+	active := &models.Session{ID: "active", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &models.Session{ID: "expired", UserID: 1, ExpiresAt: time.Now().Add(-time.Hour)}
+
+	assert.NoError(t, store.Save(active))
+	assert.NoError(t, store.Save(expired))
+
+	sessions, err := store.List(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "active", sessions[0].ID)
+
+	_, err = store.Get(1, "expired")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	seenAt := time.Now()
+	touched, err := store.Touch(1, "active", "198.51.100.4", seenAt)
+	assert.NoError(t, err)
+	assert.True(t, touched)
+	session, err := store.Get(1, "active")
+	assert.NoError(t, err)
+	assert.Equal(t, "198.51.100.4", session.IP)
+	assert.Equal(t, seenAt, session.LastSeenAt)
+
+	assert.NoError(t, store.Delete(1, "active"))
+	_, err = store.Get(1, "active")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+
+	// Touching a revoked session does not bring it back
+	touched, err = store.Touch(1, "active", "198.51.100.4", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, touched)
+	_, err = store.Get(1, "active")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestRedisSessionStore_SaveKeepsLongestExpiry(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := NewRedisSessionStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	older := &models.Session{ID: "older", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	newer := &models.Session{ID: "newer", UserID: 1, ExpiresAt: time.Now().Add(48 * time.Hour)}
+
+	assert.NoError(t, store.Save(older))
+	assert.NoError(t, store.Save(newer))
+	// Saving the older session again, as reauthentication does, must not
+	// shorten the lifetime of the hash holding the newer one
+	assert.NoError(t, store.Save(older))
+
+	server.FastForward(2 * time.Hour)
+
+	session, err := store.Get(1, "newer")
+	assert.NoError(t, err)
+	assert.Equal(t, "newer", session.ID)
+}
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	limits := models.AttemptLimits{Window: time.Minute}
+
+	_, err := store.Reserve("account:a@example.com", limits)
+	assert.NoError(t, err)
+	_, err = store.Reserve("account:a@example.com", limits)
+	assert.NoError(t, err)
+	attempts, err := store.Get("account:a@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), attempts.Failures)
+
+	assert.NoError(t, store.Release("account:a@example.com"))
+	attempts, err = store.Get("account:a@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), attempts.Failures)
+
+	// Counters are forgotten a window after the last attempt
+	_, err = store.Reserve("ip:192.0.2.1", models.AttemptLimits{Window: time.Millisecond})
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	attempts, err = store.Get("ip:192.0.2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), attempts.Failures)
+
+	assert.NoError(t, store.Reset("account:a@example.com"))
+	attempts, err = store.Get("account:a@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), attempts.Failures)
+
+	assert.NoError(t, store.Lock("account:a@example.com", time.Minute))
+	until, err := store.LockedUntil("account:a@example.com")
+	assert.NoError(t, err)
+	assert.True(t, until.After(time.Now()))
+
+	assert.NoError(t, store.Unlock("account:a@example.com"))
+	until, err = store.LockedUntil("account:a@example.com")
+	assert.NoError(t, err)
+	assert.True(t, until.IsZero())
+}
+
+func TestRedisLoginAttemptStore_Reserve(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := NewRedisLoginAttemptStore(redis.NewClient(&redis.Options{Addr: server.Addr()}))
+	limits := models.AttemptLimits{Threshold: 3, Window: time.Minute, DelayAfter: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+
+	for i := 0; i < 2; i++ {
+		wait, err := store.Reserve("ip:192.0.2.1", limits)
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+
+	// The delay refuses the next attempt without counting it
+	wait, err := store.Reserve("ip:192.0.2.1", limits)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Second, wait, float64(100*time.Millisecond))
+	attempts, err := store.Get("ip:192.0.2.1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), attempts.Failures)
+
+	assert.NoError(t, store.Release("ip:192.0.2.1"))
+	assert.NoError(t, store.Release("ip:192.0.2.1"))
+	assert.False(t, server.Exists("login:failures:ip:192.0.2.1"))
+
+	// Releasing an expired counter does not leave a negative one behind
+	assert.NoError(t, store.Release("ip:192.0.2.1"))
+	assert.False(t, server.Exists("login:failures:ip:192.0.2.1"))
+}
+
+func TestMemoryOAuthStateStore(t *testing.T) {
+	store := NewMemoryOAuthStateStore()
+
+	assert.NoError(t, store.Save("state", &models.OAuthState{Provider: "google", Nonce: "nonce", CodeVerifier: "verifier"}, time.Minute))
+	assert.NoError(t, store.Save("expired", &models.OAuthState{Provider: "google"}, time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	data, err := store.Take("state")
+	assert.NoError(t, err)
+	assert.Equal(t, "verifier", data.CodeVerifier)
+
+	// A state can only be taken once
+	_, err = store.Take("state")
+	assert.ErrorIs(t, err, ErrOAuthStateNotFound)
+
+	_, err = store.Take("expired")
+	assert.ErrorIs(t, err, ErrOAuthStateNotFound)
+}
+
+func TestMemoryAuthorizationCodeStore(t *testing.T) {
+	store := NewMemoryAuthorizationCodeStore()
+
+	assert.NoError(t, store.Save("code", &models.AuthorizationCode{ClientID: "app", UserID: 1, CodeChallenge: "challenge"}, time.Minute))
+	assert.NoError(t, store.Save("expired", &models.AuthorizationCode{ClientID: "app"}, time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	code, err := store.Take("code")
+	assert.NoError(t, err)
+	assert.Equal(t, "challenge", code.CodeChallenge)
+
+	// A code can only be redeemed once
+	_, err = store.Take("code")
+	assert.ErrorIs(t, err, ErrAuthorizationCodeNotFound)
+
+	_, err = store.Take("expired")
+	assert.ErrorIs(t, err, ErrAuthorizationCodeNotFound)
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"temp/global"
+	"temp/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenRepo handles DB operations for refresh tokens
+type TokenRepo struct{}
+
+// Ensure TokenRepo implements TokenRepository interface
+var _ TokenRepository = (*TokenRepo)(nil)
+
+func NewTokenRepo() *TokenRepo {
+	return &TokenRepo{}
+}
+
+func (r *TokenRepo) Migrate() error {
+	return global.DB.AutoMigrate(&models.RefreshToken{})
+}
+
+func (r *TokenRepo) Create(t *models.RefreshToken) error {
+	return global.DB.Create(t).Error
+}
+
+func (r *TokenRepo) FindByHash(hash string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	res := global.DB.Where("token_hash = ?", hash).First(&t)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, res.Error
+	}
+	return &t, nil
+}
+
+// Revoke marks a single token as revoked. It reports false when the token
+// was already revoked, which lets callers detect concurrent reuse.
+func (r *TokenRepo) Revoke(id uint) (bool, error) {
+	res := global.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token that descends from the same login
+func (r *TokenRepo) RevokeFamily(familyID string) error {
+	return global.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user
+func (r *TokenRepo) RevokeAllForUser(userID uint) error {
+	return global.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
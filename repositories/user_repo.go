@@ -2,35 +2,126 @@ package repositories
 
 import (
 	"errors"
+	"strings"
 	"temp/global"
 	"temp/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var ErrUserNotFound = errors.New("user not found")
 
-// UserRepo handles DB operations for users
-type UserRepo struct{}
+// UserSortColumns maps the fields users can be sorted by to their columns
+var UserSortColumns = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+// UserQuery selects a page of users. Email and Name match substrings and
+// Status is one of the models.UserStatus values. Pages are selected either
+// by Offset or, for keyset pagination, by After: the sort value and ID of
+// the last user of the previous page.
+type UserQuery struct {
+	Email         string
+	Name          string
+	Status        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	SortField     string
+	SortDesc      bool
+	Limit         int
+	Offset        int
+	After         *UserCursor
+}
+
+// UserCursor is the position after which a keyset page starts
+type UserCursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// UserRepo handles DB operations for users. A repo only sees the members
+// of its organization, so callers cannot reach other tenants' users by
+// mistake. The few system paths that work across organizations get their
+// repo from a constructor named after them: NewAuthUserRepo and
+// NewAdminUserRepo.
+type UserRepo struct {
+	orgID       uint
+	crossTenant bool
+}
 
 // Ensure UserRepo implements UserRepository interface
 var _ UserRepository = (*UserRepo)(nil)
 
-func NewUserRepo() *UserRepo {
-	return &UserRepo{}
+// NewUserRepo returns a repo limited to the members of an organization
+func NewUserRepo(orgID uint) *UserRepo {
+	return &UserRepo{orgID: orgID}
+}
+
+// TenantUserRepos returns the repo of an organization. Services that serve
+// an organization's data take it to scope each request to the active
+// organization.
+func TenantUserRepos(orgID uint) UserRepository {
+	return NewUserRepo(orgID)
+}
+
+// NewAuthUserRepo returns a repo that sees every user, for authentication:
+// logins, token issuance and refresh, credential recovery and the signed in
+// user's own account
+func NewAuthUserRepo() *UserRepo {
+	return &UserRepo{crossTenant: true}
+}
+
+// NewAdminUserRepo returns a repo that sees every user, for platform
+// administration: the admin API, role assignment, imports and the CLI
+func NewAdminUserRepo() *UserRepo {
+	return &UserRepo{crossTenant: true}
+}
+
+// db starts a query on the users the repo may see
+func (r *UserRepo) db() *gorm.DB {
+	if r.crossTenant {
+		return global.DB
+	}
+	members := global.DB.Model(&models.Membership{}).Select("user_id").Where("organization_id = ?", r.orgID)
+	return global.DB.Where("users.id IN (?)", members)
+}
+
+// preloadMemberships loads the memberships a repo may reveal: all of them,
+// or in a tenant repo only the one in its organization, so a tenant does
+// not learn which other organizations its members belong to
+func (r *UserRepo) preloadMemberships(db *gorm.DB) *gorm.DB {
+	if r.crossTenant {
+		return db.Preload("Memberships")
+	}
+	return db.Preload("Memberships", "organization_id = ?", r.orgID)
 }
 
 func (r *UserRepo) Migrate() error {
 	return global.DB.AutoMigrate(&models.User{})
 }
 
+// Create adds a user. Users created through an organization's repo join
+// the organization as members.
 func (r *UserRepo) Create(u *models.User) error {
-	return global.DB.Create(u).Error
+	if r.crossTenant {
+		return global.DB.Create(u).Error
+	}
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{OrganizationID: r.orgID, UserID: u.ID, Role: models.OrgRoleMember}).Error
+	})
 }
 
 func (r *UserRepo) FindByEmail(email string) (*models.User, error) {
 	var u models.User
-	res := global.DB.Where("email = ?", email).First(&u)
+	res := r.preloadMemberships(r.db().Preload("Roles.Permissions")).Where("email = ?", email).First(&u)
 	if res.Error != nil {
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -42,7 +133,7 @@ func (r *UserRepo) FindByEmail(email string) (*models.User, error) {
 
 func (r *UserRepo) FindByID(id uint) (*models.User, error) {
 	var u models.User
-	res := global.DB.First(&u, id)
+	res := r.preloadMemberships(r.db().Preload("Roles.Permissions")).First(&u, id)
 	if res.Error != nil {
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrUserNotFound
@@ -51,3 +142,134 @@ func (r *UserRepo) FindByID(id uint) (*models.User, error) {
 	}
 	return &u, nil
 }
+
+// Update saves the user's columns. Role assignments and memberships are
+// managed by their own repositories and are left untouched.
+func (r *UserRepo) Update(u *models.User) error {
+	if err := r.checkVisible(u.ID); err != nil {
+		return err
+	}
+	return global.DB.Omit(clause.Associations).Save(u).Error
+}
+
+// AdvanceTOTPStep records the time step of an accepted TOTP code. It reports
+// false when the same or a later step was already used, so a code cannot be
+// replayed, not even by two concurrent requests.
+func (r *UserRepo) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	res := r.db().Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// List returns a page of users matching the query and the number of users
+// matching its filters
+func (r *UserRepo) List(q UserQuery) ([]models.User, int64, error) {
+	db := r.db().Model(&models.User{})
+	if q.Email != "" {
+		db = db.Where("email LIKE ? ESCAPE '!'", "%"+escapeLike(q.Email)+"%")
+	}
+	if q.Name != "" {
+		db = db.Where("name LIKE ? ESCAPE '!'", "%"+escapeLike(q.Name)+"%")
+	}
+	switch q.Status {
+	case models.UserStatusActive:
+		db = db.Where("disabled_at IS NULL")
+	case models.UserStatusDisabled:
+		db = db.Where("disabled_at IS NOT NULL")
+	case models.UserStatusUnverified:
+		db = db.Where("email_verified_at IS NULL")
+	}
+	if q.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *q.CreatedBefore)
+	}
+
+	// The filters are shared by the count and the page query
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := UserSortColumns[q.SortField]
+	if !ok {
+		column = "id"
+	}
+	dir, cmp := "ASC", ">"
+	if q.SortDesc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.After != nil {
+		if column == "id" {
+			db = db.Where("id "+cmp+" ?", q.After.ID)
+		} else {
+			db = db.Where("(("+column+" "+cmp+" ?) OR ("+column+" = ? AND id "+cmp+" ?))", q.After.Value, q.After.Value, q.After.ID)
+		}
+	}
+	// id breaks ties so every user has a stable position
+	db = db.Order(column + " " + dir)
+	if column != "id" {
+		db = db.Order("id " + dir)
+	}
+
+	var users []models.User
+	err := r.preloadMemberships(db.Preload("Roles")).Limit(q.Limit).Offset(q.Offset).Find(&users).Error
+	return users, total, err
+}
+
+// Delete removes a user together with their credentials, role assignments
+// and memberships. An organization's repo only deletes its members.
+func (r *UserRepo) Delete(id uint) error {
+	if err := r.checkVisible(id); err != nil {
+		return err
+	}
+	return global.DB.Transaction(func(tx *gorm.DB) error {
+		u := &models.User{ID: id}
+		if err := tx.Model(u).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.RefreshToken{}, &models.OneTimeToken{}, &models.RecoveryCode{}, &models.APIKey{}, &models.PasswordHistory{}, &models.LinkedIdentity{}, &models.OAuthConsent{}, &models.OAuthRefreshToken{}, &models.Membership{}} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Delete(u)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+// checkVisible returns ErrUserNotFound when an organization's repo may not
+// see the user. Writes that gorm cannot filter by a subquery check first.
+func (r *UserRepo) checkVisible(id uint) error {
+	if r.crossTenant {
+		return nil
+	}
+	var count int64
+	if err := r.db().Model(&models.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied search term.
+// '!' is used as escape character because MySQL and SQLite disagree on
+// backslashes in string literals.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -39,7 +39,7 @@ func TestUserRepo_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// This test would require a real database connection
 			// For now, we'll test the interface compliance
-			repo := NewUserRepo()
+			repo := NewAuthUserRepo()
 			assert.NotNil(t, repo)
 			
 			// Test that the repo implements the interface
@@ -77,7 +77,7 @@ func TestUserRepo_FindByEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := NewUserRepo()
+			repo := NewAuthUserRepo()
 			assert.NotNil(t, repo)
 			
 			// Test that the repo implements the interface
@@ -115,7 +115,7 @@ func TestUserRepo_FindByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := NewUserRepo()
+			repo := NewAuthUserRepo()
 			assert.NotNil(t, repo)
 			
 			// Test that the repo implements the interface
@@ -124,6 +124,16 @@ func TestUserRepo_FindByID(t *testing.T) {
 	}
 }
 
+func TestUserRepo_TenantScopeIsDefault(t *testing.T) {
+	// Repos see one organization unless built for a cross-tenant path
+	assert.False(t, (&UserRepo{}).crossTenant)
+	assert.False(t, NewUserRepo(3).crossTenant)
+	assert.Equal(t, uint(3), NewUserRepo(3).orgID)
+	assert.Equal(t, NewUserRepo(3), TenantUserRepos(3))
+	assert.True(t, NewAuthUserRepo().crossTenant)
+	assert.True(t, NewAdminUserRepo().crossTenant)
+}
+
 // MockUserRepository is a mock implementation of UserRepository interface
 type MockUserRepository struct {
 	mock.Mock
@@ -158,6 +168,29 @@ func (m *MockUserRepository) FindByID(id uint) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Update(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) List(q UserQuery) ([]models.User, int64, error) {
+	args := m.Called(q)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 // TestMockUserRepository tests the mock implementation
 func TestMockUserRepository(t *testing.T) {
 	mockRepo := &MockUserRepository{}
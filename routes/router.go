@@ -1,16 +1,21 @@
 package routes
 
 import (
+	"net/http"
+	"time"
+
 	"temp/config"
 	"temp/handlers"
 	"temp/middlewares"
+	"temp/models"
+	"temp/utils"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(userHandler *handlers.UserHandler, jwtSecret string, cfg *config.Config) *gin.Engine {
+func NewRouter(userHandler *handlers.UserHandler, keyHandler *handlers.KeyHandler, mfaHandler *handlers.MFAHandler, apiKeyHandler *handlers.APIKeyHandler, adminHandler *handlers.AdminHandler, socialLoginHandler *handlers.SocialLoginHandler, oauthHandler *handlers.OAuthHandler, sessionHandler *handlers.SessionHandler, orgHandler *handlers.OrganizationHandler, invitationHandler *handlers.InvitationHandler, tokens *utils.TokenManager, denylist middlewares.TokenDenylist, sessions middlewares.SessionTracker, apiKeys middlewares.APIKeyAuthenticator, limiter middlewares.RateLimiter, cfg *config.Config) *gin.Engine {
 	r := gin.New()
 	_ = r.SetTrustedProxies([]string{"127.0.0.1", "::1", "localhost"})
 
@@ -40,29 +45,172 @@ func NewRouter(userHandler *handlers.UserHandler, jwtSecret string, cfg *config.
 		})
 	})
 
+	// Public signing keys for services that verify our tokens
+	r.GET("/.well-known/jwks.json", keyHandler.JWKS)
+
+	// OAuth2 authorization server for internal applications
+	if cfg.OAuthServer.Enabled {
+		r.GET("/.well-known/openid-configuration", oauthHandler.Discovery)
+
+		oauth := r.Group("/oauth")
+		oauth.Use(rateLimit(limiter, cfg, "global"))
+		{
+			oauth.POST("/token", oauthHandler.Token)
+			oauth.POST("/introspect", oauthHandler.Introspect)
+			oauth.POST("/revoke", oauthHandler.Revoke)
+			oauth.GET("/userinfo", middlewares.AuthMiddleware(tokens, denylist, sessions, apiKeys), middlewares.RequireScope(models.ScopeOpenID), oauthHandler.UserInfo)
+		}
+	}
+
 	// Swagger documentation endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API v1 routes
 	api := r.Group("/api/v1")
+	api.Use(rateLimit(limiter, cfg, "global"), sessionCookies(cfg))
 	{
 		// Public routes
-		api.POST("/register", userHandler.Register)
-		api.POST("/login", userHandler.Login)
+		public := api.Group("")
+		public.Use(rateLimit(limiter, cfg, "auth"))
+		{
+			public.POST("/register", userHandler.Register)
+			public.POST("/login", userHandler.Login)
+			public.POST("/login/mfa", mfaHandler.CompleteLogin)
+			public.POST("/login/magic-link", userHandler.RequestMagicLink)
+			public.POST("/login/magic-link/verify", userHandler.MagicLinkLogin)
+			public.GET("/auth/providers", socialLoginHandler.Providers)
+			public.GET("/auth/:provider/login", socialLoginHandler.Login)
+			public.GET("/auth/:provider/callback", socialLoginHandler.Callback)
+			public.POST("/token/refresh", userHandler.RefreshToken)
+			public.POST("/verify-email", userHandler.VerifyEmail)
+			public.POST("/resend-verification", userHandler.ResendVerification)
+			public.POST("/forgot-password", userHandler.ForgotPassword)
+			public.POST("/reset-password", userHandler.ResetPassword)
+			public.POST("/unlock-account", userHandler.UnlockAccount)
+			public.POST("/invitations/lookup", invitationHandler.Lookup)
+			public.POST("/invitations/register", invitationHandler.Register)
+		}
 		
-		// Protected routes
+		// Protected routes, open to login sessions and API keys with the scope
 		protected := api.Group("")
-		protected.Use(middlewares.AuthMiddleware(jwtSecret))
+		protected.Use(middlewares.AuthMiddleware(tokens, denylist, sessions, apiKeys), rateLimit(limiter, cfg, "api"))
 		{
-			protected.GET("/profile", userHandler.Profile)
-			protected.PUT("/profile", userHandler.UpdateProfile)
-			protected.POST("/change-password", userHandler.ChangePassword)
+			protected.GET("/profile", middlewares.RequireScope(models.ScopeProfileRead), userHandler.Profile)
+			protected.PUT("/profile", middlewares.RequireScope(models.ScopeProfileWrite), userHandler.UpdateProfile)
 		}
-		
-		// Redis operations (can be protected if needed)
-		api.POST("/set-redis-key", userHandler.SetRedisKey)
-		api.GET("/get-redis-key/:key", userHandler.GetRedisKey)
+
+		// Account and credential management is not available to API keys.
+		// Sensitive changes also need a recent login or reauthentication.
+		recentAuth := middlewares.RequireRecentAuth(time.Duration(cfg.Auth.RecentAuthMaxAgeMinutes) * time.Minute)
+		session := protected.Group("")
+		session.Use(middlewares.RequireSession())
+		{
+			session.POST("/logout", userHandler.Logout)
+			session.POST("/logout/all", userHandler.LogoutAll)
+			session.GET("/sessions", sessionHandler.List)
+			session.DELETE("/sessions/:id", sessionHandler.Revoke)
+			session.POST("/reauthenticate", rateLimit(limiter, cfg, "auth"), userHandler.Reauthenticate)
+			session.POST("/change-password", recentAuth, userHandler.ChangePassword)
+			session.POST("/change-email", recentAuth, userHandler.ChangeEmail)
+			session.DELETE("/account", recentAuth, userHandler.DeleteAccount)
+			session.POST("/mfa/totp/enroll", mfaHandler.EnrollTOTP)
+			session.POST("/mfa/totp/confirm", recentAuth, mfaHandler.ConfirmTOTP)
+			session.POST("/mfa/totp/disable", recentAuth, mfaHandler.DisableTOTP)
+			session.POST("/api-keys", recentAuth, apiKeyHandler.Create)
+			session.GET("/api-keys", apiKeyHandler.List)
+			session.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+		}
+
+		// Consent API behind the frontend's OAuth authorization page
+		if cfg.OAuthServer.Enabled {
+			session.GET("/oauth/authorize", oauthHandler.AuthorizePrompt)
+			session.POST("/oauth/authorize", oauthHandler.Approve)
+			session.GET("/oauth/consents", oauthHandler.ListConsents)
+			session.DELETE("/oauth/consents/:client_id", oauthHandler.RevokeConsent)
+		}
+
+		// Organizations and the members of the active one
+		session.POST("/organizations", orgHandler.Create)
+		session.GET("/organizations", orgHandler.List)
+		session.POST("/organizations/:id/switch", orgHandler.Switch)
+		session.POST("/invitations/accept", invitationHandler.Accept)
+		members := session.Group("/organization/members")
+		{
+			members.GET("", middlewares.RequireOrgPermission(models.OrgPermissionMembersRead), orgHandler.Members)
+			members.GET("/:id", middlewares.RequireOrgPermission(models.OrgPermissionMembersRead), orgHandler.Member)
+			members.PUT("/:id", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), orgHandler.UpdateMember)
+			members.DELETE("/:id", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), orgHandler.RemoveMember)
+		}
+		invitations := session.Group("/organization/invitations")
+		{
+			invitations.POST("", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), invitationHandler.Invite)
+			invitations.GET("", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), invitationHandler.List)
+			invitations.POST("/:id/resend", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), invitationHandler.Resend)
+			invitations.DELETE("/:id", middlewares.RequireOrgPermission(models.OrgPermissionMembersWrite), invitationHandler.Revoke)
+		}
+
+		// Admin user management. Actions that lock users out or delete them
+		// also need a recent login or reauthentication.
+		admin := session.Group("/admin")
+		{
+			admin.GET("/users", middlewares.RequirePermission(models.PermissionUsersRead), adminHandler.ListUsers)
+			admin.POST("/users/import", middlewares.RequirePermission(models.PermissionUsersWrite), adminHandler.ImportUsers)
+			admin.GET("/users/:id", middlewares.RequirePermission(models.PermissionUsersRead), adminHandler.GetUser)
+			admin.POST("/users/:id/disable", middlewares.RequirePermission(models.PermissionUsersWrite), recentAuth, adminHandler.DisableUser)
+			admin.POST("/users/:id/enable", middlewares.RequirePermission(models.PermissionUsersWrite), adminHandler.EnableUser)
+			admin.POST("/users/:id/force-password-reset", middlewares.RequirePermission(models.PermissionUsersWrite), recentAuth, adminHandler.ForcePasswordReset)
+			admin.DELETE("/users/:id", middlewares.RequirePermission(models.PermissionUsersWrite), recentAuth, adminHandler.DeleteUser)
+			admin.POST("/oauth-clients", middlewares.RequirePermission(models.PermissionClientsWrite), oauthHandler.CreateClient)
+			admin.GET("/oauth-clients", middlewares.RequirePermission(models.PermissionClientsRead), oauthHandler.ListClients)
+			admin.DELETE("/oauth-clients/:id", middlewares.RequirePermission(models.PermissionClientsWrite), oauthHandler.DeleteClient)
+		}
+
+		// Redis operations
+		protected.POST("/set-redis-key", middlewares.RequirePermission(models.PermissionRedisWrite), userHandler.SetRedisKey)
+		protected.GET("/get-redis-key/:key", middlewares.RequirePermission(models.PermissionRedisRead), userHandler.GetRedisKey)
 	}
 
 	return r
-}
\ No newline at end of file
+}
+
+// rateLimit returns the middleware of a configured rate limit policy. It
+// lets every request through when rate limiting is disabled or the policy
+// is not configured.
+func rateLimit(limiter middlewares.RateLimiter, cfg *config.Config, name string) gin.HandlerFunc {
+	policy, ok := cfg.RateLimit.Policies[name]
+	if !cfg.RateLimit.Enabled || !ok {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middlewares.RateLimit(limiter, middlewares.RateLimitPolicy{
+		Name:      name,
+		Algorithm: policy.Algorithm,
+		Limit:     policy.Limit,
+		Window:    time.Duration(policy.WindowSeconds) * time.Second,
+		KeyBy:     policy.Key,
+	})
+}
+
+// sessionCookies returns the middleware that enables cookie sessions in the
+// cookie and both auth modes. It lets every request through unchanged
+// otherwise.
+func sessionCookies(cfg *config.Config) gin.HandlerFunc {
+	if cfg.Auth.Mode != "cookie" && cfg.Auth.Mode != "both" {
+		return func(c *gin.Context) { c.Next() }
+	}
+	cookie := cfg.Auth.SessionCookie
+	sameSite := map[string]http.SameSite{
+		"lax":    http.SameSiteLaxMode,
+		"strict": http.SameSiteStrictMode,
+		"none":   http.SameSiteNoneMode,
+	}[cookie.SameSite]
+	return middlewares.SessionCookies(middlewares.SessionCookieConfig{
+		Name:           cookie.Name,
+		CSRFCookieName: cookie.CSRFCookieName,
+		CSRFHeader:     cookie.CSRFHeader,
+		Domain:         cookie.Domain,
+		Path:           cookie.Path,
+		Secure:         cookie.Secure,
+		SameSite:       sameSite,
+		OmitTokens:     cfg.Auth.Mode == "cookie",
+	})
+}
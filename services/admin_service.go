@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+)
+
+// Page size limits of the admin user listing
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+var (
+	ErrInvalidUserQuery = errors.New("invalid user query")
+	ErrCannotModifySelf = errors.New("admins cannot disable or delete their own account")
+)
+
+// UserListParams selects a page of users. Sort names a field, prefixed with
+// "-" for descending order. Pages are selected by Offset or by the Cursor
+// returned with the previous page, not both.
+type UserListParams struct {
+	Email         string
+	Name          string
+	Status        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Sort          string
+	Limit         int
+	Offset        int
+	Cursor        string
+}
+
+// UserPage is a page of the admin user listing. NextCursor is empty on the
+// last page.
+type UserPage struct {
+	Users      []models.User
+	Total      int64
+	NextCursor string
+}
+
+// userCursor is the opaque cursor handed to clients. It records the sort
+// it was created for so it cannot be replayed against another ordering.
+type userCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+// AdminService implements the user management operations of admins
+type AdminService struct {
+	users  repositories.UserRepository
+	tokens TokenServiceInterface
+	resets PasswordResetServiceInterface
+}
+
+// Ensure AdminService implements AdminServiceInterface interface
+var _ AdminServiceInterface = (*AdminService)(nil)
+
+func NewAdminService(users repositories.UserRepository, tokens TokenServiceInterface, resets PasswordResetServiceInterface) *AdminService {
+	return &AdminService{users: users, tokens: tokens, resets: resets}
+}
+
+// ListUsers returns a page of users matching the filters
+func (s *AdminService) ListUsers(params UserListParams) (*UserPage, error) {
+	q := repositories.UserQuery{
+		Email:         params.Email,
+		Name:          params.Name,
+		Status:        params.Status,
+		CreatedAfter:  params.CreatedAfter,
+		CreatedBefore: params.CreatedBefore,
+		Limit:         params.Limit,
+		Offset:        params.Offset,
+	}
+
+	sort := params.Sort
+	if sort == "" {
+		sort = "-created_at"
+	}
+	q.SortField = strings.TrimPrefix(sort, "-")
+	q.SortDesc = strings.HasPrefix(sort, "-")
+	if _, ok := repositories.UserSortColumns[q.SortField]; !ok {
+		return nil, fmt.Errorf("%w: unknown sort field %s", ErrInvalidUserQuery, q.SortField)
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultUserPageSize
+	}
+	if q.Limit > MaxUserPageSize {
+		q.Limit = MaxUserPageSize
+	}
+
+	if params.Cursor != "" {
+		if params.Offset > 0 {
+			return nil, fmt.Errorf("%w: cursor and offset cannot be combined", ErrInvalidUserQuery)
+		}
+		after, err := decodeUserCursor(params.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		q.After = after
+	}
+
+	// One extra row tells whether there is a next page
+	q.Limit++
+	users, total, err := s.users.List(q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: users, Total: total}
+	if len(users) == q.Limit {
+		page.Users = users[:q.Limit-1]
+		page.NextCursor = encodeUserCursor(&page.Users[len(page.Users)-1], sort, q.SortField)
+	}
+	return page, nil
+}
+
+// GetUser loads a user with their roles
+func (s *AdminService) GetUser(id uint) (*models.User, error) {
+	return s.users.FindByID(id)
+}
+
+// DisableUser blocks logins of a user and ends their sessions
+func (s *AdminService) DisableUser(actorID, id uint) (*models.User, error) {
+	if actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+	u, err := s.users.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.DisabledAt == nil {
+		now := time.Now()
+		u.DisabledAt = &now
+		if err := s.users.Update(u); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.tokens.RevokeAll(id); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// EnableUser allows a disabled user to log in again
+func (s *AdminService) EnableUser(id uint) (*models.User, error) {
+	u, err := s.users.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.DisabledAt != nil {
+		u.DisabledAt = nil
+		if err := s.users.Update(u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// ForcePasswordReset ends the sessions of a user, blocks password logins
+// until a new password is set and mails a reset link
+func (s *AdminService) ForcePasswordReset(id uint) (*models.User, error) {
+	u, err := s.users.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.PasswordResetRequired = true
+	if err := s.users.Update(u); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.RevokeAll(id); err != nil {
+		return nil, err
+	}
+	if err := s.resets.RequestReset(u.Email); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// DeleteUser ends the sessions of a user and deletes the account
+func (s *AdminService) DeleteUser(actorID, id uint) error {
+	if actorID == id {
+		return ErrCannotModifySelf
+	}
+	if _, err := s.users.FindByID(id); err != nil {
+		return err
+	}
+	if err := s.tokens.RevokeAll(id); err != nil {
+		return err
+	}
+	return s.users.Delete(id)
+}
+
+func encodeUserCursor(u *models.User, sort, field string) string {
+	c := userCursor{Sort: sort, ID: u.ID}
+	switch field {
+	case "email":
+		c.Value = u.Email
+	case "name":
+		c.Value = u.Name
+	case "created_at":
+		c.Value = u.CreatedAt.Format(time.RFC3339Nano)
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeUserCursor(cursor, sort string) (*repositories.UserCursor, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", ErrInvalidUserQuery)
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var c userCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort {
+		return nil, invalid
+	}
+
+	after := &repositories.UserCursor{Value: c.Value, ID: c.ID}
+	if strings.TrimPrefix(sort, "-") == "created_at" {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, invalid
+		}
+		after.Value = t
+	}
+	return after, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminService_ListUsers(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	users := []models.User{
+		{ID: 3, Email: "c@example.com", CreatedAt: created},
+		{ID: 2, Email: "b@example.com", CreatedAt: created},
+		{ID: 1, Email: "a@example.com", CreatedAt: created.Add(-time.Hour)},
+	}
+
+	mockRepo := &MockUserRepository{}
+	// Defaults: newest first, one extra row to detect the next page
+	mockRepo.On("List", repositories.UserQuery{Status: models.UserStatusActive, SortField: "created_at", SortDesc: true, Limit: 3}).
+		Return(users, int64(3), nil)
+
+	service := NewAdminService(mockRepo, &MockTokenService{}, &MockPasswordResetService{})
+	page, err := service.ListUsers(UserListParams{Status: models.UserStatusActive, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Users, 2)
+	assert.Equal(t, int64(3), page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The cursor points after the last user of the page
+	mockRepo.On("List", mock.MatchedBy(func(q repositories.UserQuery) bool {
+		return q.After != nil && q.After.ID == 2 && q.After.Value.(time.Time).Equal(created)
+	})).Return(users[2:], int64(3), nil)
+
+	next, err := service.ListUsers(UserListParams{Status: models.UserStatusActive, Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, next.Users, 1)
+	assert.Empty(t, next.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdminService_ListUsersInvalidQuery(t *testing.T) {
+	service := NewAdminService(&MockUserRepository{}, &MockTokenService{}, &MockPasswordResetService{})
+	cursor := encodeUserCursor(&models.User{ID: 1, Email: "a@example.com"}, "email", "email")
+
+	tests := []struct {
+		name   string
+		params UserListParams
+	}{
+		{name: "unknown sort field", params: UserListParams{Sort: "password"}},
+		{name: "malformed cursor", params: UserListParams{Cursor: "not-a-cursor"}},
+		{name: "cursor of another sort", params: UserListParams{Sort: "-email", Cursor: cursor}},
+		{name: "cursor and offset", params: UserListParams{Sort: "email", Cursor: cursor, Offset: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := service.ListUsers(tt.params)
+			assert.ErrorIs(t, err, ErrInvalidUserQuery)
+			assert.Nil(t, page)
+		})
+	}
+}
+
+func TestAdminService_DisableUser(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockTokens := &MockTokenService{}
+	user := &models.User{ID: 2}
+	mockRepo.On("FindByID", uint(2)).Return(user, nil)
+	mockRepo.On("Update", user).Return(nil)
+	mockTokens.On("RevokeAll", uint(2)).Return(nil)
+
+	service := NewAdminService(mockRepo, mockTokens, &MockPasswordResetService{})
+
+	_, err := service.DisableUser(1, 1)
+	assert.ErrorIs(t, err, ErrCannotModifySelf)
+
+	u, err := service.DisableUser(1, 2)
+	assert.NoError(t, err)
+	assert.NotNil(t, u.DisabledAt)
+	mockRepo.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestAdminService_ForcePasswordReset(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockTokens := &MockTokenService{}
+	mockResets := &MockPasswordResetService{}
+	user := &models.User{ID: 2, Email: "test@example.com"}
+	mockRepo.On("FindByID", uint(2)).Return(user, nil)
+	mockRepo.On("Update", user).Return(nil)
+	mockTokens.On("RevokeAll", uint(2)).Return(nil)
+	mockResets.On("RequestReset", "test@example.com").Return(nil)
+
+	service := NewAdminService(mockRepo, mockTokens, mockResets)
+	u, err := service.ForcePasswordReset(2)
+
+	assert.NoError(t, err)
+	assert.True(t, u.PasswordResetRequired)
+	mockRepo.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+	mockResets.AssertExpectations(t)
+}
+
+func TestAdminService_DeleteUser(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockTokens := &MockTokenService{}
+	mockRepo.On("FindByID", uint(2)).Return(&models.User{ID: 2}, nil)
+	mockRepo.On("FindByID", uint(3)).Return(nil, repositories.ErrUserNotFound)
+	mockRepo.On("Delete", uint(2)).Return(nil)
+	mockTokens.On("RevokeAll", uint(2)).Return(nil)
+
+	service := NewAdminService(mockRepo, mockTokens, &MockPasswordResetService{})
+
+	assert.NoError(t, service.DeleteUser(1, 2))
+	assert.ErrorIs(t, service.DeleteUser(1, 3), repositories.ErrUserNotFound)
+	assert.ErrorIs(t, service.DeleteUser(1, 1), ErrCannotModifySelf)
+	mockRepo.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+}
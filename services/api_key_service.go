@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+// apiKeyLastUsedInterval is how stale last_used_at may get before a use of
+// the key is written to the database again
+const apiKeyLastUsedInterval = time.Minute
+
+var (
+	ErrInvalidAPIKey   = errors.New("invalid or expired api key")
+	ErrInvalidAPIScope = errors.New("invalid api key scope")
+)
+
+// APIKeyService creates, lists, revokes and authenticates API keys
+type APIKeyService struct {
+	keys  repositories.APIKeyRepository
+	users repositories.UserRepository
+}
+
+// Ensure APIKeyService implements APIKeyServiceInterface interface
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
+
+func NewAPIKeyService(keys repositories.APIKeyRepository, users repositories.UserRepository) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users}
+}
+
+// Create stores a new key for the user and returns it together with the
+// plain key, which cannot be recovered later. A zero expiresIn creates a
+// key that does not expire.
+func (s *APIKeyService) Create(userID uint, name string, scopes []string, expiresIn time.Duration) (*models.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidAPIScope
+	}
+	for _, scope := range scopes {
+		if !isAPIKeyScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidAPIScope, scope)
+		}
+	}
+
+	plain, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	k := &models.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  prefix,
+		KeyHash: utils.HashToken(plain),
+		Scopes:  scopes,
+	}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		k.ExpiresAt = &expiresAt
+	}
+
+	if err := s.keys.Create(k); err != nil {
+		return nil, "", err
+	}
+	return k, plain, nil
+}
+
+// List returns the active keys of the user
+func (s *APIKeyService) List(userID uint) ([]models.APIKey, error) {
+	return s.keys.ListForUser(userID)
+}
+
+// Revoke revokes a key of the user
+func (s *APIKeyService) Revoke(userID, id uint) error {
+	revoked, err := s.keys.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return repositories.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a plain API key and records its use
+func (s *APIKeyService) Authenticate(key string) (*models.APIKey, error) {
+	k, err := s.keys.FindByHash(utils.HashToken(key))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && now.After(*k.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Keys of disabled or deleted users stop working without being revoked
+	u, err := s.users.FindByID(k.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Failing to record the use must not fail the request
+	if err := s.keys.TouchLastUsed(k.ID, now, now.Add(-apiKeyLastUsedInterval)); err != nil {
+		log.Printf("Failed to record API key use: %v", err)
+	}
+	return k, nil
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
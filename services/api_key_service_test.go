@@ -0,0 +1,155 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	tests := []struct {
+		name          string
+		scopes        []string
+		expiresIn     time.Duration
+		expectedError error
+	}{
+		{name: "key without expiry", scopes: []string{models.ScopeProfileRead}},
+		{name: "expiring key", scopes: []string{models.ScopeProfileRead, models.ScopeProfileWrite}, expiresIn: 24 * time.Hour},
+		{name: "unknown scope", scopes: []string{"admin"}, expectedError: ErrInvalidAPIScope},
+		{name: "no scopes", scopes: nil, expectedError: ErrInvalidAPIScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockAPIKeyRepository{}
+			if tt.expectedError == nil {
+				mockRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Return(nil)
+			}
+
+			service := NewAPIKeyService(mockRepo, &MockUserRepository{})
+			key, plain, err := service.Create(1, "ci", tt.scopes, tt.expiresIn)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, utils.IsAPIKey(plain))
+				assert.Equal(t, utils.HashToken(plain), key.KeyHash)
+				assert.Contains(t, plain, key.Prefix)
+				assert.Equal(t, tt.scopes, key.Scopes)
+				assert.Equal(t, tt.expiresIn == 0, key.ExpiresAt == nil)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		key           *models.APIKey
+		findErr       error
+		user          *models.User
+		expectedError error
+	}{
+		{name: "active key", key: &models.APIKey{ID: 3, UserID: 1}, user: &models.User{ID: 1}},
+		{name: "key with future expiry", key: &models.APIKey{ID: 3, UserID: 1, ExpiresAt: &future}, user: &models.User{ID: 1}},
+		{name: "key of disabled user", key: &models.APIKey{ID: 3, UserID: 1}, user: &models.User{ID: 1, DisabledAt: &past}, expectedError: ErrInvalidAPIKey},
+		{name: "expired key", key: &models.APIKey{ID: 3, UserID: 1, ExpiresAt: &past}, expectedError: ErrInvalidAPIKey},
+		{name: "revoked key", key: &models.APIKey{ID: 3, UserID: 1, RevokedAt: &past}, expectedError: ErrInvalidAPIKey},
+		{name: "unknown key", findErr: repositories.ErrAPIKeyNotFound, expectedError: ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockAPIKeyRepository{}
+			mockRepo.On("FindByHash", utils.HashToken("pat_key")).Return(tt.key, tt.findErr)
+			mockUsers := &MockUserRepository{}
+			if tt.user != nil {
+				mockUsers.On("FindByID", uint(1)).Return(tt.user, nil)
+			}
+			if tt.expectedError == nil {
+				mockRepo.On("TouchLastUsed", uint(3), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil)
+			}
+
+			service := NewAPIKeyService(mockRepo, mockUsers)
+			key, err := service.Authenticate("pat_key")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, key)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.key, key)
+			}
+			mockRepo.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	mockRepo := &MockAPIKeyRepository{}
+	mockRepo.On("Revoke", uint(1), uint(3)).Return(true, nil)
+	mockRepo.On("Revoke", uint(1), uint(4)).Return(false, nil)
+
+	service := NewAPIKeyService(mockRepo, &MockUserRepository{})
+
+	assert.NoError(t, service.Revoke(1, 3))
+	assert.ErrorIs(t, service.Revoke(1, 4), repositories.ErrAPIKeyNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository interface
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+// Ensure MockAPIKeyRepository implements APIKeyRepository interface
+var _ repositories.APIKeyRepository = (*MockAPIKeyRepository)(nil)
+
+func (m *MockAPIKeyRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Create(k *models.APIKey) error {
+	args := m.Called(k)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil || args.Get(0).(*models.APIKey) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListForUser(userID uint) ([]models.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(userID, id uint) (bool, error) {
+	args := m.Called(userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(id uint, at, notBefore time.Time) error {
+	args := m.Called(id, at, notBefore)
+	return args.Error(0)
+}
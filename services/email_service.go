@@ -2,6 +2,9 @@ package services
 
 import (
 	"fmt"
+	"html"
+	"net/url"
+	"strings"
 	"temp/global"
 
 	"go.uber.org/zap"
@@ -16,10 +19,14 @@ type EmailService struct {
 	username string
 	password string
 	from     string
+	appURL   string
 }
 
-// NewEmailService creates a new email service
-func NewEmailService(enabled bool, host string, port int, username, password, from string) *EmailService {
+// Ensure EmailService implements EmailServiceInterface interface
+var _ EmailServiceInterface = (*EmailService)(nil)
+
+// NewEmailService creates a new email service. Links in emails point to appURL.
+func NewEmailService(enabled bool, host string, port int, username, password, from, appURL string) *EmailService {
 	return &EmailService{
 		enabled:  enabled,
 		smtpHost: host,
@@ -27,6 +34,7 @@ func NewEmailService(enabled bool, host string, port int, username, password, fr
 		username: username,
 		password: password,
 		from:     from,
+		appURL:   strings.TrimRight(appURL, "/"),
 	}
 }
 
@@ -72,9 +80,80 @@ func (s *EmailService) SendVerificationEmail(to, verificationToken string) error
         <body>
             <h1>Email Verification</h1>
             <p>Please verify your email address by clicking the link below:</p>
-            <p><a href="http://localhost:8080/verify-email?token=%s">Verify Email</a></p>
+            <p><a href="%s">Verify Email</a></p>
+            <p>The link can only be used once.</p>
+        </body>
+        </html>
+    `, s.link("/verify-email", verificationToken))
+	return s.SendEmail(to, subject, body)
+}
+
+// SendPasswordResetEmail sends a password reset link
+func (s *EmailService) SendPasswordResetEmail(to, resetToken string) error {
+	subject := "Reset Your Password"
+	body := fmt.Sprintf(`
+        <html>
+        <body>
+            <h1>Password Reset</h1>
+            <p>We received a request to reset your password. Click the link below to choose a new one:</p>
+            <p><a href="%s">Reset Password</a></p>
+            <p>The link can only be used once. If you did not ask for a reset, you can ignore this email.</p>
         </body>
         </html>
-    `, verificationToken)
+    `, s.link("/reset-password", resetToken))
 	return s.SendEmail(to, subject, body)
 }
+
+// SendUnlockEmail sends a link that lifts the lockout of an account
+func (s *EmailService) SendUnlockEmail(to, unlockToken string) error {
+	subject := "Your Account Has Been Locked"
+	body := fmt.Sprintf(`
+        <html>
+        <body>
+            <h1>Account Locked</h1>
+            <p>Your account was locked after several failed login attempts. It unlocks by itself after a while, or you can unlock it now:</p>
+            <p><a href="%s">Unlock Account</a></p>
+            <p>If these attempts were not yours, consider changing your password after unlocking.</p>
+        </body>
+        </html>
+    `, s.link("/unlock-account", unlockToken))
+	return s.SendEmail(to, subject, body)
+}
+
+// SendMagicLinkEmail sends a link that logs the user in without a password
+func (s *EmailService) SendMagicLinkEmail(to, loginToken string) error {
+	subject := "Your Login Link"
+	body := fmt.Sprintf(`
+        <html>
+        <body>
+            <h1>Log In</h1>
+            <p>Click the link below to log in. No password is needed:</p>
+            <p><a href="%s">Log In</a></p>
+            <p>The link can only be used once and expires shortly. If you did not ask to log in, you can ignore this email.</p>
+        </body>
+        </html>
+    `, s.link("/magic-link", loginToken))
+	return s.SendEmail(to, subject, body)
+}
+
+// SendInvitationEmail sends a link to join an organization with a role
+func (s *EmailService) SendInvitationEmail(to, organization, role, invitationToken string) error {
+	// Line breaks in the name must not start new headers
+	subject := fmt.Sprintf("You're Invited to Join %s", strings.Join(strings.Fields(organization), " "))
+	body := fmt.Sprintf(`
+        <html>
+        <body>
+            <h1>Join %s</h1>
+            <p>You have been invited to join %s as %s. Click the link below to accept with your existing account or create one:</p>
+            <p><a href="%s">Accept Invitation</a></p>
+            <p>The link can only be used once and expires. If you did not expect this invitation, you can ignore this email.</p>
+        </body>
+        </html>
+    `, html.EscapeString(organization), html.EscapeString(organization), html.EscapeString(role), s.link("/invitation", invitationToken))
+	return s.SendEmail(to, subject, body)
+}
+
+// link builds a frontend URL that carries a token in its query string
+func (s *EmailService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"temp/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrIdentityProviderUnavailable = errors.New("identity provider is unavailable")
+	ErrIdentityProviderRejected    = errors.New("identity provider rejected the login")
+	ErrInvalidIDToken              = errors.New("invalid id token")
+)
+
+// ExternalIdentity is the account a user logged in with at an identity
+// provider
+type ExternalIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityProvider is an external OAuth2 or OpenID Connect provider users
+// can log in with
+type IdentityProvider interface {
+	// AuthCodeURL is where the user is sent to log in at the provider
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	// Identify redeems the authorization code the provider redirected
+	// back with and returns the identity of the user
+	Identify(code, codeVerifier, nonce string) (*ExternalIdentity, error)
+}
+
+// OAuthProviderConfig configures an identity provider. With an Issuer the
+// provider is treated as OpenID Connect provider: its endpoints are
+// discovered and the identity is read from the verified ID token. Plain
+// OAuth2 providers need AuthURL, TokenURL and UserInfoURL instead.
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Issuer       string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	// Claims holding the identity, sub, email, email_verified and name
+	// when empty
+	SubjectClaim       string
+	EmailClaim         string
+	EmailVerifiedClaim string
+	NameClaim          string
+	// TrustEmail treats emails as verified when the provider does not say
+	// so, for providers that only share verified addresses
+	TrustEmail bool
+}
+
+// OAuthProvider logs users in with the authorization code flow and PKCE
+type OAuthProvider struct {
+	config OAuthProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *oidcDiscovery
+	keys      map[string]interface{}
+}
+
+// Ensure OAuthProvider implements IdentityProvider interface
+var _ IdentityProvider = (*OAuthProvider)(nil)
+
+// oidcDiscovery is the part of the OpenID provider metadata we use
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func NewOAuthProvider(config OAuthProviderConfig) *OAuthProvider {
+	if config.SubjectClaim == "" {
+		config.SubjectClaim = "sub"
+	}
+	if config.EmailClaim == "" {
+		config.EmailClaim = "email"
+	}
+	if config.EmailVerifiedClaim == "" {
+		config.EmailVerifiedClaim = "email_verified"
+	}
+	if config.NameClaim == "" {
+		config.NameClaim = "name"
+	}
+	return &OAuthProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OAuthProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	endpoints, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	if p.config.Issuer != "" {
+		query.Set("nonce", nonce)
+	}
+
+	separator := "?"
+	if strings.Contains(endpoints.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return endpoints.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (p *OAuthProvider) Identify(code, codeVerifier, nonce string) (*ExternalIdentity, error) {
+	endpoints, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	tokens, err := p.exchange(endpoints.TokenEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if p.config.Issuer != "" {
+		if claims, err = p.verifyIDToken(tokens.IDToken, nonce); err != nil {
+			return nil, err
+		}
+		// Some providers keep ID tokens small and only share the email
+		// through the user info endpoint
+		if claimString(claims, p.config.EmailClaim) == "" && endpoints.UserInfoEndpoint != "" && tokens.AccessToken != "" {
+			info, err := p.userInfo(endpoints.UserInfoEndpoint, tokens.AccessToken)
+			if err != nil {
+				return nil, err
+			}
+			if claimString(info, p.config.SubjectClaim) == claimString(claims, p.config.SubjectClaim) {
+				for name, value := range info {
+					if _, ok := claims[name]; !ok {
+						claims[name] = value
+					}
+				}
+			}
+		}
+	} else {
+		if tokens.AccessToken == "" {
+			return nil, ErrIdentityProviderRejected
+		}
+		if claims, err = p.userInfo(endpoints.UserInfoEndpoint, tokens.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+
+	identity := &ExternalIdentity{
+		Subject: claimString(claims, p.config.SubjectClaim),
+		Email:   strings.TrimSpace(claimString(claims, p.config.EmailClaim)),
+		Name:    strings.TrimSpace(claimString(claims, p.config.NameClaim)),
+	}
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%w: no %s claim", ErrIdentityProviderRejected, p.config.SubjectClaim)
+	}
+	if verified, ok := claims[p.config.EmailVerifiedClaim]; ok {
+		identity.EmailVerified = verified == true || verified == "true"
+	} else {
+		identity.EmailVerified = p.config.TrustEmail
+	}
+	return identity, nil
+}
+
+// discover returns the endpoints of the provider, fetching the OpenID
+// provider metadata on first use
+func (p *OAuthProvider) discover() (*oidcDiscovery, error) {
+	if p.config.Issuer == "" {
+		return &oidcDiscovery{
+			AuthorizationEndpoint: p.config.AuthURL,
+			TokenEndpoint:         p.config.TokenURL,
+			UserInfoEndpoint:      p.config.UserInfoURL,
+		}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints oidcDiscovery
+	if err := p.do(req, &endpoints); err != nil {
+		return nil, err
+	}
+	if endpoints.Issuer != p.config.Issuer || endpoints.AuthorizationEndpoint == "" || endpoints.TokenEndpoint == "" || endpoints.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: invalid discovery document", ErrIdentityProviderUnavailable)
+	}
+	p.endpoints = &endpoints
+	return p.endpoints, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns its claims
+func (p *OAuthProvider) verifyIDToken(idToken, nonce string) (map[string]interface{}, error) {
+	if idToken == "" {
+		return nil, fmt.Errorf("%w: missing", ErrInvalidIDToken)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, p.keyfunc,
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims["nonce"] != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// keyfunc resolves the key an ID token was signed with. The key set is
+// fetched again when a token names an unknown key, as providers rotate keys.
+func (p *OAuthProvider) keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := p.fetchKeys(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, utils.ErrUnknownSigningKey
+}
+
+func (p *OAuthProvider) fetchKeys() error {
+	req, err := http.NewRequest(http.MethodGet, p.endpoints.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var set utils.JSONWebKeySet
+	if err := p.do(req, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, tokens signed with them fail
+		if key, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *OAuthProvider) userInfo(endpoint, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	var claims map[string]interface{}
+	if err := p.do(req, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// do sends a request to the provider and decodes its JSON response
+func (p *OAuthProvider) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIdentityProviderUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s answered %d", ErrIdentityProviderUnavailable, req.URL.Path, res.StatusCode)
+	}
+	return decodeProviderJSON(res.Body, v)
+}
+
+// exchange redeems an authorization code at the token endpoint
+func (p *OAuthProvider) exchange(endpoint string, form url.Values) (*oauthTokenResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIdentityProviderUnavailable, err)
+	}
+	defer res.Body.Close()
+
+	// Refused codes are reported with an error body, usually with status 400
+	var tokens oauthTokenResponse
+	decodeErr := decodeProviderJSON(res.Body, &tokens)
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrIdentityProviderRejected, tokens.Error, tokens.ErrorDescription)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s answered %d", ErrIdentityProviderUnavailable, req.URL.Path, res.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return &tokens, nil
+}
+
+func decodeProviderJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(io.LimitReader(r, 1<<20))
+	// Numeric subjects such as GitHub user IDs must keep every digit
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrIdentityProviderUnavailable, err)
+	}
+	return nil
+}
+
+// claimString reads a string or numeric claim
+func claimString(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"temp/testutils"
+	"temp/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthProvider_OIDC(t *testing.T) {
+	idp := testutils.NewMockOIDCProvider("client", "secret")
+	defer idp.Close()
+
+	provider := NewOAuthProvider(OAuthProviderConfig{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/auth/mock/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+		Issuer:       idp.Issuer(),
+	})
+	user := testutils.MockOIDCUser{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"}
+
+	tests := []struct {
+		name        string
+		verifier    string
+		nonce       string
+		expectedErr error
+	}{
+		{name: "identity returned", verifier: "verifier", nonce: "nonce"},
+		{name: "wrong PKCE verifier", verifier: "other", nonce: "nonce", expectedErr: ErrIdentityProviderRejected},
+		{name: "replayed ID token", verifier: "verifier", nonce: "other", expectedErr: ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := provider.AuthCodeURL("state", "nonce", utils.PKCEChallenge("verifier"))
+			assert.NoError(t, err)
+			code, state, err := idp.Authorize(authURL, user)
+			assert.NoError(t, err)
+			assert.Equal(t, "state", state)
+
+			identity, err := provider.Identify(code, tt.verifier, tt.nonce)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &ExternalIdentity{Subject: user.Subject, Email: user.Email, EmailVerified: true, Name: user.Name}, identity)
+		})
+	}
+}
+
+func TestOAuthProvider_OAuth2UserInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"access_token":"access","token_type":"bearer"}`))
+		case "/user":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id":9007199254740993,"login":"octocat","email":"octocat@example.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewOAuthProvider(OAuthProviderConfig{
+		ClientID:     "client",
+		AuthURL:      server.URL + "/authorize",
+		TokenURL:     server.URL + "/token",
+		UserInfoURL:  server.URL + "/user",
+		SubjectClaim: "id",
+		NameClaim:    "login",
+	})
+
+	identity, err := provider.Identify("code", "verifier", "")
+
+	assert.NoError(t, err)
+	// Numeric IDs keep every digit and emails are unverified unless trusted
+	assert.Equal(t, &ExternalIdentity{Subject: "9007199254740993", Email: "octocat@example.com", Name: "octocat"}, identity)
+}
@@ -1,9 +1,178 @@
 package services
 
-import "temp/models"
+import (
+	"time"
+
+	"temp/models"
+)
 
 // UserServiceInterface defines the interface for user service operations
 type UserServiceInterface interface {
 	Register(name, email, password string) (*models.User, error)
-	Authenticate(email, password string) (string, *models.User, error)
+	RegisterVerified(name, email, password string, create func(u *models.User) error) (*models.User, error)
+	Authenticate(email, password, clientIP, userAgent string) (*LoginResult, error)
+	RefreshTokens(refreshToken string) (*TokenPair, error)
+	Logout(userID uint, sessionID, tokenID string, expiresAt time.Time) error
+	LogoutAll(userID uint) error
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+	UnlockAccount(token string) error
+	RequestMagicLink(email, clientIP, userAgent string) error
+	LoginWithMagicLink(token, clientIP, userAgent string) (*LoginResult, error)
+	LoginWithProvider(provider, state, code, clientIP, userAgent string) (*LoginResult, error)
+	GetProfile(userID uint) (*models.User, error)
+	UpdateProfile(userID uint, update ProfileUpdate) (*models.User, error)
+	ChangePassword(userID uint, oldPassword, newPassword string) (*models.User, error)
+	ChangeEmail(userID uint, email string) (*models.User, error)
+	DeleteAccount(userID uint) error
+	Reauthenticate(userID uint, sessionID, password, code, clientIP string) (*TokenPair, error)
+}
+
+// TokenServiceInterface defines the interface for token service operations
+type TokenServiceInterface interface {
+	IssueTokens(u *models.User, amr []string, clientIP, userAgent string) (*TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Elevate(u *models.User, sessionID string, amr []string) (*TokenPair, error)
+	SwitchOrganization(u *models.User, sessionID string, orgID uint) (*TokenPair, error)
+	Revoke(userID uint, sessionID, tokenID string, expiresAt time.Time) error
+	RevokeAll(userID uint) error
+	Sessions(userID uint) ([]models.Session, error)
+	RevokeSession(userID uint, sessionID string) error
+	TouchSession(userID uint, sessionID, clientIP string) (bool, error)
+	AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error)
+}
+
+// VerificationServiceInterface defines the interface for email verification operations
+type VerificationServiceInterface interface {
+	SendVerification(u *models.User) error
+	Verify(token string) error
+	Resend(email string) error
+}
+
+// PasswordResetServiceInterface defines the interface for password reset operations
+type PasswordResetServiceInterface interface {
+	RequestReset(email string) error
+	Reset(token, newPassword string) error
+}
+
+// MFAServiceInterface defines the interface for two-factor authentication operations
+type MFAServiceInterface interface {
+	EnrollTOTP(userID uint) (*TOTPEnrollment, error)
+	ConfirmTOTP(userID uint, code string) ([]string, error)
+	DisableTOTP(userID uint, password, code, clientIP string) error
+	Challenge(u *models.User) (string, int64, error)
+	CompleteLogin(challenge, code, clientIP, userAgent string) (*LoginResult, error)
+	VerifyCode(u *models.User, code string) error
+}
+
+// APIKeyServiceInterface defines the interface for API key operations
+type APIKeyServiceInterface interface {
+	Create(userID uint, name string, scopes []string, expiresIn time.Duration) (*models.APIKey, string, error)
+	List(userID uint) ([]models.APIKey, error)
+	Revoke(userID, id uint) error
+	Authenticate(key string) (*models.APIKey, error)
+}
+
+// RoleServiceInterface defines the interface for role operations
+type RoleServiceInterface interface {
+	EnsureDefaultRoles() error
+	AssignRole(userID uint, role string) error
+	CreateAdmin(name, email, password string) (*models.User, error)
+}
+
+// AdminServiceInterface defines the interface for admin user management operations
+type AdminServiceInterface interface {
+	ListUsers(params UserListParams) (*UserPage, error)
+	GetUser(id uint) (*models.User, error)
+	DisableUser(actorID, id uint) (*models.User, error)
+	EnableUser(id uint) (*models.User, error)
+	ForcePasswordReset(id uint) (*models.User, error)
+	DeleteUser(actorID, id uint) error
+}
+
+// OrganizationServiceInterface defines the interface for organization and membership operations
+type OrganizationServiceInterface interface {
+	Create(userID uint, name string) (*models.Organization, error)
+	ListForUser(userID uint) ([]models.Membership, error)
+	Switch(userID uint, sessionID string, orgID uint) (*TokenPair, error)
+	Members(orgID uint, limit, offset int) ([]models.User, int64, error)
+	Member(orgID, userID uint) (*models.User, error)
+	UpdateMemberRole(orgID uint, actorRole string, userID uint, role string) error
+	RemoveMember(orgID uint, actorRole string, userID uint) error
+}
+
+// InvitationServiceInterface defines the interface for organization invitation operations
+type InvitationServiceInterface interface {
+	Invite(orgID, invitedBy uint, actorRole, email, role string) (*models.Invitation, error)
+	ListPending(orgID uint) ([]models.Invitation, error)
+	Resend(orgID, id uint) (*models.Invitation, error)
+	Revoke(orgID, id uint) error
+	Lookup(token string) (*models.Invitation, bool, error)
+	Accept(token string, userID uint) (*models.Invitation, error)
+	Register(token, name, password string) (*models.User, *models.Invitation, error)
+}
+
+// MagicLinkServiceInterface defines the interface for passwordless login links
+type MagicLinkServiceInterface interface {
+	Request(email, clientIP, userAgent string) error
+	Consume(token, clientIP, userAgent string) (*models.User, error)
+}
+
+// SocialLoginServiceInterface defines the interface for logins through external identity providers
+type SocialLoginServiceInterface interface {
+	Providers() []string
+	Begin(provider string) (string, error)
+	Complete(provider, state, code string) (*models.User, error)
+}
+
+// UserImportServiceInterface defines the interface for importing users from other systems
+type UserImportServiceInterface interface {
+	Import(records []UserImport) (*ImportReport, error)
+}
+
+// PasswordPolicyServiceInterface defines the interface for checking new passwords
+type PasswordPolicyServiceInterface interface {
+	Check(u *models.User, password string) error
+	Remember(u *models.User) error
+}
+
+// LockoutServiceInterface defines the interface for login brute-force protection
+type LockoutServiceInterface interface {
+	Reserve(email, clientIP string) error
+	RecordFailure(email string) error
+	RecordSuccess(email, clientIP string) error
+	Unlock(token string) error
+}
+
+// EmailServiceInterface defines the interface for sending transactional emails
+type EmailServiceInterface interface {
+	SendVerificationEmail(to, verificationToken string) error
+	SendPasswordResetEmail(to, resetToken string) error
+	SendUnlockEmail(to, unlockToken string) error
+	SendMagicLinkEmail(to, loginToken string) error
+	SendInvitationEmail(to, organization, role, invitationToken string) error
+}
+
+// OAuthClientServiceInterface defines the interface for OAuth client registration operations
+type OAuthClientServiceInterface interface {
+	Register(reg OAuthClientRegistration) (*models.OAuthClient, string, error)
+	List() ([]models.OAuthClient, error)
+	Delete(id uint) error
+	Find(clientID string) (*models.OAuthClient, error)
+	Authenticate(clientID, secret string) (*models.OAuthClient, error)
+}
+
+// OAuthServerServiceInterface defines the interface for OAuth2 authorization server operations
+type OAuthServerServiceInterface interface {
+	Metadata() *ProviderMetadata
+	Authorize(userID uint, req AuthorizationRequest) (*AuthorizationPrompt, error)
+	Approve(userID uint, req AuthorizationRequest, approved bool) (string, error)
+	Token(req TokenRequest) (*OAuthTokenResponse, error)
+	Introspect(clientID, clientSecret, token, tokenTypeHint string) (*TokenIntrospection, error)
+	Revoke(clientID, clientSecret, token, tokenTypeHint string) error
+	UserInfo(userID uint, scopes []string) (map[string]interface{}, error)
+	ListConsents(userID uint) ([]AppConsent, error)
+	RevokeConsent(userID uint, clientID string) error
 }
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrInvalidInvitation       = errors.New("invalid or expired invitation")
+	ErrInvitationPending       = errors.New("a pending invitation for this email already exists")
+	ErrInvitationEmailMismatch = errors.New("the invitation was sent to a different email address")
+	ErrAlreadyMember           = errors.New("user is already a member of the organization")
+	ErrAccountExists           = errors.New("an account with this email already exists; log in to accept the invitation")
+)
+
+// InvitationService invites people to organizations by email. The emailed
+// token is random and only its hash is stored, like the other single-use
+// links; it expires and is redeemed exactly once.
+type InvitationService struct {
+	invitations repositories.InvitationRepository
+	orgs        repositories.OrganizationRepository
+	users       repositories.UserRepository
+	accounts    UserServiceInterface
+	mailer      EmailServiceInterface
+	ttl         time.Duration
+}
+
+// Ensure InvitationService implements InvitationServiceInterface interface
+var _ InvitationServiceInterface = (*InvitationService)(nil)
+
+func NewInvitationService(invitations repositories.InvitationRepository, orgs repositories.OrganizationRepository, users repositories.UserRepository, accounts UserServiceInterface, mailer EmailServiceInterface, ttlHours int) *InvitationService {
+	return &InvitationService{
+		invitations: invitations,
+		orgs:        orgs,
+		users:       users,
+		accounts:    accounts,
+		mailer:      mailer,
+		ttl:         time.Duration(ttlHours) * time.Hour,
+	}
+}
+
+// Invite mails an invitation to join an organization with a role. Only
+// owners may invite owners.
+func (s *InvitationService) Invite(orgID, invitedBy uint, actorRole, email, role string) (*models.Invitation, error) {
+	if !models.IsOrgRole(role) {
+		return nil, ErrInvalidOrgRole
+	}
+	if role == models.OrgRoleOwner && actorRole != models.OrgRoleOwner {
+		return nil, ErrOrgOwnerRequired
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	u, err := s.users.FindByEmail(email)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, err
+	}
+	if u != nil && u.Membership(orgID) != nil {
+		return nil, ErrAlreadyMember
+	}
+	if _, err := s.invitations.FindPending(orgID, email); err == nil {
+		return nil, ErrInvitationPending
+	} else if !errors.Is(err, repositories.ErrInvitationNotFound) {
+		return nil, err
+	}
+
+	o, err := s.orgs.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	inv := &models.Invitation{
+		OrganizationID: orgID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      utils.HashToken(raw),
+		InvitedBy:      invitedBy,
+		ExpiresAt:      time.Now().Add(s.ttl),
+	}
+	if err := s.invitations.Create(inv); err != nil {
+		return nil, err
+	}
+
+	// The invitation exists even if the email cannot be sent; it can be
+	// sent again through Resend
+	if err := s.mailer.SendInvitationEmail(email, o.Name, role, raw); err != nil {
+		log.Printf("Failed to send invitation email: %v", err)
+	}
+	return inv, nil
+}
+
+// ListPending returns the invitations of an organization that were neither
+// accepted nor revoked, including expired ones
+func (s *InvitationService) ListPending(orgID uint) ([]models.Invitation, error) {
+	return s.invitations.ListPending(orgID)
+}
+
+// Resend mails a new link for an invitation and restarts its expiry. The
+// link sent earlier stops working.
+func (s *InvitationService) Resend(orgID, id uint) (*models.Invitation, error) {
+	inv, err := s.invitations.FindByID(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.AcceptedAt != nil || inv.RevokedAt != nil {
+		return nil, repositories.ErrInvitationNotFound
+	}
+	o, err := s.orgs.FindByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	inv.TokenHash = utils.HashToken(raw)
+	inv.ExpiresAt = time.Now().Add(s.ttl)
+	if err := s.invitations.Update(inv); err != nil {
+		return nil, err
+	}
+	if err := s.mailer.SendInvitationEmail(inv.Email, o.Name, inv.Role, raw); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Revoke withdraws a pending invitation
+func (s *InvitationService) Revoke(orgID, id uint) error {
+	return s.invitations.Revoke(orgID, id)
+}
+
+// Lookup returns a pending invitation with its organization and whether an
+// account with the invited email exists, so a client can send the invitee
+// to the login or to the registration
+func (s *InvitationService) Lookup(token string) (*models.Invitation, bool, error) {
+	inv, err := s.find(token)
+	if err != nil {
+		return nil, false, err
+	}
+	_, err = s.users.FindByEmail(inv.Email)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, false, err
+	}
+	return inv, err == nil, nil
+}
+
+// Accept makes an existing user a member of the organization they were
+// invited to. The user's email must be the invited one.
+func (s *InvitationService) Accept(token string, userID uint) (*models.Invitation, error) {
+	inv, err := s.find(token)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Email, inv.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	if u.Membership(inv.OrganizationID) != nil {
+		return nil, ErrAlreadyMember
+	}
+	if err := s.accept(inv, u.ID); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// Register creates an account for the invited email and makes it a member
+// of the organization. Following the emailed link proves the address, so
+// the account starts with a verified email. The account is only created
+// together with the accepted invitation, so a token used twice at the same
+// time cannot leave an account without its membership.
+func (s *InvitationService) Register(token, name, password string) (*models.User, *models.Invitation, error) {
+	inv, err := s.find(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.users.FindByEmail(inv.Email); err == nil {
+		return nil, nil, ErrAccountExists
+	} else if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, nil, err
+	}
+
+	u, err := s.accounts.RegisterVerified(name, inv.Email, password, func(u *models.User) error {
+		accepted, err := s.invitations.AcceptNewUser(inv, u)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidInvitation
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, inv, nil
+}
+
+// find returns the pending invitation of a token
+func (s *InvitationService) find(token string) (*models.Invitation, error) {
+	inv, err := s.invitations.FindByHash(utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvitationNotFound) {
+			return nil, ErrInvalidInvitation
+		}
+		return nil, err
+	}
+	if !inv.IsPending(time.Now()) {
+		return nil, ErrInvalidInvitation
+	}
+	return inv, nil
+}
+
+func (s *InvitationService) accept(inv *models.Invitation, userID uint) error {
+	accepted, err := s.invitations.Accept(inv, userID)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
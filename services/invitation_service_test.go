@@ -0,0 +1,302 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInvitationService_Invite(t *testing.T) {
+	tests := []struct {
+		name          string
+		actorRole     string
+		role          string
+		existing      *models.User
+		pending       bool
+		expectedError error
+	}{
+		{name: "new person", actorRole: models.OrgRoleAdmin, role: models.OrgRoleMember},
+		{name: "existing account", actorRole: models.OrgRoleAdmin, role: models.OrgRoleAdmin, existing: &models.User{ID: 8, Email: "bob@example.com"}},
+		{name: "owner invites owner", actorRole: models.OrgRoleOwner, role: models.OrgRoleOwner},
+		{name: "admin invites owner", actorRole: models.OrgRoleAdmin, role: models.OrgRoleOwner, expectedError: ErrOrgOwnerRequired},
+		{name: "unknown role", actorRole: models.OrgRoleOwner, role: "superuser", expectedError: ErrInvalidOrgRole},
+		{
+			name:          "already a member",
+			actorRole:     models.OrgRoleAdmin,
+			role:          models.OrgRoleMember,
+			existing:      &models.User{ID: 8, Email: "bob@example.com", Memberships: []models.Membership{{OrganizationID: 3, UserID: 8}}},
+			expectedError: ErrAlreadyMember,
+		},
+		{name: "already invited", actorRole: models.OrgRoleAdmin, role: models.OrgRoleMember, pending: true, expectedError: ErrInvitationPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			if tt.existing != nil {
+				mockUsers.On("FindByEmail", "bob@example.com").Return(tt.existing, nil)
+			} else {
+				mockUsers.On("FindByEmail", "bob@example.com").Return(nil, repositories.ErrUserNotFound)
+			}
+			mockInvitations := &MockInvitationRepository{}
+			if tt.pending {
+				mockInvitations.On("FindPending", uint(3), "bob@example.com").Return(&models.Invitation{ID: 1}, nil)
+			} else {
+				mockInvitations.On("FindPending", uint(3), "bob@example.com").Return(nil, repositories.ErrInvitationNotFound)
+			}
+			mockOrgs := &MockOrganizationRepository{}
+			mockOrgs.On("FindByID", uint(3)).Return(&models.Organization{ID: 3, Name: "Acme"}, nil)
+			var created *models.Invitation
+			mockInvitations.On("Create", mock.AnythingOfType("*models.Invitation")).Run(func(args mock.Arguments) {
+				created = args.Get(0).(*models.Invitation)
+			}).Return(nil)
+			var token string
+			mockMailer := &MockEmailService{}
+			mockMailer.On("SendInvitationEmail", "bob@example.com", "Acme", tt.role, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+				token = args.String(3)
+			}).Return(nil)
+			service := NewInvitationService(mockInvitations, mockOrgs, mockUsers, &MockUserService{}, mockMailer, 168)
+
+			inv, err := service.Invite(3, 1, tt.actorRole, " Bob@Example.com ", tt.role)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockInvitations.AssertNotCalled(t, "Create", mock.Anything)
+				mockMailer.AssertNotCalled(t, "SendInvitationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, created, inv)
+			assert.Equal(t, "bob@example.com", inv.Email)
+			assert.Equal(t, tt.role, inv.Role)
+			assert.Equal(t, uint(1), inv.InvitedBy)
+			assert.WithinDuration(t, time.Now().Add(168*time.Hour), inv.ExpiresAt, time.Minute)
+			// Only the hash of the mailed token is stored
+			assert.Equal(t, utils.HashToken(token), inv.TokenHash)
+			assert.NotEqual(t, token, inv.TokenHash)
+		})
+	}
+}
+
+func TestInvitationService_Resend(t *testing.T) {
+	expired := &models.Invitation{ID: 5, OrganizationID: 3, Email: "bob@example.com", Role: models.OrgRoleMember, TokenHash: "old", ExpiresAt: time.Now().Add(-time.Hour)}
+	mockInvitations := &MockInvitationRepository{}
+	mockInvitations.On("FindByID", uint(3), uint(5)).Return(expired, nil)
+	mockInvitations.On("Update", expired).Return(nil)
+	mockOrgs := &MockOrganizationRepository{}
+	mockOrgs.On("FindByID", uint(3)).Return(&models.Organization{ID: 3, Name: "Acme"}, nil)
+	mockMailer := &MockEmailService{}
+	mockMailer.On("SendInvitationEmail", "bob@example.com", "Acme", models.OrgRoleMember, mock.AnythingOfType("string")).Return(nil)
+	service := NewInvitationService(mockInvitations, mockOrgs, &MockUserRepository{}, &MockUserService{}, mockMailer, 168)
+
+	inv, err := service.Resend(3, 5)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "old", inv.TokenHash)
+	assert.True(t, inv.IsPending(time.Now()))
+	mockMailer.AssertExpectations(t)
+
+	// Accepted invitations cannot be resent
+	now := time.Now()
+	mockInvitations.On("FindByID", uint(3), uint(6)).Return(&models.Invitation{ID: 6, OrganizationID: 3, AcceptedAt: &now}, nil)
+	_, err = service.Resend(3, 6)
+	assert.ErrorIs(t, err, repositories.ErrInvitationNotFound)
+}
+
+func TestInvitationService_Accept(t *testing.T) {
+	pending := func() *models.Invitation {
+		return &models.Invitation{ID: 5, OrganizationID: 3, Email: "bob@example.com", Role: models.OrgRoleAdmin, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		invitation    *models.Invitation
+		user          *models.User
+		attempted     bool
+		accepted      bool
+		expectedError error
+	}{
+		{name: "matching account", invitation: pending(), user: &models.User{ID: 8, Email: "Bob@example.com"}, attempted: true, accepted: true},
+		{name: "other account", invitation: pending(), user: &models.User{ID: 9, Email: "eve@example.com"}, expectedError: ErrInvitationEmailMismatch},
+		{
+			name:          "already a member",
+			invitation:    pending(),
+			user:          &models.User{ID: 8, Email: "bob@example.com", Memberships: []models.Membership{{OrganizationID: 3, UserID: 8}}},
+			expectedError: ErrAlreadyMember,
+		},
+		{name: "expired", invitation: &models.Invitation{ID: 5, OrganizationID: 3, Email: "bob@example.com", ExpiresAt: now.Add(-time.Minute)}, expectedError: ErrInvalidInvitation},
+		{name: "revoked", invitation: &models.Invitation{ID: 5, OrganizationID: 3, Email: "bob@example.com", ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, expectedError: ErrInvalidInvitation},
+		{name: "used concurrently", invitation: pending(), user: &models.User{ID: 8, Email: "bob@example.com"}, attempted: true, expectedError: ErrInvalidInvitation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockInvitations := &MockInvitationRepository{}
+			mockInvitations.On("FindByHash", utils.HashToken("token")).Return(tt.invitation, nil)
+			if tt.attempted {
+				mockInvitations.On("Accept", tt.invitation, tt.user.ID).Return(tt.accepted, nil)
+			}
+			mockUsers := &MockUserRepository{}
+			if tt.user != nil {
+				mockUsers.On("FindByID", tt.user.ID).Return(tt.user, nil)
+			}
+			service := NewInvitationService(mockInvitations, &MockOrganizationRepository{}, mockUsers, &MockUserService{}, &MockEmailService{}, 168)
+
+			userID := uint(8)
+			if tt.user != nil {
+				userID = tt.user.ID
+			}
+			inv, err := service.Accept("token", userID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(3), inv.OrganizationID)
+			}
+			mockInvitations.AssertExpectations(t)
+		})
+	}
+}
+
+func TestInvitationService_Register(t *testing.T) {
+	inv := &models.Invitation{ID: 5, OrganizationID: 3, Email: "bob@example.com", Role: models.OrgRoleMember, ExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("new account", func(t *testing.T) {
+		mockInvitations := &MockInvitationRepository{}
+		mockInvitations.On("FindByHash", utils.HashToken("token")).Return(inv, nil)
+		mockInvitations.On("AcceptNewUser", inv, mock.AnythingOfType("*models.User")).Return(true, nil)
+		mockUsers := &MockUserRepository{}
+		mockUsers.On("FindByEmail", "bob@example.com").Return(nil, repositories.ErrUserNotFound)
+		mockAccounts := &MockUserService{}
+		mockAccounts.On("RegisterVerified", "Bob", "bob@example.com", "password123").Return(&models.User{ID: 8, Name: "Bob", Email: "bob@example.com"}, nil)
+		service := NewInvitationService(mockInvitations, &MockOrganizationRepository{}, mockUsers, mockAccounts, &MockEmailService{}, 168)
+
+		u, accepted, err := service.Register("token", "Bob", "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(8), u.ID)
+		assert.Equal(t, inv, accepted)
+		mockAccounts.AssertNotCalled(t, "Register", mock.Anything, mock.Anything, mock.Anything)
+		mockInvitations.AssertExpectations(t)
+	})
+
+	t.Run("account exists", func(t *testing.T) {
+		mockInvitations := &MockInvitationRepository{}
+		mockInvitations.On("FindByHash", utils.HashToken("token")).Return(inv, nil)
+		mockUsers := &MockUserRepository{}
+		mockUsers.On("FindByEmail", "bob@example.com").Return(&models.User{ID: 8}, nil)
+		mockAccounts := &MockUserService{}
+		service := NewInvitationService(mockInvitations, &MockOrganizationRepository{}, mockUsers, mockAccounts, &MockEmailService{}, 168)
+
+		_, _, err := service.Register("token", "Bob", "password123")
+
+		assert.ErrorIs(t, err, ErrAccountExists)
+		mockAccounts.AssertNotCalled(t, "RegisterVerified", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invitation used concurrently", func(t *testing.T) {
+		mockInvitations := &MockInvitationRepository{}
+		mockInvitations.On("FindByHash", utils.HashToken("token")).Return(inv, nil)
+		mockInvitations.On("AcceptNewUser", inv, mock.AnythingOfType("*models.User")).Return(false, nil)
+		mockUsers := &MockUserRepository{}
+		mockUsers.On("FindByEmail", "bob@example.com").Return(nil, repositories.ErrUserNotFound)
+		mockAccounts := &MockUserService{}
+		mockAccounts.On("RegisterVerified", "Bob", "bob@example.com", "password123").Return(&models.User{Name: "Bob", Email: "bob@example.com"}, nil)
+		service := NewInvitationService(mockInvitations, &MockOrganizationRepository{}, mockUsers, mockAccounts, &MockEmailService{}, 168)
+
+		_, _, err := service.Register("token", "Bob", "password123")
+
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mockInvitations := &MockInvitationRepository{}
+		mockInvitations.On("FindByHash", utils.HashToken("forged")).Return(nil, repositories.ErrInvitationNotFound)
+		service := NewInvitationService(mockInvitations, &MockOrganizationRepository{}, &MockUserRepository{}, &MockUserService{}, &MockEmailService{}, 168)
+
+		_, _, err := service.Register("forged", "Bob", "password123")
+
+		assert.ErrorIs(t, err, ErrInvalidInvitation)
+	})
+}
+
+// MockInvitationRepository is a mock implementation of InvitationRepository
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockInvitationRepository implements InvitationRepository interface
+var _ repositories.InvitationRepository = (*MockInvitationRepository)(nil)
+
+func (m *MockInvitationRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Create(inv *models.Invitation) error {
+	args := m.Called(inv)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) FindByID(orgID, id uint) (*models.Invitation, error) {
+	args := m.Called(orgID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) FindByHash(hash string) (*models.Invitation, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) FindPending(orgID uint, email string) (*models.Invitation, error) {
+	args := m.Called(orgID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) ListPending(orgID uint) ([]models.Invitation, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) Update(inv *models.Invitation) error {
+	args := m.Called(inv)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Revoke(orgID, id uint) error {
+	args := m.Called(orgID, id)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Accept(inv *models.Invitation, userID uint) (bool, error) {
+	args := m.Called(inv, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvitationRepository) AcceptNewUser(inv *models.Invitation, u *models.User) (bool, error) {
+	args := m.Called(inv, u)
+	if args.Bool(0) {
+		u.ID = 8
+	}
+	return args.Bool(0), args.Error(1)
+}
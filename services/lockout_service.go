@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrAccountLocked        = errors.New("account is temporarily locked")
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts")
+	ErrInvalidUnlockToken   = errors.New("invalid or expired unlock token")
+)
+
+// RetryError refuses a login attempt before the password is checked and
+// reports when the client may try again
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s, retry after %d seconds", e.Err, int64(e.RetryAfter.Seconds()))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// LockoutPolicy holds the thresholds of the login brute-force protection
+type LockoutPolicy struct {
+	// AccountThreshold failures lock the account for LockoutDuration
+	AccountThreshold int
+	// IPThreshold failures block the client IP until Window has passed
+	// since its last failure
+	IPThreshold int
+	Window      time.Duration
+	// LockoutDuration is how long a locked account stays locked
+	LockoutDuration time.Duration
+	// After DelayAfter failures each retry has to wait BaseDelay, doubling
+	// with every further failure up to MaxDelay
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// UnlockTokenTTL is the lifetime of the link in the unlock email
+	UnlockTokenTTL time.Duration
+}
+
+// LockoutService counts failed logins per account and per client IP,
+// delays retries, locks accounts and mails unlock links
+type LockoutService struct {
+	attempts repositories.LoginAttemptStore
+	users    repositories.UserRepository
+	tokens   repositories.OneTimeTokenRepository
+	mailer   EmailServiceInterface
+	policy   LockoutPolicy
+}
+
+// Ensure LockoutService implements LockoutServiceInterface interface
+var _ LockoutServiceInterface = (*LockoutService)(nil)
+
+func NewLockoutService(attempts repositories.LoginAttemptStore, users repositories.UserRepository, tokens repositories.OneTimeTokenRepository, mailer EmailServiceInterface, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		attempts: attempts,
+		users:    users,
+		tokens:   tokens,
+		mailer:   mailer,
+		policy:   policy,
+	}
+}
+
+// Reserve refuses a login attempt while the account is locked, while the
+// client IP is blocked or before the progressive delay has passed.
+// Otherwise the attempt is counted as a failure before the credentials are
+// checked, in the same atomic step as the limits, so parallel guesses
+// cannot slip past the delay together. RecordSuccess takes it back.
+func (s *LockoutService) Reserve(email, clientIP string) error {
+	now := time.Now()
+
+	until, err := s.attempts.LockedUntil(accountKey(email))
+	if err != nil {
+		return err
+	}
+	if until.After(now) {
+		return &RetryError{Err: ErrAccountLocked, RetryAfter: until.Sub(now)}
+	}
+
+	wait, err := s.attempts.Reserve(ipKey(clientIP), s.ipLimits())
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &RetryError{Err: ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+
+	wait, err = s.attempts.Reserve(accountKey(email), s.accountLimits())
+	if err != nil || wait > 0 {
+		// The attempt is refused as a whole, so the client IP must not be
+		// charged for it either
+		if err := s.attempts.Release(ipKey(clientIP)); err != nil {
+			log.Printf("Failed to release login attempt: %v", err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &RetryError{Err: ErrTooManyLoginAttempts, RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordFailure confirms a reserved attempt as failed and locks the
+// account once it reaches the threshold. Unknown emails are counted too,
+// so the response does not reveal whether an account exists.
+func (s *LockoutService) RecordFailure(email string) error {
+	account, err := s.attempts.Get(accountKey(email))
+	if err != nil {
+		return err
+	}
+	if account.Failures < int64(s.policy.AccountThreshold) {
+		return nil
+	}
+
+	if err := s.attempts.Lock(accountKey(email), s.policy.LockoutDuration); err != nil {
+		return err
+	}
+	// The lock takes over; the account starts from zero once it ends
+	if err := s.attempts.Reset(accountKey(email)); err != nil {
+		return err
+	}
+
+	if err := s.sendUnlockEmail(email); err != nil {
+		log.Printf("Failed to send unlock email: %v", err)
+	}
+	return nil
+}
+
+// RecordSuccess clears the failures of an account after a correct
+// password and takes back the attempt reserved for the client IP. Earlier
+// failures of the client IP are kept so one valid account cannot be used to
+// reset the counter of an attacker.
+func (s *LockoutService) RecordSuccess(email, clientIP string) error {
+	if err := s.attempts.Reset(accountKey(email)); err != nil {
+		return err
+	}
+	return s.attempts.Release(ipKey(clientIP))
+}
+
+// Unlock redeems an unlock token and lifts the lockout of the account
+func (s *LockoutService) Unlock(token string) error {
+	t, err := s.tokens.FindByHash(utils.HashToken(token), models.TokenPurposeAccountUnlock)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return ErrInvalidUnlockToken
+		}
+		return err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidUnlockToken
+	}
+
+	consumed, err := s.tokens.Consume(t.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidUnlockToken
+	}
+
+	u, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.attempts.Unlock(accountKey(u.Email)); err != nil {
+		return err
+	}
+	return s.attempts.Reset(accountKey(u.Email))
+}
+
+func (s *LockoutService) sendUnlockEmail(email string) error {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Only the most recent link works
+	if err := s.tokens.ConsumeAllForUser(u.ID, models.TokenPurposeAccountUnlock); err != nil {
+		return err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	t := &models.OneTimeToken{
+		UserID:    u.ID,
+		Purpose:   models.TokenPurposeAccountUnlock,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.policy.UnlockTokenTTL),
+	}
+	if err := s.tokens.Create(t); err != nil {
+		return err
+	}
+
+	return s.mailer.SendUnlockEmail(u.Email, raw)
+}
+
+// ipLimits blocks a client IP at its threshold and delays its retries
+func (s *LockoutService) ipLimits() models.AttemptLimits {
+	return models.AttemptLimits{
+		Threshold:  s.policy.IPThreshold,
+		Window:     s.policy.Window,
+		DelayAfter: s.policy.DelayAfter,
+		BaseDelay:  s.policy.BaseDelay,
+		MaxDelay:   s.policy.MaxDelay,
+	}
+}
+
+// accountLimits only delays retries; accounts are locked by RecordFailure
+// instead of being blocked
+func (s *LockoutService) accountLimits() models.AttemptLimits {
+	limits := s.ipLimits()
+	limits.Threshold = 0
+	return limits
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipKey(clientIP string) string {
+	return "ip:" + clientIP
+}
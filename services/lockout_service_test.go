@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		AccountThreshold: 3,
+		IPThreshold:      5,
+		Window:           15 * time.Minute,
+		LockoutDuration:  15 * time.Minute,
+		DelayAfter:       2,
+		BaseDelay:        time.Second,
+		MaxDelay:         4 * time.Second,
+		UnlockTokenTTL:   time.Hour,
+	}
+}
+
+func TestLockoutService_Reserve(t *testing.T) {
+	t.Run("no failures", func(t *testing.T) {
+		service := NewLockoutService(repositories.NewMemoryLoginAttemptStore(), &MockUserRepository{}, &MockOneTimeTokenRepository{}, &MockEmailService{}, testLockoutPolicy())
+
+		assert.NoError(t, service.Reserve("test@example.com", "203.0.113.7"))
+	})
+
+	t.Run("progressive delay after repeated failures", func(t *testing.T) {
+		service := NewLockoutService(repositories.NewMemoryLoginAttemptStore(), &MockUserRepository{}, &MockOneTimeTokenRepository{}, &MockEmailService{}, testLockoutPolicy())
+
+		assert.NoError(t, service.Reserve("test@example.com", "203.0.113.7"))
+		assert.NoError(t, service.RecordFailure("test@example.com"))
+		assert.NoError(t, service.Reserve("test@example.com", "203.0.113.7"))
+		assert.NoError(t, service.RecordFailure("test@example.com"))
+
+		err := service.Reserve("test@example.com", "203.0.113.7")
+		var retry *RetryError
+		assert.True(t, errors.As(err, &retry))
+		assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		assert.InDelta(t, time.Second, retry.RetryAfter, float64(100*time.Millisecond))
+	})
+
+	t.Run("parallel attempts cannot pass the delay together", func(t *testing.T) {
+		store := repositories.NewMemoryLoginAttemptStore()
+		service := NewLockoutService(store, &MockUserRepository{}, &MockOneTimeTokenRepository{}, &MockEmailService{}, testLockoutPolicy())
+
+		var wg sync.WaitGroup
+		var allowed atomic.Int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if service.Reserve("test@example.com", "203.0.113.7") == nil {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Only the attempts before the delay sets in get through
+		assert.Equal(t, int32(2), allowed.Load())
+		account, _ := store.Get("account:test@example.com")
+		ip, _ := store.Get("ip:203.0.113.7")
+		assert.Equal(t, int64(2), account.Failures)
+		assert.Equal(t, int64(2), ip.Failures)
+	})
+
+	t.Run("account is locked at the threshold", func(t *testing.T) {
+		mockUsers := &MockUserRepository{}
+		mockTokens := &MockOneTimeTokenRepository{}
+		mockMailer := &MockEmailService{}
+		user := &models.User{ID: 1, Email: "test@example.com"}
+
+		var stored *models.OneTimeToken
+		var mailed string
+		mockUsers.On("FindByEmail", "Test@Example.com").Return(user, nil)
+		mockTokens.On("ConsumeAllForUser", uint(1), models.TokenPurposeAccountUnlock).Return(nil)
+		mockTokens.On("Create", mock.AnythingOfType("*models.OneTimeToken")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.OneTimeToken)
+		}).Return(nil)
+		mockMailer.On("SendUnlockEmail", "test@example.com", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			mailed = args.String(1)
+		}).Return(nil)
+
+		store := repositories.NewMemoryLoginAttemptStore()
+		for i := 0; i < 3; i++ {
+			_, err := store.Reserve("account:test@example.com", models.AttemptLimits{Window: 15 * time.Minute})
+			assert.NoError(t, err)
+		}
+		service := NewLockoutService(store, mockUsers, mockTokens, mockMailer, testLockoutPolicy())
+		assert.NoError(t, service.RecordFailure("Test@Example.com"))
+
+		// A different client IP is refused too
+		err := service.Reserve("test@example.com", "198.51.100.1")
+		var retry *RetryError
+		assert.True(t, errors.As(err, &retry))
+		assert.ErrorIs(t, err, ErrAccountLocked)
+		assert.InDelta(t, 15*time.Minute, retry.RetryAfter, float64(time.Second))
+
+		assert.Equal(t, utils.HashToken(mailed), stored.TokenHash)
+		assert.Equal(t, models.TokenPurposeAccountUnlock, stored.Purpose)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("client IP is blocked at the threshold", func(t *testing.T) {
+		store := repositories.NewMemoryLoginAttemptStore()
+		for i := 0; i < 5; i++ {
+			_, err := store.Reserve("ip:203.0.113.7", models.AttemptLimits{Window: 15 * time.Minute})
+			assert.NoError(t, err)
+		}
+		service := NewLockoutService(store, &MockUserRepository{}, &MockOneTimeTokenRepository{}, &MockEmailService{}, testLockoutPolicy())
+
+		err := service.Reserve("other@example.com", "203.0.113.7")
+		var retry *RetryError
+		assert.True(t, errors.As(err, &retry))
+		assert.ErrorIs(t, err, ErrTooManyLoginAttempts)
+		assert.InDelta(t, 15*time.Minute, retry.RetryAfter, float64(time.Second))
+		assert.NoError(t, service.Reserve("other@example.com", "198.51.100.1"))
+	})
+}
+
+func TestLockoutService_RecordSuccess(t *testing.T) {
+	store := repositories.NewMemoryLoginAttemptStore()
+	service := NewLockoutService(store, &MockUserRepository{}, &MockOneTimeTokenRepository{}, &MockEmailService{}, testLockoutPolicy())
+
+	assert.NoError(t, service.Reserve("test@example.com", "203.0.113.7"))
+	assert.NoError(t, service.RecordFailure("test@example.com"))
+	assert.NoError(t, service.Reserve("test@example.com", "203.0.113.7"))
+	assert.NoError(t, service.RecordSuccess("test@example.com", "203.0.113.7"))
+
+	// Only the successful attempt is taken back from the client IP
+	account, _ := store.Get("account:test@example.com")
+	ip, _ := store.Get("ip:203.0.113.7")
+	assert.Equal(t, int64(0), account.Failures)
+	assert.Equal(t, int64(1), ip.Failures)
+}
+
+func TestLockoutService_Unlock(t *testing.T) {
+	hash := utils.HashToken("token")
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		setupMock   func(*MockOneTimeTokenRepository, *MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name: "valid token",
+			setupMock: func(mockTokens *MockOneTimeTokenRepository, mockUsers *MockUserRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeAccountUnlock).Return(ot, nil)
+				mockTokens.On("Consume", uint(3)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(&models.User{ID: 1, Email: "test@example.com"}, nil)
+			},
+		},
+		{
+			name: "unknown token",
+			setupMock: func(mockTokens *MockOneTimeTokenRepository, mockUsers *MockUserRepository) {
+				mockTokens.On("FindByHash", hash, models.TokenPurposeAccountUnlock).Return(nil, repositories.ErrTokenNotFound)
+			},
+			expectedErr: ErrInvalidUnlockToken,
+		},
+		{
+			name: "used token",
+			setupMock: func(mockTokens *MockOneTimeTokenRepository, mockUsers *MockUserRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeAccountUnlock).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidUnlockToken,
+		},
+		{
+			name: "expired token",
+			setupMock: func(mockTokens *MockOneTimeTokenRepository, mockUsers *MockUserRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeAccountUnlock).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidUnlockToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := &MockOneTimeTokenRepository{}
+			mockUsers := &MockUserRepository{}
+			tt.setupMock(mockTokens, mockUsers)
+
+			store := repositories.NewMemoryLoginAttemptStore()
+			assert.NoError(t, store.Lock("account:test@example.com", time.Hour))
+
+			service := NewLockoutService(store, mockUsers, mockTokens, &MockEmailService{}, testLockoutPolicy())
+			err := service.Unlock("token")
+
+			until, _ := store.LockedUntil("account:test@example.com")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.True(t, until.After(time.Now()))
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, until.IsZero() || until.Before(time.Now()))
+			}
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+// MockLockoutService is a mock implementation of LockoutServiceInterface
+type MockLockoutService struct {
+	mock.Mock
+}
+
+// Ensure MockLockoutService implements LockoutServiceInterface interface
+var _ LockoutServiceInterface = (*MockLockoutService)(nil)
+
+func (m *MockLockoutService) Reserve(email, clientIP string) error {
+	args := m.Called(email, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) RecordFailure(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) RecordSuccess(email, clientIP string) error {
+	args := m.Called(email, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) Unlock(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
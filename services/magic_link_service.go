@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrInvalidMagicLink  = errors.New("invalid or expired login link")
+	ErrMagicLinkClient   = errors.New("login link was requested from another device")
+	ErrTooManyMagicLinks = errors.New("too many login links requested")
+)
+
+// MagicLinkPolicy configures passwordless login links
+type MagicLinkPolicy struct {
+	// TTL is the lifetime of a link
+	TTL time.Duration
+	// MaxRequests links may be requested per email address within Window
+	MaxRequests int
+	Window      time.Duration
+	// BindClient restricts a link to the IP address and user agent that
+	// requested it
+	BindClient bool
+}
+
+// MagicLinkService mails single-use login links so users can sign in
+// without their password
+type MagicLinkService struct {
+	users   repositories.UserRepository
+	tokens  repositories.OneTimeTokenRepository
+	limiter repositories.RateLimitStore
+	mailer  EmailServiceInterface
+	policy  MagicLinkPolicy
+}
+
+// Ensure MagicLinkService implements MagicLinkServiceInterface interface
+var _ MagicLinkServiceInterface = (*MagicLinkService)(nil)
+
+func NewMagicLinkService(users repositories.UserRepository, tokens repositories.OneTimeTokenRepository, limiter repositories.RateLimitStore, mailer EmailServiceInterface, policy MagicLinkPolicy) *MagicLinkService {
+	return &MagicLinkService{
+		users:   users,
+		tokens:  tokens,
+		limiter: limiter,
+		mailer:  mailer,
+		policy:  policy,
+	}
+}
+
+// Request mails a login link to the user. Requests are limited per email
+// address, registered or not, and a *RetryError is returned over the limit.
+// Unknown and disabled accounts are silently ignored so callers cannot tell
+// which emails are registered.
+func (s *MagicLinkService) Request(email, clientIP, userAgent string) error {
+	result, err := s.limiter.SlidingWindow("magic-link:"+strings.ToLower(email), s.policy.MaxRequests, s.policy.Window)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return &RetryError{Err: ErrTooManyMagicLinks, RetryAfter: result.RetryAfter}
+	}
+
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if u.DisabledAt != nil {
+		return nil
+	}
+
+	// Only the most recent link works
+	if err := s.tokens.ConsumeAllForUser(u.ID, models.TokenPurposeMagicLink); err != nil {
+		return err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	t := &models.OneTimeToken{
+		UserID:    u.ID,
+		Purpose:   models.TokenPurposeMagicLink,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.policy.TTL),
+	}
+	if s.policy.BindClient {
+		t.Binding = clientBinding(clientIP, userAgent)
+	}
+	if err := s.tokens.Create(t); err != nil {
+		return err
+	}
+
+	return s.mailer.SendMagicLinkEmail(u.Email, raw)
+}
+
+// Consume redeems a login link and returns its user. A link bound to
+// another client is refused without being used up, so it still works on
+// the device that requested it.
+func (s *MagicLinkService) Consume(token, clientIP, userAgent string) (*models.User, error) {
+	t, err := s.tokens.FindByHash(utils.HashToken(token), models.TokenPurposeMagicLink)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, ErrInvalidMagicLink
+	}
+	if t.Binding != "" && t.Binding != clientBinding(clientIP, userAgent) {
+		return nil, ErrMagicLinkClient
+	}
+
+	consumed, err := s.tokens.Consume(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMagicLink
+	}
+
+	u, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Following the emailed link proves the user owns the address
+	if u.EmailVerifiedAt == nil {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+		if err := s.users.Update(u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// clientBinding identifies the client a login link is bound to
+func clientBinding(clientIP, userAgent string) string {
+	return utils.HashToken(clientIP + "\n" + userAgent)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testMagicLinkPolicy = MagicLinkPolicy{
+	TTL:         15 * time.Minute,
+	MaxRequests: 2,
+	Window:      time.Hour,
+	BindClient:  true,
+}
+
+func TestMagicLinkService_Request(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockTokens := &MockOneTimeTokenRepository{}
+	mockMailer := &MockEmailService{}
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	var stored *models.OneTimeToken
+	var mailed string
+	mockUsers.On("FindByEmail", "test@example.com").Return(user, nil)
+	mockUsers.On("FindByEmail", "Test@Example.com").Return(nil, repositories.ErrUserNotFound)
+	mockTokens.On("ConsumeAllForUser", user.ID, models.TokenPurposeMagicLink).Return(nil)
+	mockTokens.On("Create", mock.AnythingOfType("*models.OneTimeToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.OneTimeToken)
+	}).Return(nil)
+	mockMailer.On("SendMagicLinkEmail", user.Email, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		mailed = args.String(1)
+	}).Return(nil)
+
+	service := NewMagicLinkService(mockUsers, mockTokens, repositories.NewMemoryRateLimitStore(), mockMailer, testMagicLinkPolicy)
+
+	assert.NoError(t, service.Request("test@example.com", "203.0.113.7", "Firefox"))
+	// Only the hash of the mailed token may be persisted
+	assert.Equal(t, utils.HashToken(mailed), stored.TokenHash)
+	assert.Equal(t, models.TokenPurposeMagicLink, stored.Purpose)
+	assert.Equal(t, clientBinding("203.0.113.7", "Firefox"), stored.Binding)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), stored.ExpiresAt, time.Minute)
+
+	// The limit applies per email address, whatever its letter case
+	assert.NoError(t, service.Request("Test@Example.com", "203.0.113.8", "Firefox"))
+	err := service.Request("test@example.com", "203.0.113.9", "Firefox")
+	var retry *RetryError
+	assert.ErrorAs(t, err, &retry)
+	assert.ErrorIs(t, err, ErrTooManyMagicLinks)
+	assert.Greater(t, retry.RetryAfter, time.Duration(0))
+	mockMailer.AssertNumberOfCalls(t, "SendMagicLinkEmail", 1)
+}
+
+func TestMagicLinkService_RequestUnknownEmail(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockUsers.On("FindByEmail", "nobody@example.com").Return(nil, repositories.ErrUserNotFound)
+
+	service := NewMagicLinkService(mockUsers, &MockOneTimeTokenRepository{}, repositories.NewMemoryRateLimitStore(), &MockEmailService{}, testMagicLinkPolicy)
+
+	assert.NoError(t, service.Request("nobody@example.com", "203.0.113.7", "Firefox"))
+	mockUsers.AssertExpectations(t)
+}
+
+func TestMagicLinkService_Consume(t *testing.T) {
+	hash := utils.HashToken("token")
+	binding := clientBinding("203.0.113.7", "Firefox")
+
+	tests := []struct {
+		name        string
+		clientIP    string
+		setupMock   func(*MockUserRepository, *MockOneTimeTokenRepository)
+		expectedErr error
+	}{
+		{
+			name:     "link redeemed",
+			clientIP: "203.0.113.7",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, Binding: binding, ExpiresAt: time.Now().Add(time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeMagicLink).Return(ot, nil)
+				mockTokens.On("Consume", uint(3)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(&models.User{ID: 1}, nil)
+				mockUsers.On("Update", mock.MatchedBy(func(u *models.User) bool {
+					return u.ID == 1 && u.EmailVerifiedAt != nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "other client",
+			clientIP: "198.51.100.1",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, Binding: binding, ExpiresAt: time.Now().Add(time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeMagicLink).Return(ot, nil)
+			},
+			expectedErr: ErrMagicLinkClient,
+		},
+		{
+			name:     "expired link",
+			clientIP: "203.0.113.7",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, Binding: binding, ExpiresAt: time.Now().Add(-time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeMagicLink).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidMagicLink,
+		},
+		{
+			name:     "unknown link",
+			clientIP: "203.0.113.7",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				mockTokens.On("FindByHash", hash, models.TokenPurposeMagicLink).Return(nil, repositories.ErrTokenNotFound)
+			},
+			expectedErr: ErrInvalidMagicLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockTokens := &MockOneTimeTokenRepository{}
+			tt.setupMock(mockUsers, mockTokens)
+
+			service := NewMagicLinkService(mockUsers, mockTokens, repositories.NewMemoryRateLimitStore(), &MockEmailService{}, testMagicLinkPolicy)
+			u, err := service.Consume("token", tt.clientIP, "Firefox")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, u)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(1), u.ID)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+// MockMagicLinkService is a mock implementation of MagicLinkServiceInterface interface
+type MockMagicLinkService struct {
+	mock.Mock
+}
+
+// Ensure MockMagicLinkService implements MagicLinkServiceInterface interface
+var _ MagicLinkServiceInterface = (*MockMagicLinkService)(nil)
+
+func (m *MockMagicLinkService) Request(email, clientIP, userAgent string) error {
+	args := m.Called(email, clientIP, userAgent)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkService) Consume(token, clientIP, userAgent string) (*models.User, error) {
+	args := m.Called(token, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
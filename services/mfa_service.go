@@ -0,0 +1,263 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+// RecoveryCodeCount is how many recovery codes are issued when TOTP is enabled
+const RecoveryCodeCount = 10
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication enrollment has not been started")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrMFACodeRequired   = errors.New("a two-factor authentication code is required")
+)
+
+// TOTPEnrollment is the secret a user adds to their authenticator app
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAService manages TOTP two-factor authentication and the second step of
+// the login of users who enabled it
+type MFAService struct {
+	users         repositories.UserRepository
+	challenges    repositories.OneTimeTokenRepository
+	recoveryCodes repositories.RecoveryCodeRepository
+	tokens        TokenServiceInterface
+	lockout       LockoutServiceInterface
+	issuer        string
+	challengeTTL  time.Duration
+}
+
+// Ensure MFAService implements MFAServiceInterface interface
+var _ MFAServiceInterface = (*MFAService)(nil)
+
+func NewMFAService(users repositories.UserRepository, challenges repositories.OneTimeTokenRepository, recoveryCodes repositories.RecoveryCodeRepository, tokens TokenServiceInterface, lockout LockoutServiceInterface, issuer string, challengeTTLMinutes int) *MFAService {
+	return &MFAService{
+		users:         users,
+		challenges:    challenges,
+		recoveryCodes: recoveryCodes,
+		tokens:        tokens,
+		lockout:       lockout,
+		issuer:        issuer,
+		challengeTTL:  time.Duration(challengeTTLMinutes) * time.Minute,
+	}
+}
+
+// EnrollTOTP generates a new secret for the user. It only takes effect once
+// ConfirmTOTP has seen a valid code for it.
+func (s *MFAService) EnrollTOTP(userID uint) (*TOTPEnrollment, error) {
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	u.TOTPSecret = secret
+	u.TOTPLastStep = 0
+	if err := s.users.Update(u); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    utils.TOTPProvisioningURI(s.issuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves their
+// authenticator produces valid codes. It returns the recovery codes, which
+// are shown to the user this one time only.
+func (s *MFAService) ConfirmTOTP(userID uint, code string) ([]string, error) {
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(u.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+	if err := s.recoveryCodes.ReplaceForUser(u.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u.MFAEnabledAt = &now
+	u.TOTPLastStep = step
+	if err := s.users.Update(u); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking the
+// password and a current TOTP or recovery code. Failures count towards the
+// login lockout, so neither can be guessed from a hijacked session.
+func (s *MFAService) DisableTOTP(userID uint, password, code, clientIP string) error {
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.MFAEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	if err := s.lockout.Reserve(u.Email, clientIP); err != nil {
+		return err
+	}
+	if !utils.CompareHash(password, u.Password) {
+		s.recordFailure(u.Email)
+		return ErrIncorrectPassword
+	}
+	if err := s.verifyCode(u, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.recordFailure(u.Email)
+		}
+		return err
+	}
+	if err := s.lockout.RecordSuccess(u.Email, clientIP); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+
+	u.TOTPSecret = ""
+	u.TOTPLastStep = 0
+	u.MFAEnabledAt = nil
+	if err := s.users.Update(u); err != nil {
+		return err
+	}
+	return s.recoveryCodes.DeleteForUser(u.ID)
+}
+
+// Challenge issues the short-lived token that stands in for a login
+// between the password step and the second factor
+func (s *MFAService) Challenge(u *models.User) (string, int64, error) {
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", 0, err
+	}
+	t := &models.OneTimeToken{
+		UserID:    u.ID,
+		Purpose:   models.TokenPurposeMFAChallenge,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.challengeTTL),
+	}
+	if err := s.challenges.Create(t); err != nil {
+		return "", 0, err
+	}
+	return raw, int64(s.challengeTTL.Seconds()), nil
+}
+
+// CompleteLogin exchanges a challenge token and a TOTP or recovery code for
+// a token pair. The challenge is used up by the attempt, so a wrong code
+// sends the user back to the password step.
+func (s *MFAService) CompleteLogin(challenge, code, clientIP, userAgent string) (*LoginResult, error) {
+	t, err := s.challenges.FindByHash(utils.HashToken(challenge), models.TokenPurposeMFAChallenge)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, ErrInvalidMFAToken
+	}
+	consumed, err := s.challenges.Consume(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAToken
+	}
+
+	u, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// The account may have been disabled since the password step
+	if u.MFAEnabledAt == nil || u.DisabledAt != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.verifyCode(u, code); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.tokens.IssueTokens(u, []string{models.AMRMultiFactor, models.AMROTP}, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: u, Tokens: pair}, nil
+}
+
+// VerifyCode checks a TOTP or recovery code of a user with two-factor
+// authentication enabled, using it up
+func (s *MFAService) VerifyCode(u *models.User, code string) error {
+	if u.MFAEnabledAt == nil {
+		return ErrInvalidMFACode
+	}
+	return s.verifyCode(u, code)
+}
+
+// verifyCode accepts a TOTP code that has not been used before or an unused
+// recovery code
+func (s *MFAService) verifyCode(u *models.User, code string) error {
+	if step, ok := utils.ValidateTOTP(u.TOTPSecret, code, time.Now()); ok {
+		advanced, err := s.users.AdvanceTOTPStep(u.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	consumed, err := s.recoveryCodes.Consume(u.ID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) recordFailure(email string) {
+	if err := s.lockout.RecordFailure(email); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	}
+}
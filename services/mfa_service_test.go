@@ -0,0 +1,235 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMFAService_EnrollAndConfirm(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockCodes := &MockRecoveryCodeRepository{}
+	user := &models.User{ID: 1, Email: "test@example.com"}
+	mockUsers.On("FindByID", uint(1)).Return(user, nil)
+	mockUsers.On("Update", user).Return(nil)
+
+	service := NewMFAService(mockUsers, &MockOneTimeTokenRepository{}, mockCodes, &MockTokenService{}, &MockLockoutService{}, "Test", 5)
+
+	enrollment, err := service.EnrollTOTP(1)
+	assert.NoError(t, err)
+	assert.Equal(t, user.TOTPSecret, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Test:test@example.com")
+	assert.Nil(t, user.MFAEnabledAt)
+
+	// A wrong code leaves two-factor authentication disabled
+	_, err = service.ConfirmTOTP(1, "000000")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	assert.Nil(t, user.MFAEnabledAt)
+
+	var hashes []string
+	mockCodes.On("ReplaceForUser", uint(1), mock.Anything).Run(func(args mock.Arguments) {
+		hashes = args.Get(1).([]string)
+	}).Return(nil)
+
+	code, err := utils.TOTPCode(enrollment.Secret, time.Now())
+	assert.NoError(t, err)
+	codes, err := service.ConfirmTOTP(1, code)
+	assert.NoError(t, err)
+	assert.NotNil(t, user.MFAEnabledAt)
+	assert.Len(t, codes, RecoveryCodeCount)
+	// Only hashes of the recovery codes are stored
+	assert.Equal(t, utils.HashToken(utils.NormalizeRecoveryCode(codes[0])), hashes[0])
+
+	_, err = service.EnrollTOTP(1)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	mockCodes.AssertExpectations(t)
+}
+
+func TestMFAService_CompleteLogin(t *testing.T) {
+	secret, _ := utils.GenerateTOTPSecret()
+	validCode, _ := utils.TOTPCode(secret, time.Now())
+	hash := utils.HashToken("challenge")
+
+	tests := []struct {
+		name        string
+		code        string
+		setupMock   func(*MockUserRepository, *MockOneTimeTokenRepository, *MockRecoveryCodeRepository, *MockTokenService)
+		expectedErr error
+	}{
+		{
+			name: "valid TOTP code",
+			code: validCode,
+			setupMock: func(mockUsers *MockUserRepository, mockChallenges *MockOneTimeTokenRepository, mockCodes *MockRecoveryCodeRepository, mockTokens *MockTokenService) {
+				mockUsers.On("AdvanceTOTPStep", uint(1), mock.AnythingOfType("int64")).Return(true, nil)
+				mockTokens.On("IssueTokens", mock.AnythingOfType("*models.User"), []string{models.AMRMultiFactor, models.AMROTP}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access"}, nil)
+			},
+		},
+		{
+			name: "replayed TOTP code",
+			code: validCode,
+			setupMock: func(mockUsers *MockUserRepository, mockChallenges *MockOneTimeTokenRepository, mockCodes *MockRecoveryCodeRepository, mockTokens *MockTokenService) {
+				mockUsers.On("AdvanceTOTPStep", uint(1), mock.AnythingOfType("int64")).Return(false, nil)
+			},
+			expectedErr: ErrInvalidMFACode,
+		},
+		{
+			name: "valid recovery code",
+			code: "ABCDE-FGHIJ",
+			setupMock: func(mockUsers *MockUserRepository, mockChallenges *MockOneTimeTokenRepository, mockCodes *MockRecoveryCodeRepository, mockTokens *MockTokenService) {
+				mockCodes.On("Consume", uint(1), utils.HashToken("abcdefghij")).Return(true, nil)
+				mockTokens.On("IssueTokens", mock.AnythingOfType("*models.User"), []string{models.AMRMultiFactor, models.AMROTP}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access"}, nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "abcde-fghij",
+			setupMock: func(mockUsers *MockUserRepository, mockChallenges *MockOneTimeTokenRepository, mockCodes *MockRecoveryCodeRepository, mockTokens *MockTokenService) {
+				mockCodes.On("Consume", uint(1), utils.HashToken("abcdefghij")).Return(false, nil)
+			},
+			expectedErr: ErrInvalidMFACode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockChallenges := &MockOneTimeTokenRepository{}
+			mockCodes := &MockRecoveryCodeRepository{}
+			mockTokens := &MockTokenService{}
+
+			enabledAt := time.Now()
+			user := &models.User{ID: 1, TOTPSecret: secret, MFAEnabledAt: &enabledAt}
+			challenge := &models.OneTimeToken{ID: 9, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+			mockChallenges.On("FindByHash", hash, models.TokenPurposeMFAChallenge).Return(challenge, nil)
+			mockChallenges.On("Consume", uint(9)).Return(true, nil)
+			mockUsers.On("FindByID", uint(1)).Return(user, nil)
+			tt.setupMock(mockUsers, mockChallenges, mockCodes, mockTokens)
+
+			service := NewMFAService(mockUsers, mockChallenges, mockCodes, mockTokens, &MockLockoutService{}, "Test", 5)
+			result, err := service.CompleteLogin("challenge", tt.code, "203.0.113.7", "test-agent")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "access", result.Tokens.AccessToken)
+				assert.Equal(t, user, result.User)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockChallenges.AssertExpectations(t)
+			mockCodes.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMFAService_CompleteLoginWithUsedChallenge(t *testing.T) {
+	mockChallenges := &MockOneTimeTokenRepository{}
+	usedAt := time.Now()
+	challenge := &models.OneTimeToken{ID: 9, UserID: 1, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}
+	mockChallenges.On("FindByHash", utils.HashToken("challenge"), models.TokenPurposeMFAChallenge).Return(challenge, nil)
+
+	service := NewMFAService(&MockUserRepository{}, mockChallenges, &MockRecoveryCodeRepository{}, &MockTokenService{}, &MockLockoutService{}, "Test", 5)
+	result, err := service.CompleteLogin("challenge", "123456", "203.0.113.7", "test-agent")
+
+	assert.ErrorIs(t, err, ErrInvalidMFAToken)
+	assert.Nil(t, result)
+}
+
+func TestMFAService_DisableTOTP(t *testing.T) {
+	const password = "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m" // "password123"
+	secret, _ := utils.GenerateTOTPSecret()
+	validCode, _ := utils.TOTPCode(secret, time.Now())
+
+	tests := []struct {
+		name          string
+		password      string
+		code          string
+		enabled       bool
+		lockoutErr    error
+		expectFailure bool
+		expectedError error
+	}{
+		{name: "password and code", password: "password123", code: validCode, enabled: true},
+		{name: "wrong password", password: "wrongpassword", code: validCode, enabled: true, expectFailure: true, expectedError: ErrIncorrectPassword},
+		{name: "wrong code", password: "password123", code: "abcdefghij", enabled: true, expectFailure: true, expectedError: ErrInvalidMFACode},
+		{name: "locked out", password: "password123", code: validCode, enabled: true, lockoutErr: &RetryError{Err: ErrAccountLocked, RetryAfter: time.Minute}, expectedError: ErrAccountLocked},
+		{name: "not enabled", password: "password123", code: validCode, expectedError: ErrMFANotEnabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: 1, Email: "test@example.com", Password: password, TOTPSecret: secret}
+			if tt.enabled {
+				now := time.Now()
+				user.MFAEnabledAt = &now
+			}
+			mockUsers := &MockUserRepository{}
+			mockUsers.On("FindByID", uint(1)).Return(user, nil)
+			mockUsers.On("AdvanceTOTPStep", uint(1), mock.AnythingOfType("int64")).Return(true, nil).Maybe()
+			mockCodes := &MockRecoveryCodeRepository{}
+			mockCodes.On("Consume", uint(1), utils.HashToken("abcdefghij")).Return(false, nil).Maybe()
+			mockLockout := &MockLockoutService{}
+			if tt.enabled {
+				mockLockout.On("Reserve", user.Email, "203.0.113.7").Return(tt.lockoutErr)
+			}
+			if tt.expectFailure {
+				mockLockout.On("RecordFailure", user.Email).Return(nil)
+			}
+			if tt.expectedError == nil {
+				mockLockout.On("RecordSuccess", user.Email, "203.0.113.7").Return(nil)
+				mockUsers.On("Update", user).Return(nil)
+				mockCodes.On("DeleteForUser", uint(1)).Return(nil)
+			}
+
+			service := NewMFAService(mockUsers, &MockOneTimeTokenRepository{}, mockCodes, &MockTokenService{}, mockLockout, "Test", 5)
+			err := service.DisableTOTP(1, tt.password, tt.code, "203.0.113.7")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.NotEmpty(t, user.TOTPSecret)
+			} else {
+				assert.NoError(t, err)
+				assert.Nil(t, user.MFAEnabledAt)
+				assert.Empty(t, user.TOTPSecret)
+			}
+			mockLockout.AssertExpectations(t)
+			mockCodes.AssertExpectations(t)
+		})
+	}
+}
+
+// MockRecoveryCodeRepository is a mock implementation of RecoveryCodeRepository interface
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRecoveryCodeRepository implements RecoveryCodeRepository interface
+var _ repositories.RecoveryCodeRepository = (*MockRecoveryCodeRepository)(nil)
+
+func (m *MockRecoveryCodeRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) ReplaceForUser(userID uint, hashes []string) error {
+	args := m.Called(userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Consume(userID uint, hash string) (bool, error) {
+	args := m.Called(userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) DeleteForUser(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
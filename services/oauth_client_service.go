@@ -0,0 +1,196 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrInvalidOAuthClient       = errors.New("invalid oauth client registration")
+	ErrOAuthClientNotFound      = errors.New("oauth client not found")
+	ErrInvalidClientCredentials = errors.New("invalid client credentials")
+)
+
+// OAuthClientRegistration describes an application to register with the
+// authorization server
+type OAuthClientRegistration struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+	// Confidential clients get a secret; public ones rely on PKCE alone
+	Confidential bool
+	// Trusted clients are not asked for the user's consent
+	Trusted bool
+}
+
+// OAuthClientService manages the applications registered with the
+// authorization server and authenticates them at its endpoints
+type OAuthClientService struct {
+	clients repositories.OAuthClientRepository
+}
+
+// Ensure OAuthClientService implements OAuthClientServiceInterface interface
+var _ OAuthClientServiceInterface = (*OAuthClientService)(nil)
+
+func NewOAuthClientService(clients repositories.OAuthClientRepository) *OAuthClientService {
+	return &OAuthClientService{clients: clients}
+}
+
+// Register stores a new client and returns it together with its plain
+// secret, which is empty for public clients and cannot be recovered later
+func (s *OAuthClientService) Register(reg OAuthClientRegistration) (*models.OAuthClient, string, error) {
+	if strings.TrimSpace(reg.Name) == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidOAuthClient)
+	}
+	if len(reg.GrantTypes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one grant type is required", ErrInvalidOAuthClient)
+	}
+	for _, grantType := range reg.GrantTypes {
+		if !containsString(models.OAuthGrantTypes, grantType) {
+			return nil, "", fmt.Errorf("%w: unsupported grant type %s", ErrInvalidOAuthClient, grantType)
+		}
+	}
+	if containsString(reg.GrantTypes, models.GrantClientCredentials) && !reg.Confidential {
+		return nil, "", fmt.Errorf("%w: public clients cannot use client_credentials", ErrInvalidOAuthClient)
+	}
+	if containsString(reg.GrantTypes, models.GrantAuthorizationCode) && len(reg.RedirectURIs) == 0 {
+		return nil, "", fmt.Errorf("%w: authorization_code requires a redirect URI", ErrInvalidOAuthClient)
+	}
+	for _, uri := range reg.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, "", err
+		}
+	}
+	for _, scope := range reg.Scopes {
+		if !containsString(models.OAuthScopes, scope) {
+			return nil, "", fmt.Errorf("%w: unsupported scope %s", ErrInvalidOAuthClient, scope)
+		}
+	}
+
+	clientID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+	c := &models.OAuthClient{
+		ClientID:     clientID,
+		Name:         strings.TrimSpace(reg.Name),
+		RedirectURIs: reg.RedirectURIs,
+		Scopes:       reg.Scopes,
+		GrantTypes:   reg.GrantTypes,
+		Trusted:      reg.Trusted,
+	}
+
+	var secret string
+	if reg.Confidential {
+		if secret, err = utils.GenerateRandomToken(32); err != nil {
+			return nil, "", err
+		}
+		c.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.clients.Create(c); err != nil {
+		return nil, "", err
+	}
+	return c, secret, nil
+}
+
+// List returns every registered client
+func (s *OAuthClientService) List() ([]models.OAuthClient, error) {
+	return s.clients.List()
+}
+
+// Delete removes a client. Consents given to it and its refresh tokens are
+// removed with it; access tokens it holds stay valid until they expire.
+func (s *OAuthClientService) Delete(id uint) error {
+	if err := s.clients.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+			return ErrOAuthClientNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Find returns a client by its client_id
+func (s *OAuthClientService) Find(clientID string) (*models.OAuthClient, error) {
+	c, err := s.clients.FindByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+			return nil, ErrOAuthClientNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// Authenticate identifies the client calling an endpoint of the
+// authorization server. Confidential clients must present their secret and
+// public clients must not present one.
+func (s *OAuthClientService) Authenticate(clientID, secret string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrInvalidClientCredentials
+	}
+	c, err := s.Find(clientID)
+	if err != nil {
+		if errors.Is(err, ErrOAuthClientNotFound) {
+			return nil, ErrInvalidClientCredentials
+		}
+		return nil, err
+	}
+
+	if !c.IsConfidential() {
+		if secret != "" {
+			return nil, ErrInvalidClientCredentials
+		}
+		return c, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidClientCredentials
+	}
+	return c, nil
+}
+
+// validateRedirectURI accepts absolute URIs without a fragment that use
+// https, http on the loopback interface, or the private-use scheme of a
+// native app (RFC 8252), which must contain a period
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Fragment != "" || strings.Contains(raw, "#") {
+		return fmt.Errorf("%w: invalid redirect URI %s", ErrInvalidOAuthClient, raw)
+	}
+
+	switch u.Scheme {
+	case "https":
+		if u.Host != "" {
+			return nil
+		}
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	default:
+		if strings.Contains(u.Scheme, ".") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: redirect URI %s must use https, loopback http or a private-use scheme", ErrInvalidOAuthClient, raw)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
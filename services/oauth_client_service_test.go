@@ -0,0 +1,175 @@
+package services
+
+import (
+	"testing"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOAuthClientService_Register(t *testing.T) {
+	tests := []struct {
+		name          string
+		reg           OAuthClientRegistration
+		expectedError error
+	}{
+		{
+			name: "confidential web app",
+			reg: OAuthClientRegistration{
+				Name:         "Wiki",
+				RedirectURIs: []string{"https://wiki.example.com/callback"},
+				Scopes:       []string{models.ScopeOpenID, models.ScopeEmail},
+				GrantTypes:   []string{models.GrantAuthorizationCode, models.GrantRefreshToken},
+				Confidential: true,
+			},
+		},
+		{
+			name: "public native app",
+			reg: OAuthClientRegistration{
+				Name:         "Desktop",
+				RedirectURIs: []string{"http://127.0.0.1:7777/callback", "com.example.desktop:/callback"},
+				Scopes:       []string{models.ScopeOpenID},
+				GrantTypes:   []string{models.GrantAuthorizationCode},
+			},
+		},
+		{
+			name: "plain http redirect",
+			reg: OAuthClientRegistration{
+				Name:         "Wiki",
+				RedirectURIs: []string{"http://wiki.example.com/callback"},
+				GrantTypes:   []string{models.GrantAuthorizationCode},
+			},
+			expectedError: ErrInvalidOAuthClient,
+		},
+		{
+			name: "redirect with fragment",
+			reg: OAuthClientRegistration{
+				Name:         "Wiki",
+				RedirectURIs: []string{"https://wiki.example.com/callback#done"},
+				GrantTypes:   []string{models.GrantAuthorizationCode},
+			},
+			expectedError: ErrInvalidOAuthClient,
+		},
+		{
+			name: "public client with client credentials",
+			reg: OAuthClientRegistration{
+				Name:       "Cron",
+				GrantTypes: []string{models.GrantClientCredentials},
+			},
+			expectedError: ErrInvalidOAuthClient,
+		},
+		{
+			name: "unknown scope",
+			reg: OAuthClientRegistration{
+				Name:         "Cron",
+				Scopes:       []string{"users:write"},
+				GrantTypes:   []string{models.GrantClientCredentials},
+				Confidential: true,
+			},
+			expectedError: ErrInvalidOAuthClient,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockOAuthClientRepository{}
+			if tt.expectedError == nil {
+				mockRepo.On("Create", mock.AnythingOfType("*models.OAuthClient")).Return(nil)
+			}
+
+			service := NewOAuthClientService(mockRepo)
+			client, secret, err := service.Register(tt.reg)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, client)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, client.ClientID)
+				assert.Equal(t, tt.reg.Confidential, client.IsConfidential())
+				assert.Equal(t, tt.reg.Confidential, secret != "")
+				if secret != "" {
+					assert.Equal(t, utils.HashToken(secret), client.SecretHash)
+				}
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOAuthClientService_Authenticate(t *testing.T) {
+	confidential := &models.OAuthClient{ClientID: "wiki", SecretHash: utils.HashToken("secret")}
+	public := &models.OAuthClient{ClientID: "spa"}
+
+	tests := []struct {
+		name          string
+		clientID      string
+		secret        string
+		expectedError error
+	}{
+		{name: "confidential client", clientID: "wiki", secret: "secret"},
+		{name: "wrong secret", clientID: "wiki", secret: "guess", expectedError: ErrInvalidClientCredentials},
+		{name: "missing secret", clientID: "wiki", expectedError: ErrInvalidClientCredentials},
+		{name: "public client", clientID: "spa"},
+		{name: "public client with secret", clientID: "spa", secret: "secret", expectedError: ErrInvalidClientCredentials},
+		{name: "unknown client", clientID: "other", expectedError: ErrInvalidClientCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockOAuthClientRepository{}
+			mockRepo.On("FindByClientID", "wiki").Return(confidential, nil)
+			mockRepo.On("FindByClientID", "spa").Return(public, nil)
+			mockRepo.On("FindByClientID", "other").Return(nil, repositories.ErrOAuthClientNotFound)
+
+			client, err := NewOAuthClientService(mockRepo).Authenticate(tt.clientID, tt.secret)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.clientID, client.ClientID)
+			}
+		})
+	}
+}
+
+// MockOAuthClientRepository is a mock implementation of OAuthClientRepository interface
+type MockOAuthClientRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOAuthClientRepository implements OAuthClientRepository interface
+var _ repositories.OAuthClientRepository = (*MockOAuthClientRepository)(nil)
+
+func (m *MockOAuthClientRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) Create(c *models.OAuthClient) error {
+	args := m.Called(c)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) FindByClientID(clientID string) (*models.OAuthClient, error) {
+	args := m.Called(clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) List() ([]models.OAuthClient, error) {
+	args := m.Called()
+	return args.Get(0).([]models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,798 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthError is an error response of the OAuth2 protocol (RFC 6749
+// sections 4.1.2.1 and 5.2). Errors with the same Code match each other
+// with errors.Is, so the variables below can be used as targets.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func (e *OAuthError) Is(target error) bool {
+	t, ok := target.(*OAuthError)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrOAuthInvalidRequest          = &OAuthError{Code: "invalid_request"}
+	ErrOAuthInvalidClient           = &OAuthError{Code: "invalid_client"}
+	ErrOAuthInvalidGrant            = &OAuthError{Code: "invalid_grant"}
+	ErrOAuthUnauthorizedClient      = &OAuthError{Code: "unauthorized_client"}
+	ErrOAuthUnsupportedGrantType    = &OAuthError{Code: "unsupported_grant_type"}
+	ErrOAuthUnsupportedResponseType = &OAuthError{Code: "unsupported_response_type"}
+	ErrOAuthInvalidScope            = &OAuthError{Code: "invalid_scope"}
+	ErrOAuthAccessDenied            = &OAuthError{Code: "access_denied"}
+)
+
+var (
+	// ErrInvalidAuthorizationRequest is returned for authorization requests
+	// with an unknown client or an unregistered redirect URI. Unlike other
+	// errors these must not be redirected back to the client.
+	ErrInvalidAuthorizationRequest = errors.New("unknown client_id or unregistered redirect_uri")
+	ErrConsentNotFound             = errors.New("consent not found")
+)
+
+func oauthError(kind *OAuthError, description string) *OAuthError {
+	return &OAuthError{Code: kind.Code, Description: description}
+}
+
+// OAuthServerPolicy configures the authorization server. Issuer is the
+// public URL of this service and the iss of every token it issues;
+// AuthorizationURL is the frontend page that asks users for consent.
+type OAuthServerPolicy struct {
+	Issuer           string
+	AuthorizationURL string
+	SigningAlgorithm string
+	CodeTTL          time.Duration
+	AccessTTL        time.Duration
+	RefreshTTL       time.Duration
+}
+
+// AuthorizationRequest holds the parameters of an authorization request
+// (RFC 6749 section 4.1.1 with PKCE, RFC 7636)
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ErrorRedirect returns the redirect URI of the request carrying an error
+func (r AuthorizationRequest) ErrorRedirect(e *OAuthError) string {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	return redirectWith(r.RedirectURI, params, r.State)
+}
+
+// AuthorizationPrompt describes a valid authorization request to the user
+type AuthorizationPrompt struct {
+	Client *models.OAuthClient
+	Scopes []string
+	// ConsentRequired is false for trusted clients and when the user has
+	// already allowed every requested scope
+	ConsentRequired bool
+}
+
+// TokenRequest holds the parameters of a token request. The client
+// credentials come from HTTP Basic authentication or the request body.
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// OAuthTokenResponse is the successful response of the token endpoint
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// TokenIntrospection is the response of the introspection endpoint
+// (RFC 7662). Inactive tokens only carry active=false.
+type TokenIntrospection struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	TokenID   string   `json:"jti,omitempty"`
+}
+
+// AppConsent is a consent as shown to the user who gave it
+type AppConsent struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	GrantedAt  time.Time `json:"granted_at"`
+}
+
+// ProviderMetadata is the OpenID Connect discovery document
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// OAuthServerService lets registered applications log users in through
+// this service with the authorization code flow and PKCE, and issues
+// tokens to clients acting for themselves with client credentials. Access
+// tokens are JWTs signed like those of logins, restricted to the granted
+// scopes; refresh tokens rotate on every use.
+type OAuthServerService struct {
+	clients    OAuthClientServiceInterface
+	consents   repositories.OAuthConsentRepository
+	codes      repositories.AuthorizationCodeStore
+	tokens     repositories.OAuthTokenRepository
+	users      repositories.UserRepository
+	sessions   repositories.SessionStore
+	denylist   repositories.TokenDenylist
+	jwtManager *utils.TokenManager
+	policy     OAuthServerPolicy
+}
+
+// Ensure OAuthServerService implements OAuthServerServiceInterface interface
+var _ OAuthServerServiceInterface = (*OAuthServerService)(nil)
+
+func NewOAuthServerService(clients OAuthClientServiceInterface, consents repositories.OAuthConsentRepository, codes repositories.AuthorizationCodeStore, tokens repositories.OAuthTokenRepository, users repositories.UserRepository, sessions repositories.SessionStore, denylist repositories.TokenDenylist, jwtManager *utils.TokenManager, policy OAuthServerPolicy) *OAuthServerService {
+	return &OAuthServerService{
+		clients:    clients,
+		consents:   consents,
+		codes:      codes,
+		tokens:     tokens,
+		users:      users,
+		sessions:   sessions,
+		denylist:   denylist,
+		jwtManager: jwtManager,
+		policy:     policy,
+	}
+}
+
+// Metadata returns the discovery document published at
+// /.well-known/openid-configuration
+func (s *OAuthServerService) Metadata() *ProviderMetadata {
+	base := strings.TrimSuffix(s.policy.Issuer, "/")
+	return &ProviderMetadata{
+		Issuer:                            s.policy.Issuer,
+		AuthorizationEndpoint:             s.policy.AuthorizationURL,
+		TokenEndpoint:                     base + "/oauth/token",
+		UserInfoEndpoint:                  base + "/oauth/userinfo",
+		IntrospectionEndpoint:             base + "/oauth/introspect",
+		RevocationEndpoint:                base + "/oauth/revoke",
+		JWKSURI:                           base + "/.well-known/jwks.json",
+		ScopesSupported:                   models.OAuthScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               models.OAuthGrantTypes,
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.policy.SigningAlgorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+// Authorize validates an authorization request of a logged in user and
+// tells whether the user still has to consent to it
+func (s *OAuthServerService) Authorize(userID uint, req AuthorizationRequest) (*AuthorizationPrompt, error) {
+	client, scopes, err := s.validateAuthorization(req)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := &AuthorizationPrompt{Client: client, Scopes: scopes, ConsentRequired: !client.Trusted}
+	if prompt.ConsentRequired {
+		consent, err := s.consents.Find(userID, client.ClientID)
+		if err != nil && !errors.Is(err, repositories.ErrConsentNotFound) {
+			return nil, err
+		}
+		prompt.ConsentRequired = consent == nil || !consent.Covers(scopes)
+	}
+	return prompt, nil
+}
+
+// Approve records the user's decision on an authorization request and
+// returns the URI to send the user back to the client with, carrying an
+// authorization code or the access_denied error
+func (s *OAuthServerService) Approve(userID uint, req AuthorizationRequest, approved bool) (string, error) {
+	client, scopes, err := s.validateAuthorization(req)
+	if err != nil {
+		return "", err
+	}
+	if !approved {
+		return req.ErrorRedirect(oauthError(ErrOAuthAccessDenied, "the user denied the request")), nil
+	}
+
+	if !client.Trusted {
+		consent, err := s.consents.Find(userID, client.ClientID)
+		if err != nil {
+			if !errors.Is(err, repositories.ErrConsentNotFound) {
+				return "", err
+			}
+			consent = &models.OAuthConsent{UserID: userID, ClientID: client.ClientID}
+		}
+		for _, scope := range scopes {
+			if !containsString(consent.Scopes, scope) {
+				consent.Scopes = append(consent.Scopes, scope)
+			}
+		}
+		if err := s.consents.Save(consent); err != nil {
+			return "", err
+		}
+	}
+
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.codes.Save(utils.HashToken(code), &models.AuthorizationCode{
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+	}, s.policy.CodeTTL); err != nil {
+		return "", err
+	}
+	return redirectWith(req.RedirectURI, url.Values{"code": {code}}, req.State), nil
+}
+
+// Token handles a request to the token endpoint
+func (s *OAuthServerService) Token(req TokenRequest) (*OAuthTokenResponse, error) {
+	if !containsString(models.OAuthGrantTypes, req.GrantType) {
+		return nil, oauthError(ErrOAuthUnsupportedGrantType, "unsupported grant_type "+req.GrantType)
+	}
+	client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.HasGrantType(req.GrantType) {
+		return nil, oauthError(ErrOAuthUnauthorizedClient, "the client may not use "+req.GrantType)
+	}
+
+	switch req.GrantType {
+	case models.GrantAuthorizationCode:
+		return s.exchangeCode(client, req)
+	case models.GrantRefreshToken:
+		return s.refresh(client, req)
+	default:
+		return s.clientCredentials(client, req)
+	}
+}
+
+// Introspect tells a confidential client whether a token is active. Access
+// tokens of every kind are described; refresh tokens only to the client
+// they were issued to.
+func (s *OAuthServerService) Introspect(clientID, clientSecret, token, tokenTypeHint string) (*TokenIntrospection, error) {
+	client, err := s.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsConfidential() {
+		return nil, oauthError(ErrOAuthInvalidClient, "public clients cannot introspect tokens")
+	}
+
+	// Access tokens are looked at first unless the hint says otherwise
+	if tokenTypeHint != "refresh_token" {
+		info, err := s.introspectAccessToken(token)
+		if err != nil || info.Active {
+			return info, err
+		}
+	}
+	if info, ok := s.introspectRefreshToken(client, token); ok {
+		return info, nil
+	}
+	if tokenTypeHint == "refresh_token" {
+		return s.introspectAccessToken(token)
+	}
+	return &TokenIntrospection{Active: false}, nil
+}
+
+// Revoke revokes an access or refresh token issued to the client (RFC
+// 7009). Revoking a refresh token revokes every token rotated from the
+// same authorization. Unknown tokens and tokens of other clients are
+// ignored.
+func (s *OAuthServerService) Revoke(clientID, clientSecret, token, tokenTypeHint string) error {
+	client, err := s.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	if tokenTypeHint != "refresh_token" {
+		if claims, err := s.jwtManager.Verify(token); err == nil {
+			if claims.ClientID != client.ClientID {
+				return nil
+			}
+			return s.denylist.Add(claims.ID, time.Until(s.jwtManager.AcceptedUntil(claims.ExpiresAt.Time)))
+		}
+	}
+
+	rt, err := s.tokens.FindByHash(utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil
+		}
+		return err
+	}
+	if rt.ClientID != client.ClientID {
+		return nil
+	}
+	return s.tokens.RevokeFamily(rt.FamilyID)
+}
+
+// UserInfo returns the claims about a user that the scopes allow
+func (s *OAuthServerService) UserInfo(userID uint, scopes []string) (map[string]interface{}, error) {
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := map[string]interface{}{"sub": strconv.FormatUint(uint64(u.ID), 10)}
+	if containsString(scopes, models.ScopeProfile) {
+		info["name"] = u.Name
+	}
+	if containsString(scopes, models.ScopeEmail) {
+		info["email"] = u.Email
+		info["email_verified"] = u.EmailVerifiedAt != nil
+	}
+	return info, nil
+}
+
+// ListConsents returns the clients a user has given consent to
+func (s *OAuthServerService) ListConsents(userID uint) ([]AppConsent, error) {
+	consents, err := s.consents.ListForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apps := make([]AppConsent, 0, len(consents))
+	for _, consent := range consents {
+		app := AppConsent{ClientID: consent.ClientID, Scopes: consent.Scopes, GrantedAt: consent.UpdatedAt}
+		if client, err := s.clients.Find(consent.ClientID); err == nil {
+			app.ClientName = client.Name
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// RevokeConsent withdraws a user's consent to a client and revokes the
+// refresh tokens the client holds for the user
+func (s *OAuthServerService) RevokeConsent(userID uint, clientID string) error {
+	if err := s.consents.Delete(userID, clientID); err != nil {
+		if errors.Is(err, repositories.ErrConsentNotFound) {
+			return ErrConsentNotFound
+		}
+		return err
+	}
+	return s.tokens.RevokeForClient(userID, clientID)
+}
+
+// validateAuthorization checks an authorization request and returns the
+// client and the scopes to grant
+func (s *OAuthServerService) validateAuthorization(req AuthorizationRequest) (*models.OAuthClient, []string, error) {
+	client, err := s.clients.Find(req.ClientID)
+	if err != nil {
+		if errors.Is(err, ErrOAuthClientNotFound) {
+			return nil, nil, ErrInvalidAuthorizationRequest
+		}
+		return nil, nil, err
+	}
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return nil, nil, ErrInvalidAuthorizationRequest
+	}
+
+	if req.ResponseType != "code" {
+		return nil, nil, oauthError(ErrOAuthUnsupportedResponseType, "response_type must be code")
+	}
+	if !client.HasGrantType(models.GrantAuthorizationCode) {
+		return nil, nil, oauthError(ErrOAuthUnauthorizedClient, "the client may not use authorization_code")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, nil, oauthError(ErrOAuthInvalidRequest, "PKCE with code_challenge_method S256 is required")
+	}
+
+	scopes, err := grantableScopes(client, req.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, scopes, nil
+}
+
+// grantableScopes parses a space separated scope parameter, defaulting to
+// every scope of the client
+func grantableScopes(client *models.OAuthClient, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return append([]string{}, client.Scopes...), nil
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, s := range requested {
+		if !client.HasScope(s) {
+			return nil, oauthError(ErrOAuthInvalidScope, "the client may not request "+s)
+		}
+		if !containsString(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, nil
+}
+
+func (s *OAuthServerService) authenticateClient(clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := s.clients.Authenticate(clientID, clientSecret)
+	if err != nil {
+		if errors.Is(err, ErrInvalidClientCredentials) {
+			return nil, oauthError(ErrOAuthInvalidClient, "client authentication failed")
+		}
+		return nil, err
+	}
+	return client, nil
+}
+
+func (s *OAuthServerService) exchangeCode(client *models.OAuthClient, req TokenRequest) (*OAuthTokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauthError(ErrOAuthInvalidRequest, "code and code_verifier are required")
+	}
+
+	code, err := s.codes.Take(utils.HashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAuthorizationCodeNotFound) {
+			return nil, oauthError(ErrOAuthInvalidGrant, "invalid or expired authorization code")
+		}
+		return nil, err
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != req.RedirectURI {
+		return nil, oauthError(ErrOAuthInvalidGrant, "the code was issued to another client or redirect_uri")
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.PKCEChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, oauthError(ErrOAuthInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+
+	u, err := s.activeUser(code.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(oauthGrant{client: client, user: u, scopes: code.Scopes, nonce: code.Nonce}, code.Scopes)
+}
+
+func (s *OAuthServerService) refresh(client *models.OAuthClient, req TokenRequest) (*OAuthTokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, oauthError(ErrOAuthInvalidRequest, "refresh_token is required")
+	}
+
+	rt, err := s.tokens.FindByHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil, oauthError(ErrOAuthInvalidGrant, "invalid refresh token")
+		}
+		return nil, err
+	}
+	if rt.ClientID != client.ClientID {
+		return nil, oauthError(ErrOAuthInvalidGrant, "invalid refresh token")
+	}
+	if rt.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(rt)
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, oauthError(ErrOAuthInvalidGrant, "refresh token expired")
+	}
+
+	// The client may ask for fewer scopes than it was granted
+	scopes := rt.Scopes
+	if req.Scope != "" {
+		scopes = strings.Fields(req.Scope)
+		for _, scope := range scopes {
+			if !containsString(rt.Scopes, scope) {
+				return nil, oauthError(ErrOAuthInvalidScope, scope+" was not granted")
+			}
+		}
+	}
+
+	rotated, err := s.tokens.Revoke(rt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated this token first
+		return nil, s.revokeReusedFamily(rt)
+	}
+
+	u, err := s.activeUser(rt.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(oauthGrant{client: client, user: u, scopes: rt.Scopes, familyID: rt.FamilyID}, scopes)
+}
+
+func (s *OAuthServerService) clientCredentials(client *models.OAuthClient, req TokenRequest) (*OAuthTokenResponse, error) {
+	scopes, err := grantableScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scopes about a user make no sense without one; they are dropped from
+	// the defaults and refused when asked for
+	granted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if isIdentityScope(scope) {
+			if req.Scope != "" {
+				return nil, oauthError(ErrOAuthInvalidScope, scope+" requires a user")
+			}
+			continue
+		}
+		granted = append(granted, scope)
+	}
+
+	access, err := s.signAccessToken(client.ClientID, client.ClientID, granted)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthTokenResponse{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.policy.AccessTTL.Seconds()),
+		Scope:       strings.Join(granted, " "),
+	}, nil
+}
+
+// oauthGrant is an authorization a user gave a client. Refresh tokens keep
+// the granted scopes even when an access token is issued for fewer.
+type oauthGrant struct {
+	client   *models.OAuthClient
+	user     *models.User
+	scopes   []string
+	familyID string
+	nonce    string
+}
+
+// issue returns an access token for the scopes, a refresh token when the
+// client may use them and an ID token for new openid authorizations
+func (s *OAuthServerService) issue(grant oauthGrant, scopes []string) (*OAuthTokenResponse, error) {
+	subject := strconv.FormatUint(uint64(grant.user.ID), 10)
+	access, err := s.signAccessToken(subject, grant.client.ClientID, scopes)
+	if err != nil {
+		return nil, err
+	}
+	resp := &OAuthTokenResponse{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.policy.AccessTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}
+
+	if grant.client.HasGrantType(models.GrantRefreshToken) {
+		familyID := grant.familyID
+		if familyID == "" {
+			if familyID, err = utils.GenerateRandomToken(16); err != nil {
+				return nil, err
+			}
+		}
+		raw, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.tokens.Create(&models.OAuthRefreshToken{
+			ClientID:  grant.client.ClientID,
+			UserID:    grant.user.ID,
+			FamilyID:  familyID,
+			TokenHash: utils.HashToken(raw),
+			Scopes:    grant.scopes,
+			ExpiresAt: time.Now().Add(s.policy.RefreshTTL),
+		}); err != nil {
+			return nil, err
+		}
+		resp.RefreshToken = raw
+	}
+
+	if grant.familyID == "" && containsString(scopes, models.ScopeOpenID) {
+		idToken, err := s.signIDToken(grant, scopes)
+		if err != nil {
+			return nil, err
+		}
+		resp.IDToken = idToken
+	}
+	return resp, nil
+}
+
+func (s *OAuthServerService) signAccessToken(subject, clientID string, scopes []string) (string, error) {
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return s.jwtManager.Sign(&utils.Claims{
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.policy.AccessTTL)),
+		},
+	})
+}
+
+// signIDToken issues an OpenID Connect ID token. It carries no jti, so it
+// can never pass as an access token.
+func (s *OAuthServerService) signIDToken(grant oauthGrant, scopes []string) (string, error) {
+	now := time.Now()
+	claims := &utils.IDClaims{
+		Nonce: grant.nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(grant.user.ID), 10),
+			Audience:  jwt.ClaimStrings{grant.client.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.policy.AccessTTL)),
+		},
+	}
+	if containsString(scopes, models.ScopeProfile) {
+		claims.Name = grant.user.Name
+	}
+	if containsString(scopes, models.ScopeEmail) {
+		verified := grant.user.EmailVerifiedAt != nil
+		claims.Email = grant.user.Email
+		claims.EmailVerified = &verified
+	}
+	return s.jwtManager.SignIDToken(claims)
+}
+
+func (s *OAuthServerService) activeUser(id uint) (*models.User, error) {
+	u, err := s.users.FindByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, oauthError(ErrOAuthInvalidGrant, "the user no longer exists")
+		}
+		return nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, oauthError(ErrOAuthInvalidGrant, "the user account is disabled")
+	}
+	return u, nil
+}
+
+func (s *OAuthServerService) revokeReusedFamily(rt *models.OAuthRefreshToken) error {
+	log.Printf("OAuth refresh token reuse detected for client %s and user %d, revoking family %s", rt.ClientID, rt.UserID, rt.FamilyID)
+	if err := s.tokens.RevokeFamily(rt.FamilyID); err != nil {
+		return err
+	}
+	return oauthError(ErrOAuthInvalidGrant, "refresh token reuse detected")
+}
+
+func (s *OAuthServerService) introspectAccessToken(token string) (*TokenIntrospection, error) {
+	claims, err := s.jwtManager.Verify(token)
+	if err != nil {
+		return &TokenIntrospection{Active: false}, nil
+	}
+	revoked, err := s.denylist.Contains(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return &TokenIntrospection{Active: false}, nil
+	}
+
+	// Login access tokens end with their session, as in AuthMiddleware
+	if claims.SessionID != "" {
+		userID, err := claims.UserID()
+		if err != nil {
+			return &TokenIntrospection{Active: false}, nil
+		}
+		if _, err := s.sessions.Get(userID, claims.SessionID); err != nil {
+			if errors.Is(err, repositories.ErrSessionNotFound) {
+				return &TokenIntrospection{Active: false}, nil
+			}
+			return nil, err
+		}
+	}
+
+	info := &TokenIntrospection{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		TokenType: "Bearer",
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		TokenID:   claims.ID,
+	}
+	if claims.IssuedAt != nil {
+		info.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return info, nil
+}
+
+// introspectRefreshToken describes a refresh token of the client. It
+// reports false when the token is not one.
+func (s *OAuthServerService) introspectRefreshToken(client *models.OAuthClient, token string) (*TokenIntrospection, bool) {
+	rt, err := s.tokens.FindByHash(utils.HashToken(token))
+	if err != nil || rt.ClientID != client.ClientID {
+		return nil, false
+	}
+	if rt.RevokedAt != nil || time.Now().After(rt.ExpiresAt) {
+		return &TokenIntrospection{Active: false}, true
+	}
+	return &TokenIntrospection{
+		Active:    true,
+		Scope:     strings.Join(rt.Scopes, " "),
+		ClientID:  rt.ClientID,
+		Subject:   strconv.FormatUint(uint64(rt.UserID), 10),
+		TokenType: "refresh_token",
+		ExpiresAt: rt.ExpiresAt.Unix(),
+		IssuedAt:  rt.CreatedAt.Unix(),
+	}, true
+}
+
+func isIdentityScope(scope string) bool {
+	return scope == models.ScopeOpenID || scope == models.ScopeProfile || scope == models.ScopeEmail
+}
+
+// redirectWith adds parameters and the state to a redirect URI
+func redirectWith(redirectURI string, params url.Values, state string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
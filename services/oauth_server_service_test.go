@@ -0,0 +1,546 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// oauthTestClients registers a confidential web app, a trusted public app
+// and a confidential service that uses client credentials
+func oauthTestClients() *MockOAuthClientRepository {
+	mockClients := &MockOAuthClientRepository{}
+	mockClients.On("FindByClientID", "wiki").Return(&models.OAuthClient{
+		ClientID:     "wiki",
+		SecretHash:   utils.HashToken("secret"),
+		Name:         "Wiki",
+		RedirectURIs: []string{"https://wiki.example.com/callback"},
+		Scopes:       []string{models.ScopeOpenID, models.ScopeProfile, models.ScopeEmail, models.ScopeProfileRead},
+		GrantTypes:   []string{models.GrantAuthorizationCode, models.GrantRefreshToken},
+	}, nil)
+	mockClients.On("FindByClientID", "spa").Return(&models.OAuthClient{
+		ClientID:     "spa",
+		Name:         "Dashboard",
+		RedirectURIs: []string{"http://localhost:5173/callback"},
+		Scopes:       []string{models.ScopeOpenID},
+		GrantTypes:   []string{models.GrantAuthorizationCode},
+		Trusted:      true,
+	}, nil)
+	mockClients.On("FindByClientID", "reports").Return(&models.OAuthClient{
+		ClientID:   "reports",
+		SecretHash: utils.HashToken("secret"),
+		Name:       "Reports",
+		Scopes:     []string{models.ScopeOpenID, models.ScopeProfileRead},
+		GrantTypes: []string{models.GrantClientCredentials},
+	}, nil)
+	mockClients.On("FindByClientID", mock.Anything).Return(nil, repositories.ErrOAuthClientNotFound)
+	return mockClients
+}
+
+func newTestOAuthServer(t *testing.T, consents *MockOAuthConsentRepository, tokens *MockOAuthTokenRepository, users *MockUserRepository) (*OAuthServerService, *utils.TokenManager) {
+	jwtManager := testTokenManager(t)
+	service := NewOAuthServerService(NewOAuthClientService(oauthTestClients()), consents, repositories.NewMemoryAuthorizationCodeStore(), tokens, users, repositories.NewMemorySessionStore(), repositories.NewMemoryTokenDenylist(), jwtManager, OAuthServerPolicy{
+		Issuer:           "https://auth.example.com",
+		AuthorizationURL: "https://app.example.com/oauth/authorize",
+		SigningAlgorithm: utils.AlgorithmEdDSA,
+		CodeTTL:          time.Minute,
+		AccessTTL:        15 * time.Minute,
+		RefreshTTL:       time.Hour,
+	})
+	return service, jwtManager
+}
+
+func wikiAuthorizationRequest() AuthorizationRequest {
+	return AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "wiki",
+		RedirectURI:         "https://wiki.example.com/callback",
+		Scope:               "openid email profile:read",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       utils.PKCEChallenge("verifier"),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestOAuthServerService_AuthorizationCodeFlow(t *testing.T) {
+	verifiedAt := time.Now()
+	user := &models.User{ID: 7, Name: "Jane", Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}
+
+	mockConsents := &MockOAuthConsentRepository{}
+	mockTokens := &MockOAuthTokenRepository{}
+	mockUsers := &MockUserRepository{}
+	mockConsents.On("Find", uint(7), "wiki").Return(nil, repositories.ErrConsentNotFound)
+	mockConsents.On("Save", mock.MatchedBy(func(c *models.OAuthConsent) bool {
+		return c.UserID == 7 && c.ClientID == "wiki" && len(c.Scopes) == 3
+	})).Return(nil)
+	mockUsers.On("FindByID", uint(7)).Return(user, nil)
+	mockTokens.On("Create", mock.MatchedBy(func(rt *models.OAuthRefreshToken) bool {
+		return rt.ClientID == "wiki" && rt.UserID == 7 && rt.FamilyID != ""
+	})).Return(nil)
+
+	service, jwtManager := newTestOAuthServer(t, mockConsents, mockTokens, mockUsers)
+	req := wikiAuthorizationRequest()
+
+	prompt, err := service.Authorize(7, req)
+	assert.NoError(t, err)
+	assert.True(t, prompt.ConsentRequired)
+	assert.Equal(t, []string{models.ScopeOpenID, models.ScopeEmail, models.ScopeProfileRead}, prompt.Scopes)
+
+	redirect, err := service.Approve(7, req, true)
+	assert.NoError(t, err)
+	u, err := url.Parse(redirect)
+	assert.NoError(t, err)
+	assert.Equal(t, "wiki.example.com", u.Host)
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	code := u.Query().Get("code")
+	assert.NotEmpty(t, code)
+
+	tokenReq := TokenRequest{
+		GrantType:    models.GrantAuthorizationCode,
+		ClientID:     "wiki",
+		ClientSecret: "secret",
+		Code:         code,
+		RedirectURI:  req.RedirectURI,
+		CodeVerifier: "verifier",
+	}
+	resp, err := service.Token(tokenReq)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.RefreshToken)
+	assert.Equal(t, "openid email profile:read", resp.Scope)
+
+	claims, err := jwtManager.Parse(resp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "wiki", claims.ClientID)
+	assert.Equal(t, "7", claims.Subject)
+
+	idClaims := &utils.IDClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(resp.IDToken, idClaims)
+	assert.NoError(t, err)
+	assert.Equal(t, "n-0S6", idClaims.Nonce)
+	assert.Equal(t, "jane@example.com", idClaims.Email)
+	assert.Equal(t, jwt.ClaimStrings{"wiki"}, idClaims.Audience)
+	assert.Empty(t, idClaims.ID)
+
+	// A code can only be redeemed once
+	_, err = service.Token(tokenReq)
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+
+	mockConsents.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+	mockUsers.AssertExpectations(t)
+}
+
+func TestOAuthServerService_Authorize(t *testing.T) {
+	tests := []struct {
+		name            string
+		modify          func(*AuthorizationRequest)
+		consent         *models.OAuthConsent
+		expectedErr     error
+		consentRequired bool
+	}{
+		{
+			name:            "consent required",
+			modify:          func(r *AuthorizationRequest) {},
+			consentRequired: true,
+		},
+		{
+			name:    "earlier consent covers the scopes",
+			modify:  func(r *AuthorizationRequest) {},
+			consent: &models.OAuthConsent{Scopes: []string{models.ScopeOpenID, models.ScopeEmail, models.ScopeProfileRead, models.ScopeProfile}},
+		},
+		{
+			name:            "earlier consent to fewer scopes",
+			modify:          func(r *AuthorizationRequest) {},
+			consent:         &models.OAuthConsent{Scopes: []string{models.ScopeOpenID}},
+			consentRequired: true,
+		},
+		{
+			name: "trusted client",
+			modify: func(r *AuthorizationRequest) {
+				r.ClientID = "spa"
+				r.RedirectURI = "http://localhost:5173/callback"
+				r.Scope = "openid"
+			},
+		},
+		{
+			name:        "unknown client",
+			modify:      func(r *AuthorizationRequest) { r.ClientID = "other" },
+			expectedErr: ErrInvalidAuthorizationRequest,
+		},
+		{
+			name:        "unregistered redirect URI",
+			modify:      func(r *AuthorizationRequest) { r.RedirectURI = "https://evil.example.com/callback" },
+			expectedErr: ErrInvalidAuthorizationRequest,
+		},
+		{
+			name:        "missing PKCE",
+			modify:      func(r *AuthorizationRequest) { r.CodeChallenge = "" },
+			expectedErr: ErrOAuthInvalidRequest,
+		},
+		{
+			name:        "plain PKCE",
+			modify:      func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" },
+			expectedErr: ErrOAuthInvalidRequest,
+		},
+		{
+			name:        "scope not allowed for the client",
+			modify:      func(r *AuthorizationRequest) { r.Scope = "openid profile:write" },
+			expectedErr: ErrOAuthInvalidScope,
+		},
+		{
+			name:        "client without the authorization code grant",
+			modify:      func(r *AuthorizationRequest) { r.ClientID = "reports"; r.RedirectURI = "" },
+			expectedErr: ErrInvalidAuthorizationRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConsents := &MockOAuthConsentRepository{}
+			if tt.consent != nil {
+				mockConsents.On("Find", uint(7), "wiki").Return(tt.consent, nil)
+			} else {
+				mockConsents.On("Find", uint(7), "wiki").Return(nil, repositories.ErrConsentNotFound)
+			}
+			service, _ := newTestOAuthServer(t, mockConsents, &MockOAuthTokenRepository{}, &MockUserRepository{})
+
+			req := wikiAuthorizationRequest()
+			tt.modify(&req)
+			prompt, err := service.Authorize(7, req)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.consentRequired, prompt.ConsentRequired)
+		})
+	}
+}
+
+func TestOAuthServerService_ApproveDenied(t *testing.T) {
+	service, _ := newTestOAuthServer(t, &MockOAuthConsentRepository{}, &MockOAuthTokenRepository{}, &MockUserRepository{})
+
+	redirect, err := service.Approve(7, wikiAuthorizationRequest(), false)
+
+	assert.NoError(t, err)
+	u, _ := url.Parse(redirect)
+	assert.Equal(t, "access_denied", u.Query().Get("error"))
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	assert.Empty(t, u.Query().Get("code"))
+}
+
+func TestOAuthServerService_TokenErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         TokenRequest
+		expectedErr error
+	}{
+		{
+			name:        "unsupported grant type",
+			req:         TokenRequest{GrantType: "password", ClientID: "wiki", ClientSecret: "secret"},
+			expectedErr: ErrOAuthUnsupportedGrantType,
+		},
+		{
+			name:        "wrong client secret",
+			req:         TokenRequest{GrantType: models.GrantAuthorizationCode, ClientID: "wiki", ClientSecret: "guess"},
+			expectedErr: ErrOAuthInvalidClient,
+		},
+		{
+			name:        "grant type not allowed for the client",
+			req:         TokenRequest{GrantType: models.GrantClientCredentials, ClientID: "wiki", ClientSecret: "secret"},
+			expectedErr: ErrOAuthUnauthorizedClient,
+		},
+		{
+			name:        "unknown code",
+			req:         TokenRequest{GrantType: models.GrantAuthorizationCode, ClientID: "wiki", ClientSecret: "secret", Code: "code", CodeVerifier: "verifier"},
+			expectedErr: ErrOAuthInvalidGrant,
+		},
+		{
+			name:        "missing code verifier",
+			req:         TokenRequest{GrantType: models.GrantAuthorizationCode, ClientID: "wiki", ClientSecret: "secret", Code: "code"},
+			expectedErr: ErrOAuthInvalidRequest,
+		},
+		{
+			name:        "identity scope with client credentials",
+			req:         TokenRequest{GrantType: models.GrantClientCredentials, ClientID: "reports", ClientSecret: "secret", Scope: "openid"},
+			expectedErr: ErrOAuthInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestOAuthServer(t, &MockOAuthConsentRepository{}, &MockOAuthTokenRepository{}, &MockUserRepository{})
+
+			_, err := service.Token(tt.req)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestOAuthServerService_WrongCodeVerifier(t *testing.T) {
+	mockConsents := &MockOAuthConsentRepository{}
+	mockConsents.On("Find", uint(7), "wiki").Return(nil, repositories.ErrConsentNotFound)
+	mockConsents.On("Save", mock.Anything).Return(nil)
+	service, _ := newTestOAuthServer(t, mockConsents, &MockOAuthTokenRepository{}, &MockUserRepository{})
+
+	redirect, err := service.Approve(7, wikiAuthorizationRequest(), true)
+	assert.NoError(t, err)
+	u, _ := url.Parse(redirect)
+
+	_, err = service.Token(TokenRequest{
+		GrantType:    models.GrantAuthorizationCode,
+		ClientID:     "wiki",
+		ClientSecret: "secret",
+		Code:         u.Query().Get("code"),
+		RedirectURI:  "https://wiki.example.com/callback",
+		CodeVerifier: "stolen-code-without-verifier",
+	})
+
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+}
+
+func TestOAuthServerService_ClientCredentials(t *testing.T) {
+	service, jwtManager := newTestOAuthServer(t, &MockOAuthConsentRepository{}, &MockOAuthTokenRepository{}, &MockUserRepository{})
+
+	resp, err := service.Token(TokenRequest{GrantType: models.GrantClientCredentials, ClientID: "reports", ClientSecret: "secret"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, resp.RefreshToken)
+	assert.Empty(t, resp.IDToken)
+	// openid is dropped from the default scopes
+	assert.Equal(t, models.ScopeProfileRead, resp.Scope)
+
+	// The token has no user, so it cannot be used as a login
+	_, err = jwtManager.Parse(resp.AccessToken)
+	assert.Error(t, err)
+	claims, err := jwtManager.Verify(resp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "reports", claims.Subject)
+}
+
+func TestOAuthServerService_Refresh(t *testing.T) {
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name        string
+		stored      *models.OAuthRefreshToken
+		scope       string
+		setupMock   func(*MockOAuthTokenRepository, *MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:   "token rotated",
+			stored: &models.OAuthRefreshToken{ID: 1, ClientID: "wiki", UserID: 7, FamilyID: "family", Scopes: []string{models.ScopeOpenID, models.ScopeProfileRead}, ExpiresAt: time.Now().Add(time.Hour)},
+			scope:  models.ScopeProfileRead,
+			setupMock: func(mockTokens *MockOAuthTokenRepository, mockUsers *MockUserRepository) {
+				mockTokens.On("Revoke", uint(1)).Return(true, nil)
+				mockUsers.On("FindByID", uint(7)).Return(&models.User{ID: 7}, nil)
+				mockTokens.On("Create", mock.MatchedBy(func(rt *models.OAuthRefreshToken) bool {
+					// The new token keeps every granted scope
+					return rt.FamilyID == "family" && len(rt.Scopes) == 2
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "revoked token reused",
+			stored: &models.OAuthRefreshToken{ID: 1, ClientID: "wiki", UserID: 7, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+			setupMock: func(mockTokens *MockOAuthTokenRepository, mockUsers *MockUserRepository) {
+				mockTokens.On("RevokeFamily", "family").Return(nil)
+			},
+			expectedErr: ErrOAuthInvalidGrant,
+		},
+		{
+			name:        "token of another client",
+			stored:      &models.OAuthRefreshToken{ID: 1, ClientID: "spa", UserID: 7, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)},
+			setupMock:   func(mockTokens *MockOAuthTokenRepository, mockUsers *MockUserRepository) {},
+			expectedErr: ErrOAuthInvalidGrant,
+		},
+		{
+			name:        "scope not granted",
+			stored:      &models.OAuthRefreshToken{ID: 1, ClientID: "wiki", UserID: 7, FamilyID: "family", Scopes: []string{models.ScopeOpenID}, ExpiresAt: time.Now().Add(time.Hour)},
+			scope:       models.ScopeProfileRead,
+			setupMock:   func(mockTokens *MockOAuthTokenRepository, mockUsers *MockUserRepository) {},
+			expectedErr: ErrOAuthInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := &MockOAuthTokenRepository{}
+			mockUsers := &MockUserRepository{}
+			mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(tt.stored, nil)
+			tt.setupMock(mockTokens, mockUsers)
+			service, _ := newTestOAuthServer(t, &MockOAuthConsentRepository{}, mockTokens, mockUsers)
+
+			resp, err := service.Token(TokenRequest{GrantType: models.GrantRefreshToken, ClientID: "wiki", ClientSecret: "secret", RefreshToken: "refresh", Scope: tt.scope})
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.scope, resp.Scope)
+				assert.Empty(t, resp.IDToken)
+			}
+			mockTokens.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOAuthServerService_IntrospectAndRevoke(t *testing.T) {
+	mockTokens := &MockOAuthTokenRepository{}
+	mockTokens.On("FindByHash", mock.Anything).Return(nil, repositories.ErrTokenNotFound)
+	service, _ := newTestOAuthServer(t, &MockOAuthConsentRepository{}, mockTokens, &MockUserRepository{})
+
+	resp, err := service.Token(TokenRequest{GrantType: models.GrantClientCredentials, ClientID: "reports", ClientSecret: "secret"})
+	assert.NoError(t, err)
+
+	info, err := service.Introspect("wiki", "secret", resp.AccessToken, "")
+	assert.NoError(t, err)
+	assert.True(t, info.Active)
+	assert.Equal(t, "reports", info.ClientID)
+
+	// Public clients may not introspect
+	_, err = service.Introspect("spa", "", resp.AccessToken, "")
+	assert.ErrorIs(t, err, ErrOAuthInvalidClient)
+
+	// Only the client the token was issued to can revoke it
+	assert.NoError(t, service.Revoke("wiki", "secret", resp.AccessToken, ""))
+	info, err = service.Introspect("wiki", "secret", resp.AccessToken, "")
+	assert.NoError(t, err)
+	assert.True(t, info.Active)
+
+	assert.NoError(t, service.Revoke("reports", "secret", resp.AccessToken, "access_token"))
+	info, err = service.Introspect("wiki", "secret", resp.AccessToken, "")
+	assert.NoError(t, err)
+	assert.Equal(t, &TokenIntrospection{Active: false}, info)
+
+	// Unknown tokens are not an error
+	assert.NoError(t, service.Revoke("reports", "secret", "unknown", ""))
+}
+
+func TestOAuthServerService_IntrospectLoginToken(t *testing.T) {
+	sessions := repositories.NewMemorySessionStore()
+	jwtManager := testTokenManager(t)
+	oauthTokens := &MockOAuthTokenRepository{}
+	oauthTokens.On("FindByHash", mock.Anything).Return(nil, repositories.ErrTokenNotFound)
+	service := NewOAuthServerService(NewOAuthClientService(oauthTestClients()), &MockOAuthConsentRepository{}, repositories.NewMemoryAuthorizationCodeStore(), oauthTokens, &MockUserRepository{}, sessions, repositories.NewMemoryTokenDenylist(), jwtManager, OAuthServerPolicy{AccessTTL: 15 * time.Minute})
+	mockTokens := &MockTokenRepository{}
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	tokens := NewTokenService(&MockUserRepository{}, mockTokens, sessions, repositories.NewMemoryTokenDenylist(), jwtManager, 15, 720)
+
+	pair, err := tokens.IssueTokens(&models.User{ID: 7}, []string{models.AMRPassword}, "203.0.113.7", "test-agent")
+	assert.NoError(t, err)
+	info, err := service.Introspect("wiki", "secret", pair.AccessToken, "")
+	assert.NoError(t, err)
+	assert.True(t, info.Active)
+
+	// Once its session is revoked the token is no longer active
+	active, _ := sessions.List(7)
+	assert.NoError(t, sessions.Delete(7, active[0].ID))
+	info, err = service.Introspect("wiki", "secret", pair.AccessToken, "")
+	assert.NoError(t, err)
+	assert.Equal(t, &TokenIntrospection{Active: false}, info)
+}
+
+func TestOAuthServerService_RevokeConsent(t *testing.T) {
+	mockConsents := &MockOAuthConsentRepository{}
+	mockTokens := &MockOAuthTokenRepository{}
+	mockConsents.On("Delete", uint(7), "wiki").Return(nil)
+	mockConsents.On("Delete", uint(7), "spa").Return(repositories.ErrConsentNotFound)
+	mockTokens.On("RevokeForClient", uint(7), "wiki").Return(nil)
+	service, _ := newTestOAuthServer(t, mockConsents, mockTokens, &MockUserRepository{})
+
+	assert.NoError(t, service.RevokeConsent(7, "wiki"))
+	assert.ErrorIs(t, service.RevokeConsent(7, "spa"), ErrConsentNotFound)
+	mockConsents.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+}
+
+// MockOAuthConsentRepository is a mock implementation of OAuthConsentRepository interface
+type MockOAuthConsentRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOAuthConsentRepository implements OAuthConsentRepository interface
+var _ repositories.OAuthConsentRepository = (*MockOAuthConsentRepository)(nil)
+
+func (m *MockOAuthConsentRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockOAuthConsentRepository) Find(userID uint, clientID string) (*models.OAuthConsent, error) {
+	args := m.Called(userID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthConsent), args.Error(1)
+}
+
+func (m *MockOAuthConsentRepository) Save(c *models.OAuthConsent) error {
+	args := m.Called(c)
+	return args.Error(0)
+}
+
+func (m *MockOAuthConsentRepository) ListForUser(userID uint) ([]models.OAuthConsent, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.OAuthConsent), args.Error(1)
+}
+
+func (m *MockOAuthConsentRepository) Delete(userID uint, clientID string) error {
+	args := m.Called(userID, clientID)
+	return args.Error(0)
+}
+
+// MockOAuthTokenRepository is a mock implementation of OAuthTokenRepository interface
+type MockOAuthTokenRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOAuthTokenRepository implements OAuthTokenRepository interface
+var _ repositories.OAuthTokenRepository = (*MockOAuthTokenRepository)(nil)
+
+func (m *MockOAuthTokenRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockOAuthTokenRepository) Create(t *models.OAuthRefreshToken) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *MockOAuthTokenRepository) FindByHash(hash string) (*models.OAuthRefreshToken, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthRefreshToken), args.Error(1)
+}
+
+func (m *MockOAuthTokenRepository) Revoke(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOAuthTokenRepository) RevokeFamily(familyID string) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthTokenRepository) RevokeForClient(userID uint, clientID string) error {
+	args := m.Called(userID, clientID)
+	return args.Error(0)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"temp/models"
+	"temp/repositories"
+)
+
+var (
+	ErrInvalidOrganization = errors.New("invalid organization")
+	ErrInvalidOrgRole      = errors.New("unknown organization role")
+	ErrOrgOwnerRequired    = errors.New("only owners can grant or change the owner role")
+)
+
+// OrganizationService manages organizations and their members. Member
+// lookups go through the user repository of the organization, so they
+// never return users of another tenant; users only looks up the caller's
+// own account.
+type OrganizationService struct {
+	orgs    repositories.OrganizationRepository
+	members func(orgID uint) repositories.UserRepository
+	users   repositories.UserRepository
+	tokens  TokenServiceInterface
+}
+
+// Ensure OrganizationService implements OrganizationServiceInterface interface
+var _ OrganizationServiceInterface = (*OrganizationService)(nil)
+
+func NewOrganizationService(orgs repositories.OrganizationRepository, members func(orgID uint) repositories.UserRepository, users repositories.UserRepository, tokens TokenServiceInterface) *OrganizationService {
+	return &OrganizationService{orgs: orgs, members: members, users: users, tokens: tokens}
+}
+
+// Create adds an organization owned by the user
+func (s *OrganizationService) Create(userID uint, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w: name must be 1 to 100 characters", ErrInvalidOrganization)
+	}
+	// The name ends up in email headers
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return nil, fmt.Errorf("%w: name must not contain control characters", ErrInvalidOrganization)
+	}
+	o := &models.Organization{Name: name}
+	if err := s.orgs.Create(o, userID); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ListForUser returns the user's memberships with their organizations
+func (s *OrganizationService) ListForUser(userID uint) ([]models.Membership, error) {
+	return s.orgs.ListForUser(userID)
+}
+
+// Switch makes a session act in another of the user's organizations
+func (s *OrganizationService) Switch(userID uint, sessionID string, orgID uint) (*TokenPair, error) {
+	u, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tokens.SwitchOrganization(u, sessionID, orgID)
+}
+
+// Members returns a page of the members of an organization, ordered by ID,
+// and the number of members
+func (s *OrganizationService) Members(orgID uint, limit, offset int) ([]models.User, int64, error) {
+	if limit <= 0 {
+		limit = DefaultUserPageSize
+	}
+	if limit > MaxUserPageSize {
+		limit = MaxUserPageSize
+	}
+	return s.members(orgID).List(repositories.UserQuery{Limit: limit, Offset: offset})
+}
+
+// Member returns a member of an organization. Users of other organizations
+// are not found.
+func (s *OrganizationService) Member(orgID, userID uint) (*models.User, error) {
+	return s.members(orgID).FindByID(userID)
+}
+
+// UpdateMemberRole changes the role of a member. Only owners may make
+// someone an owner or change the role of an owner.
+func (s *OrganizationService) UpdateMemberRole(orgID uint, actorRole string, userID uint, role string) error {
+	if !models.IsOrgRole(role) {
+		return ErrInvalidOrgRole
+	}
+	m, err := s.orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if actorRole != models.OrgRoleOwner && (role == models.OrgRoleOwner || m.Role == models.OrgRoleOwner) {
+		return ErrOrgOwnerRequired
+	}
+	return s.orgs.UpdateMemberRole(orgID, userID, role)
+}
+
+// RemoveMember removes a user from an organization. Only owners may remove
+// owners, and the last owner cannot be removed.
+func (s *OrganizationService) RemoveMember(orgID uint, actorRole string, userID uint) error {
+	m, err := s.orgs.FindMembership(orgID, userID)
+	if err != nil {
+		return err
+	}
+	if actorRole != models.OrgRoleOwner && m.Role == models.OrgRoleOwner {
+		return ErrOrgOwnerRequired
+	}
+	return s.orgs.RemoveMember(orgID, userID)
+}
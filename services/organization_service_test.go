@@ -0,0 +1,217 @@
+package services
+
+import (
+	"testing"
+
+	"temp/models"
+	"temp/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOrganizationService_Create(t *testing.T) {
+	tests := []struct {
+		name          string
+		orgName       string
+		expectedName  string
+		expectedError error
+	}{
+		{name: "valid name", orgName: "  Acme  ", expectedName: "Acme"},
+		{name: "blank name", orgName: "   ", expectedError: ErrInvalidOrganization},
+		{name: "too long name", orgName: string(make([]byte, 101)), expectedError: ErrInvalidOrganization},
+		{name: "line break in name", orgName: "Acme\r\nBcc: victim@example.com", expectedError: ErrInvalidOrganization},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrgs := &MockOrganizationRepository{}
+			if tt.expectedError == nil {
+				mockOrgs.On("Create", mock.MatchedBy(func(o *models.Organization) bool {
+					return o.Name == tt.expectedName
+				}), uint(7)).Return(nil)
+			}
+			service := NewOrganizationService(mockOrgs, noMembers, &MockUserRepository{}, &MockTokenService{})
+
+			o, err := service.Create(7, tt.orgName)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockOrgs.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedName, o.Name)
+			mockOrgs.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationService_Switch(t *testing.T) {
+	u := &models.User{ID: 7, Memberships: []models.Membership{{OrganizationID: 3, UserID: 7, Role: models.OrgRoleMember}}}
+	mockUsers := &MockUserRepository{}
+	mockUsers.On("FindByID", uint(7)).Return(u, nil)
+	mockTokens := &MockTokenService{}
+	pair := &TokenPair{AccessToken: "access"}
+	mockTokens.On("SwitchOrganization", u, "sid", uint(3)).Return(pair, nil)
+	service := NewOrganizationService(&MockOrganizationRepository{}, noMembers, mockUsers, mockTokens)
+
+	result, err := service.Switch(7, "sid", 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, pair, result)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestOrganizationService_MembersAreScoped(t *testing.T) {
+	scoped := &MockUserRepository{}
+	scoped.On("List", repositories.UserQuery{Limit: DefaultUserPageSize}).Return([]models.User{{ID: 7}}, int64(1), nil)
+	scoped.On("FindByID", uint(8)).Return(nil, repositories.ErrUserNotFound)
+	mockUsers := &MockUserRepository{}
+	members := func(orgID uint) repositories.UserRepository {
+		assert.Equal(t, uint(3), orgID)
+		return scoped
+	}
+	service := NewOrganizationService(&MockOrganizationRepository{}, members, mockUsers, &MockTokenService{})
+
+	users, total, err := service.Members(3, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, users, 1)
+
+	_, err = service.Member(3, 8)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	mockUsers.AssertNotCalled(t, "List", mock.Anything)
+	mockUsers.AssertNotCalled(t, "FindByID", mock.Anything)
+	scoped.AssertExpectations(t)
+}
+
+func TestOrganizationService_UpdateMemberRole(t *testing.T) {
+	tests := []struct {
+		name          string
+		actorRole     string
+		currentRole   string
+		role          string
+		expectedError error
+	}{
+		{name: "admin promotes member", actorRole: models.OrgRoleAdmin, currentRole: models.OrgRoleMember, role: models.OrgRoleAdmin},
+		{name: "owner grants owner", actorRole: models.OrgRoleOwner, currentRole: models.OrgRoleAdmin, role: models.OrgRoleOwner},
+		{name: "admin grants owner", actorRole: models.OrgRoleAdmin, currentRole: models.OrgRoleAdmin, role: models.OrgRoleOwner, expectedError: ErrOrgOwnerRequired},
+		{name: "admin demotes owner", actorRole: models.OrgRoleAdmin, currentRole: models.OrgRoleOwner, role: models.OrgRoleMember, expectedError: ErrOrgOwnerRequired},
+		{name: "unknown role", actorRole: models.OrgRoleOwner, currentRole: models.OrgRoleMember, role: "superuser", expectedError: ErrInvalidOrgRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOrgs := &MockOrganizationRepository{}
+			mockOrgs.On("FindMembership", uint(3), uint(8)).Return(&models.Membership{OrganizationID: 3, UserID: 8, Role: tt.currentRole}, nil)
+			if tt.expectedError == nil {
+				mockOrgs.On("UpdateMemberRole", uint(3), uint(8), tt.role).Return(nil)
+			}
+			service := NewOrganizationService(mockOrgs, noMembers, &MockUserRepository{}, &MockTokenService{})
+
+			err := service.UpdateMemberRole(3, tt.actorRole, 8, tt.role)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				mockOrgs.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			mockOrgs.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+	t.Run("admin cannot remove owner", func(t *testing.T) {
+		mockOrgs := &MockOrganizationRepository{}
+		mockOrgs.On("FindMembership", uint(3), uint(8)).Return(&models.Membership{Role: models.OrgRoleOwner}, nil)
+		service := NewOrganizationService(mockOrgs, noMembers, &MockUserRepository{}, &MockTokenService{})
+
+		err := service.RemoveMember(3, models.OrgRoleAdmin, 8)
+
+		assert.ErrorIs(t, err, ErrOrgOwnerRequired)
+		mockOrgs.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("last owner", func(t *testing.T) {
+		mockOrgs := &MockOrganizationRepository{}
+		mockOrgs.On("FindMembership", uint(3), uint(7)).Return(&models.Membership{Role: models.OrgRoleOwner}, nil)
+		mockOrgs.On("RemoveMember", uint(3), uint(7)).Return(repositories.ErrLastOwner)
+		service := NewOrganizationService(mockOrgs, noMembers, &MockUserRepository{}, &MockTokenService{})
+
+		err := service.RemoveMember(3, models.OrgRoleOwner, 7)
+
+		assert.ErrorIs(t, err, repositories.ErrLastOwner)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mockOrgs := &MockOrganizationRepository{}
+		mockOrgs.On("FindMembership", uint(3), uint(9)).Return(nil, repositories.ErrMembershipNotFound)
+		service := NewOrganizationService(mockOrgs, noMembers, &MockUserRepository{}, &MockTokenService{})
+
+		err := service.RemoveMember(3, models.OrgRoleOwner, 9)
+
+		assert.ErrorIs(t, err, repositories.ErrMembershipNotFound)
+	})
+}
+
+// MockOrganizationRepository is a mock implementation of OrganizationRepository
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOrganizationRepository implements OrganizationRepository interface
+var _ repositories.OrganizationRepository = (*MockOrganizationRepository)(nil)
+
+func (m *MockOrganizationRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) Create(o *models.Organization, ownerID uint) error {
+	args := m.Called(o, ownerID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) FindByID(id uint) (*models.Organization, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) ListForUser(userID uint) ([]models.Membership, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) FindMembership(orgID, userID uint) (*models.Membership, error) {
+	args := m.Called(orgID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) UpdateMemberRole(orgID, userID uint, role string) error {
+	args := m.Called(orgID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) RemoveMember(orgID, userID uint) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+// noMembers stands in for the tenant user repos in tests that do not look
+// up members
+func noMembers(orgID uint) repositories.UserRepository {
+	return &MockUserRepository{}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var ErrPasswordPolicy = errors.New("password does not meet the password policy")
+
+// Password policy rules reported in PasswordViolation.Rule
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBreached     = "breached"
+	PasswordRuleReused       = "reused"
+)
+
+// PasswordViolation is a rule of the password policy a password breaks
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password breaks, so the client
+// can show them all at once
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return ErrPasswordPolicy.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrPasswordPolicy
+}
+
+// PasswordPolicy holds the rules new passwords have to follow
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// History refuses the last History passwords of the user, 0 disables
+	History int
+	// RejectPersonalInfo refuses passwords containing the user's name or
+	// the local part of their email
+	RejectPersonalInfo bool
+}
+
+// PasswordPolicyService checks new passwords against the configured policy,
+// a breached-password list and the user's password history
+type PasswordPolicyService struct {
+	history  repositories.PasswordHistoryRepository
+	policy   PasswordPolicy
+	breached *utils.BloomFilter
+}
+
+// Ensure PasswordPolicyService implements PasswordPolicyServiceInterface interface
+var _ PasswordPolicyServiceInterface = (*PasswordPolicyService)(nil)
+
+// NewPasswordPolicyService creates the policy service. breached may be nil
+// when no breached-password list is configured.
+func NewPasswordPolicyService(history repositories.PasswordHistoryRepository, policy PasswordPolicy, breached *utils.BloomFilter) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		history:  history,
+		policy:   policy,
+		breached: breached,
+	}
+}
+
+// Check validates a new password of u and returns a *PasswordPolicyError
+// listing every broken rule. u may not be stored yet, in which case its
+// history is not looked at.
+func (s *PasswordPolicyService) Check(u *models.User, password string) error {
+	var violations []PasswordViolation
+	violate := func(rule, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := len([]rune(password))
+	if length < s.policy.MinLength {
+		violate(PasswordRuleMinLength, "must be at least %d characters long", s.policy.MinLength)
+	}
+	if s.policy.MaxLength > 0 && length > s.policy.MaxLength {
+		violate(PasswordRuleMaxLength, "must be at most %d characters long", s.policy.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if s.policy.RequireUpper && !upper {
+		violate(PasswordRuleUppercase, "must contain an uppercase letter")
+	}
+	if s.policy.RequireLower && !lower {
+		violate(PasswordRuleLowercase, "must contain a lowercase letter")
+	}
+	if s.policy.RequireDigit && !digit {
+		violate(PasswordRuleDigit, "must contain a digit")
+	}
+	if s.policy.RequireSymbol && !symbol {
+		violate(PasswordRuleSymbol, "must contain a symbol")
+	}
+
+	if s.policy.RejectPersonalInfo && containsPersonalInfo(u, password) {
+		violate(PasswordRulePersonalInfo, "must not contain your name or email")
+	}
+
+	if s.breached != nil && s.breached.Contains(password) {
+		violate(PasswordRuleBreached, "has appeared in a data breach")
+	}
+
+	// Comparing hashes is slow, so the history is only checked for
+	// passwords that pass every other rule
+	if len(violations) == 0 && s.policy.History > 0 && u.ID != 0 {
+		reused, err := s.reused(u, password)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violate(PasswordRuleReused, "must not be one of your last %d passwords", s.policy.History)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Remember records the current password hash of u in its history
+func (s *PasswordPolicyService) Remember(u *models.User) error {
+	if s.policy.History <= 0 {
+		return nil
+	}
+	return s.history.Add(u.ID, u.Password, s.policy.History)
+}
+
+func (s *PasswordPolicyService) reused(u *models.User, password string) (bool, error) {
+	hashes, err := s.history.Recent(u.ID, s.policy.History)
+	if err != nil {
+		return false, err
+	}
+	// Users created before the history existed only have their current hash
+	if u.Password != "" {
+		hashes = append(hashes, u.Password)
+	}
+	for _, hash := range hashes {
+		if utils.CompareHash(password, hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsPersonalInfo reports whether the password contains a part of the
+// user's name or email of at least three characters, ignoring case
+func containsPersonalInfo(u *models.User, password string) bool {
+	password = strings.ToLower(password)
+	var parts []string
+	parts = append(parts, strings.Fields(u.Name)...)
+	parts = append(parts, strings.Fields(u.DisplayName)...)
+	if local, _, ok := strings.Cut(u.Email, "@"); ok {
+		parts = append(parts, local)
+	}
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if len([]rune(part)) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:          8,
+		MaxLength:          72,
+		RequireUpper:       true,
+		RequireLower:       true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		History:            3,
+		RejectPersonalInfo: true,
+	}
+}
+
+func TestPasswordPolicyService_Check(t *testing.T) {
+	breached := utils.NewBloomFilter(10, 0.001)
+	breached.Add("P@ssw0rd!")
+	newUser := &models.User{Name: "Jane Doe", Email: "jdoe@example.com"}
+
+	tests := []struct {
+		name          string
+		password      string
+		expectedRules []string
+	}{
+		{name: "strong password", password: "Tr0ub4dor&3x"},
+		{name: "too short", password: "Ab1!", expectedRules: []string{PasswordRuleMinLength}},
+		{name: "missing character classes", password: "lowercaseonly", expectedRules: []string{PasswordRuleUppercase, PasswordRuleDigit, PasswordRuleSymbol}},
+		{name: "contains the name", password: "Jane-2024!x", expectedRules: []string{PasswordRulePersonalInfo}},
+		{name: "contains the email", password: "x!JDOE-2024", expectedRules: []string{PasswordRulePersonalInfo}},
+		{name: "breached", password: "P@ssw0rd!", expectedRules: []string{PasswordRuleBreached}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewPasswordPolicyService(&MockPasswordHistoryRepository{}, testPasswordPolicy(), breached)
+			err := service.Check(newUser, tt.password)
+
+			if tt.expectedRules == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var policyErr *PasswordPolicyError
+			assert.True(t, errors.As(err, &policyErr))
+			assert.ErrorIs(t, err, ErrPasswordPolicy)
+			var rules []string
+			for _, v := range policyErr.Violations {
+				rules = append(rules, v.Rule)
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tt.expectedRules, rules)
+		})
+	}
+}
+
+func TestPasswordPolicyService_History(t *testing.T) {
+	oldHash, _ := utils.GenerateHash("Old-Passw0rd")
+	currentHash, _ := utils.GenerateHash("Current-Passw0rd")
+	user := &models.User{ID: 1, Name: "Jane Doe", Email: "jdoe@example.com", Password: currentHash}
+
+	mockHistory := &MockPasswordHistoryRepository{}
+	mockHistory.On("Recent", uint(1), 3).Return([]string{currentHash, oldHash}, nil)
+	service := NewPasswordPolicyService(mockHistory, testPasswordPolicy(), nil)
+
+	for _, password := range []string{"Old-Passw0rd", "Current-Passw0rd"} {
+		err := service.Check(user, password)
+		var policyErr *PasswordPolicyError
+		assert.True(t, errors.As(err, &policyErr))
+		assert.Equal(t, PasswordRuleReused, policyErr.Violations[0].Rule)
+	}
+	assert.NoError(t, service.Check(user, "Brand-New-Passw0rd"))
+
+	mockHistory.On("Add", uint(1), currentHash, 3).Return(nil)
+	assert.NoError(t, service.Remember(user))
+	mockHistory.AssertExpectations(t)
+}
+
+// MockPasswordHistoryRepository is a mock implementation of PasswordHistoryRepository interface
+type MockPasswordHistoryRepository struct {
+	mock.Mock
+}
+
+// Ensure MockPasswordHistoryRepository implements PasswordHistoryRepository interface
+var _ repositories.PasswordHistoryRepository = (*MockPasswordHistoryRepository)(nil)
+
+func (m *MockPasswordHistoryRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) Add(userID uint, hash string, keep int) error {
+	args := m.Called(userID, hash, keep)
+	return args.Error(0)
+}
+
+func (m *MockPasswordHistoryRepository) Recent(userID uint, limit int) ([]string, error) {
+	args := m.Called(userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockPasswordPolicyService is a mock implementation of PasswordPolicyServiceInterface
+type MockPasswordPolicyService struct {
+	mock.Mock
+}
+
+// Ensure MockPasswordPolicyService implements PasswordPolicyServiceInterface interface
+var _ PasswordPolicyServiceInterface = (*MockPasswordPolicyService)(nil)
+
+func (m *MockPasswordPolicyService) Check(u *models.User, password string) error {
+	args := m.Called(u, password)
+	return args.Error(0)
+}
+
+func (m *MockPasswordPolicyService) Remember(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// PasswordResetService lets users who forgot their password choose a new
+// one through a single-use link sent to their email address
+type PasswordResetService struct {
+	users     repositories.UserRepository
+	tokens    repositories.OneTimeTokenRepository
+	sessions  TokenServiceInterface
+	mailer    EmailServiceInterface
+	passwords PasswordPolicyServiceInterface
+	ttl       time.Duration
+}
+
+// Ensure PasswordResetService implements PasswordResetServiceInterface interface
+var _ PasswordResetServiceInterface = (*PasswordResetService)(nil)
+
+func NewPasswordResetService(users repositories.UserRepository, tokens repositories.OneTimeTokenRepository, sessions TokenServiceInterface, mailer EmailServiceInterface, passwords PasswordPolicyServiceInterface, ttlMinutes int) *PasswordResetService {
+	return &PasswordResetService{
+		users:     users,
+		tokens:    tokens,
+		sessions:  sessions,
+		mailer:    mailer,
+		passwords: passwords,
+		ttl:       time.Duration(ttlMinutes) * time.Minute,
+	}
+}
+
+// RequestReset mails a reset link to the user. Unknown addresses are
+// silently ignored so callers cannot tell which emails are registered.
+func (s *PasswordResetService) RequestReset(email string) error {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Only the most recent link works
+	if err := s.tokens.ConsumeAllForUser(u.ID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	t := &models.OneTimeToken{
+		UserID:    u.ID,
+		Purpose:   models.TokenPurposePasswordReset,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.tokens.Create(t); err != nil {
+		return err
+	}
+
+	return s.mailer.SendPasswordResetEmail(u.Email, raw)
+}
+
+// Reset redeems a reset token, stores the new password and revokes every
+// session of the user so a stolen login cannot outlive the reset
+func (s *PasswordResetService) Reset(token, newPassword string) error {
+	t, err := s.tokens.FindByHash(utils.HashToken(token), models.TokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	u, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	// The token stays valid when the password is refused, so the user can
+	// try another one
+	if err := s.passwords.Check(u, newPassword); err != nil {
+		return err
+	}
+
+	consumed, err := s.tokens.Consume(t.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := utils.GenerateHash(newPassword)
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	u.PasswordResetRequired = false
+	// Following the emailed link proves the user owns the address
+	if u.EmailVerifiedAt == nil {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	if err := s.users.Update(u); err != nil {
+		return err
+	}
+	if err := s.passwords.Remember(u); err != nil {
+		log.Printf("Failed to record password history: %v", err)
+	}
+
+	return s.sessions.RevokeAll(u.ID)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	t.Run("registered email gets a reset link", func(t *testing.T) {
+		mockUsers := &MockUserRepository{}
+		mockTokens := &MockOneTimeTokenRepository{}
+		mockMailer := &MockEmailService{}
+		user := &models.User{ID: 1, Email: "test@example.com"}
+
+		var stored *models.OneTimeToken
+		var mailed string
+		mockUsers.On("FindByEmail", "test@example.com").Return(user, nil)
+		mockTokens.On("ConsumeAllForUser", uint(1), models.TokenPurposePasswordReset).Return(nil)
+		mockTokens.On("Create", mock.AnythingOfType("*models.OneTimeToken")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*models.OneTimeToken)
+		}).Return(nil)
+		mockMailer.On("SendPasswordResetEmail", "test@example.com", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			mailed = args.String(1)
+		}).Return(nil)
+
+		service := NewPasswordResetService(mockUsers, mockTokens, &MockTokenService{}, mockMailer, &MockPasswordPolicyService{}, 30)
+		err := service.RequestReset("test@example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, utils.HashToken(mailed), stored.TokenHash)
+		assert.Equal(t, models.TokenPurposePasswordReset, stored.Purpose)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, time.Minute)
+		mockTokens.AssertExpectations(t)
+		mockMailer.AssertExpectations(t)
+	})
+
+	t.Run("unknown email is ignored", func(t *testing.T) {
+		mockUsers := &MockUserRepository{}
+		mockMailer := &MockEmailService{}
+		mockUsers.On("FindByEmail", "nobody@example.com").Return(nil, repositories.ErrUserNotFound)
+
+		service := NewPasswordResetService(mockUsers, &MockOneTimeTokenRepository{}, &MockTokenService{}, mockMailer, &MockPasswordPolicyService{}, 30)
+		err := service.RequestReset("nobody@example.com")
+
+		assert.NoError(t, err)
+		mockMailer.AssertNotCalled(t, "SendPasswordResetEmail", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPasswordResetService_Reset(t *testing.T) {
+	hash := utils.HashToken("token")
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		setupMock   func(*MockUserRepository, *MockOneTimeTokenRepository, *MockTokenService)
+		policyErr   error
+		expectedErr error
+	}{
+		{
+			name: "successful reset revokes sessions",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository, mockSessions *MockTokenService) {
+				ot := &models.OneTimeToken{ID: 5, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposePasswordReset).Return(ot, nil)
+				mockTokens.On("Consume", uint(5)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(&models.User{ID: 1, Password: "old-hash"}, nil)
+				mockUsers.On("Update", mock.MatchedBy(func(u *models.User) bool {
+					return utils.CompareHash("new-password", u.Password)
+				})).Return(nil)
+				mockSessions.On("RevokeAll", uint(1)).Return(nil)
+			},
+		},
+		{
+			name: "password refused by the policy keeps the token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository, mockSessions *MockTokenService) {
+				ot := &models.OneTimeToken{ID: 5, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposePasswordReset).Return(ot, nil)
+				mockUsers.On("FindByID", uint(1)).Return(&models.User{ID: 1, Password: "old-hash"}, nil)
+			},
+			policyErr:   &PasswordPolicyError{Violations: []PasswordViolation{{Rule: PasswordRuleBreached}}},
+			expectedErr: ErrPasswordPolicy,
+		},
+		{
+			name: "unknown token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository, mockSessions *MockTokenService) {
+				mockTokens.On("FindByHash", hash, models.TokenPurposePasswordReset).Return(nil, repositories.ErrTokenNotFound)
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			name: "expired token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository, mockSessions *MockTokenService) {
+				ot := &models.OneTimeToken{ID: 5, UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposePasswordReset).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			name: "used token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository, mockSessions *MockTokenService) {
+				ot := &models.OneTimeToken{ID: 5, UserID: 1, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}
+				mockTokens.On("FindByHash", hash, models.TokenPurposePasswordReset).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockTokens := &MockOneTimeTokenRepository{}
+			mockSessions := &MockTokenService{}
+			tt.setupMock(mockUsers, mockTokens, mockSessions)
+			mockPasswords := &MockPasswordPolicyService{}
+			mockPasswords.On("Check", mock.AnythingOfType("*models.User"), "new-password").Return(tt.policyErr).Maybe()
+			mockPasswords.On("Remember", mock.AnythingOfType("*models.User")).Return(nil).Maybe()
+
+			service := NewPasswordResetService(mockUsers, mockTokens, mockSessions, &MockEmailService{}, mockPasswords, 30)
+			err := service.Reset("token", "new-password")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+			mockSessions.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+// RoleService manages roles and their assignment to users
+type RoleService struct {
+	roles repositories.RoleRepository
+	users repositories.UserRepository
+}
+
+// Ensure RoleService implements RoleServiceInterface interface
+var _ RoleServiceInterface = (*RoleService)(nil)
+
+func NewRoleService(roles repositories.RoleRepository, users repositories.UserRepository) *RoleService {
+	return &RoleService{roles: roles, users: users}
+}
+
+// EnsureDefaultRoles creates the built-in roles and their permissions
+func (s *RoleService) EnsureDefaultRoles() error {
+	for name, permissions := range models.DefaultRolePermissions {
+		if err := s.roles.EnsureRole(name, permissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssignRole grants a role to a user. The change shows up in the user's
+// access tokens once they are refreshed.
+func (s *RoleService) AssignRole(userID uint, role string) error {
+	return s.roles.AssignRole(userID, role)
+}
+
+// CreateAdmin grants the admin role to the user with the given email,
+// creating the user with a verified email address if it does not exist yet.
+// The password of an existing user is left unchanged.
+func (s *RoleService) CreateAdmin(name, email, password string) (*models.User, error) {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, err
+		}
+
+		hash, err := utils.GenerateHash(password)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		u = &models.User{
+			Name:            name,
+			Email:           email,
+			Password:        hash,
+			EmailVerifiedAt: &now,
+		}
+		if err := s.users.Create(u); err != nil {
+			return nil, err
+		}
+		if err := s.roles.AssignRole(u.ID, models.RoleUser); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.roles.AssignRole(u.ID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoleService_EnsureDefaultRoles(t *testing.T) {
+	mockRoles := &MockRoleRepository{}
+	for name, permissions := range models.DefaultRolePermissions {
+		mockRoles.On("EnsureRole", name, permissions).Return(nil)
+	}
+
+	service := NewRoleService(mockRoles, &MockUserRepository{})
+
+	assert.NoError(t, service.EnsureDefaultRoles())
+	mockRoles.AssertExpectations(t)
+}
+
+func TestRoleService_CreateAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupMock func(*MockUserRepository, *MockRoleRepository)
+	}{
+		{
+			name: "new user",
+			setupMock: func(mockUsers *MockUserRepository, mockRoles *MockRoleRepository) {
+				mockUsers.On("FindByEmail", "admin@example.com").Return(nil, repositories.ErrUserNotFound)
+				mockUsers.On("Create", mock.AnythingOfType("*models.User")).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.User).ID = 5
+				}).Return(nil)
+				mockRoles.On("AssignRole", uint(5), models.RoleUser).Return(nil)
+				mockRoles.On("AssignRole", uint(5), models.RoleAdmin).Return(nil)
+			},
+		},
+		{
+			name: "existing user is promoted",
+			setupMock: func(mockUsers *MockUserRepository, mockRoles *MockRoleRepository) {
+				existing := &models.User{ID: 5, Email: "admin@example.com", Password: "existing-hash"}
+				mockUsers.On("FindByEmail", "admin@example.com").Return(existing, nil)
+				mockRoles.On("AssignRole", uint(5), models.RoleAdmin).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockRoles := &MockRoleRepository{}
+			tt.setupMock(mockUsers, mockRoles)
+
+			service := NewRoleService(mockRoles, mockUsers)
+			u, err := service.CreateAdmin("Admin", "admin@example.com", "password123")
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint(5), u.ID)
+			if u.Password != "existing-hash" {
+				// New admins can log in right away
+				assert.True(t, utils.CompareHash("password123", u.Password))
+				assert.NotNil(t, u.EmailVerifiedAt)
+			}
+			mockUsers.AssertExpectations(t)
+			mockRoles.AssertExpectations(t)
+		})
+	}
+}
+
+// MockRoleRepository is a mock implementation of RoleRepository interface
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+// Ensure MockRoleRepository implements RoleRepository interface
+var _ repositories.RoleRepository = (*MockRoleRepository)(nil)
+
+func (m *MockRoleRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) EnsureRole(name string, permissions []string) error {
+	args := m.Called(name, permissions)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) FindByName(name string) (*models.Role, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) AssignRole(userID uint, name string) error {
+	args := m.Called(userID, name)
+	return args.Error(0)
+}
+
+// MockRoleService is a mock implementation of RoleServiceInterface interface
+type MockRoleService struct {
+	mock.Mock
+}
+
+// Ensure MockRoleService implements RoleServiceInterface interface
+var _ RoleServiceInterface = (*MockRoleService)(nil)
+
+func (m *MockRoleService) EnsureDefaultRoles() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRoleService) AssignRole(userID uint, role string) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) CreateAdmin(name, email, password string) (*models.User, error) {
+	args := m.Called(name, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+	ErrInvalidOAuthState       = errors.New("invalid or expired login attempt")
+	ErrIdentityEmailMissing    = errors.New("identity provider did not share an email address")
+	// ErrIdentityLinkRefused is returned when the email of an external
+	// identity belongs to an account it may not be linked to automatically
+	ErrIdentityLinkRefused = errors.New("an account with this email already exists; log in with it to link this provider")
+)
+
+// SocialLoginService logs users in through external identity providers.
+// Identities are linked to users by provider and subject; the first login
+// links an existing account by its verified email or creates a new one.
+type SocialLoginService struct {
+	providers  map[string]IdentityProvider
+	states     repositories.OAuthStateStore
+	identities repositories.LinkedIdentityRepository
+	users      repositories.UserRepository
+	roles      RoleServiceInterface
+	stateTTL   time.Duration
+}
+
+// Ensure SocialLoginService implements SocialLoginServiceInterface interface
+var _ SocialLoginServiceInterface = (*SocialLoginService)(nil)
+
+func NewSocialLoginService(providers map[string]IdentityProvider, states repositories.OAuthStateStore, identities repositories.LinkedIdentityRepository, users repositories.UserRepository, roles RoleServiceInterface, stateTTLMinutes int) *SocialLoginService {
+	return &SocialLoginService{
+		providers:  providers,
+		states:     states,
+		identities: identities,
+		users:      users,
+		roles:      roles,
+		stateTTL:   time.Duration(stateTTLMinutes) * time.Minute,
+	}
+}
+
+// Providers returns the names of the configured providers
+func (s *SocialLoginService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Begin starts a login at a provider and returns the URL to send the user
+// to. The state, nonce and PKCE verifier are kept until the provider
+// redirects back.
+func (s *SocialLoginService) Begin(provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownIdentityProvider
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthCodeURL(state, nonce, utils.PKCEChallenge(verifier))
+	if err != nil {
+		return "", err
+	}
+	if err := s.states.Save(state, &models.OAuthState{Provider: provider, Nonce: nonce, CodeVerifier: verifier}, s.stateTTL); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// Complete finishes a login with the code and state the provider
+// redirected back with and returns the user the identity belongs to
+func (s *SocialLoginService) Complete(provider, state, code string) (*models.User, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	pending, err := s.states.Take(state)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOAuthStateNotFound) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+	if pending.Provider != provider {
+		return nil, ErrInvalidOAuthState
+	}
+
+	identity, err := p.Identify(code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.identities.Find(provider, identity.Subject)
+	if err == nil {
+		if err := s.identities.TouchLastLogin(linked.ID, time.Now()); err != nil {
+			log.Printf("Failed to record identity login: %v", err)
+		}
+		return s.users.FindByID(linked.UserID)
+	}
+	if !errors.Is(err, repositories.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	u, err := s.userForIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.identities.Create(&models.LinkedIdentity{
+		UserID:      u.ID,
+		Provider:    provider,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// userForIdentity finds the account a new identity is linked to. Accounts
+// are only matched when both the provider and we have verified the email,
+// so nobody can take over an account by registering its email elsewhere.
+func (s *SocialLoginService) userForIdentity(identity *ExternalIdentity) (*models.User, error) {
+	if identity.Email == "" {
+		return nil, ErrIdentityEmailMissing
+	}
+
+	u, err := s.users.FindByEmail(identity.Email)
+	if err == nil {
+		if !identity.EmailVerified || u.EmailVerifiedAt == nil {
+			return nil, ErrIdentityLinkRefused
+		}
+		return u, nil
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	// Users created here have no password; they can set one through the
+	// password reset
+	u = &models.User{Name: name, Email: identity.Email}
+	if identity.EmailVerified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	if err := s.users.Create(u); err != nil {
+		return nil, err
+	}
+	if err := s.roles.AssignRole(u.ID, models.RoleUser); err != nil {
+		log.Printf("Failed to assign default role: %v", err)
+	}
+	return u, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSocialLoginService_Complete(t *testing.T) {
+	idp := testutils.NewMockOIDCProvider("client", "secret")
+	defer idp.Close()
+
+	verifiedAt := time.Now()
+	jane := testutils.MockOIDCUser{Subject: "jane-sub", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+
+	tests := []struct {
+		name        string
+		user        testutils.MockOIDCUser
+		setupMock   func(*MockUserRepository, *MockLinkedIdentityRepository, *MockRoleService)
+		expectedErr error
+	}{
+		{
+			name: "linked identity logs in",
+			user: jane,
+			setupMock: func(mockUsers *MockUserRepository, mockIdentities *MockLinkedIdentityRepository, mockRoles *MockRoleService) {
+				mockIdentities.On("Find", "mock", "jane-sub").Return(&models.LinkedIdentity{ID: 4, UserID: 7}, nil)
+				mockIdentities.On("TouchLastLogin", uint(4), mock.AnythingOfType("time.Time")).Return(nil)
+				mockUsers.On("FindByID", uint(7)).Return(&models.User{ID: 7}, nil)
+			},
+		},
+		{
+			name: "verified email links the existing account",
+			user: jane,
+			setupMock: func(mockUsers *MockUserRepository, mockIdentities *MockLinkedIdentityRepository, mockRoles *MockRoleService) {
+				mockIdentities.On("Find", "mock", "jane-sub").Return(nil, repositories.ErrIdentityNotFound)
+				mockUsers.On("FindByEmail", "jane@example.com").Return(&models.User{ID: 7, EmailVerifiedAt: &verifiedAt}, nil)
+				mockIdentities.On("Create", mock.MatchedBy(func(i *models.LinkedIdentity) bool {
+					return i.UserID == 7 && i.Provider == "mock" && i.Subject == "jane-sub"
+				})).Return(nil)
+			},
+		},
+		{
+			name: "unverified local account is not linked",
+			user: jane,
+			setupMock: func(mockUsers *MockUserRepository, mockIdentities *MockLinkedIdentityRepository, mockRoles *MockRoleService) {
+				mockIdentities.On("Find", "mock", "jane-sub").Return(nil, repositories.ErrIdentityNotFound)
+				mockUsers.On("FindByEmail", "jane@example.com").Return(&models.User{ID: 7}, nil)
+			},
+			expectedErr: ErrIdentityLinkRefused,
+		},
+		{
+			name: "unverified provider email is not linked",
+			user: testutils.MockOIDCUser{Subject: "jane-sub", Email: "jane@example.com", Name: "Jane"},
+			setupMock: func(mockUsers *MockUserRepository, mockIdentities *MockLinkedIdentityRepository, mockRoles *MockRoleService) {
+				mockIdentities.On("Find", "mock", "jane-sub").Return(nil, repositories.ErrIdentityNotFound)
+				mockUsers.On("FindByEmail", "jane@example.com").Return(&models.User{ID: 7, EmailVerifiedAt: &verifiedAt}, nil)
+			},
+			expectedErr: ErrIdentityLinkRefused,
+		},
+		{
+			name: "new user is created",
+			user: jane,
+			setupMock: func(mockUsers *MockUserRepository, mockIdentities *MockLinkedIdentityRepository, mockRoles *MockRoleService) {
+				mockIdentities.On("Find", "mock", "jane-sub").Return(nil, repositories.ErrIdentityNotFound)
+				mockUsers.On("FindByEmail", "jane@example.com").Return(nil, repositories.ErrUserNotFound)
+				mockUsers.On("Create", mock.MatchedBy(func(u *models.User) bool {
+					return u.Name == "Jane" && u.Password == "" && u.EmailVerifiedAt != nil
+				})).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.User).ID = 8
+				}).Return(nil)
+				mockRoles.On("AssignRole", uint(8), models.RoleUser).Return(nil)
+				mockIdentities.On("Create", mock.MatchedBy(func(i *models.LinkedIdentity) bool {
+					return i.UserID == 8 && i.Email == "jane@example.com"
+				})).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockIdentities := &MockLinkedIdentityRepository{}
+			mockRoles := &MockRoleService{}
+			tt.setupMock(mockUsers, mockIdentities, mockRoles)
+
+			provider := NewOAuthProvider(OAuthProviderConfig{
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURL:  "http://localhost:3000/auth/mock/callback",
+				Scopes:       []string{"openid", "email"},
+				Issuer:       idp.Issuer(),
+			})
+			service := NewSocialLoginService(map[string]IdentityProvider{"mock": provider}, repositories.NewMemoryOAuthStateStore(), mockIdentities, mockUsers, mockRoles, 10)
+
+			authURL, err := service.Begin("mock")
+			assert.NoError(t, err)
+			code, state, err := idp.Authorize(authURL, tt.user)
+			assert.NoError(t, err)
+
+			u, err := service.Complete("mock", state, code)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, u)
+			}
+			mockUsers.AssertExpectations(t)
+			mockIdentities.AssertExpectations(t)
+			mockRoles.AssertExpectations(t)
+
+			// The state cannot be used a second time
+			_, err = service.Complete("mock", state, code)
+			assert.ErrorIs(t, err, ErrInvalidOAuthState)
+		})
+	}
+}
+
+func TestSocialLoginService_UnknownProvider(t *testing.T) {
+	service := NewSocialLoginService(map[string]IdentityProvider{}, repositories.NewMemoryOAuthStateStore(), &MockLinkedIdentityRepository{}, &MockUserRepository{}, &MockRoleService{}, 10)
+
+	_, err := service.Begin("nope")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+	assert.Empty(t, service.Providers())
+}
+
+// MockLinkedIdentityRepository is a mock implementation of LinkedIdentityRepository interface
+type MockLinkedIdentityRepository struct {
+	mock.Mock
+}
+
+// Ensure MockLinkedIdentityRepository implements LinkedIdentityRepository interface
+var _ repositories.LinkedIdentityRepository = (*MockLinkedIdentityRepository)(nil)
+
+func (m *MockLinkedIdentityRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockLinkedIdentityRepository) Create(i *models.LinkedIdentity) error {
+	args := m.Called(i)
+	return args.Error(0)
+}
+
+func (m *MockLinkedIdentityRepository) Find(provider, subject string) (*models.LinkedIdentity, error) {
+	args := m.Called(provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LinkedIdentity), args.Error(1)
+}
+
+func (m *MockLinkedIdentityRepository) ListForUser(userID uint) ([]models.LinkedIdentity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.LinkedIdentity), args.Error(1)
+}
+
+func (m *MockLinkedIdentityRepository) TouchLastLogin(id uint, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+// MockSocialLoginService is a mock implementation of SocialLoginServiceInterface interface
+type MockSocialLoginService struct {
+	mock.Mock
+}
+
+// Ensure MockSocialLoginService implements SocialLoginServiceInterface interface
+var _ SocialLoginServiceInterface = (*MockSocialLoginService)(nil)
+
+func (m *MockSocialLoginService) Providers() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *MockSocialLoginService) Begin(provider string) (string, error) {
+	args := m.Called(provider)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSocialLoginService) Complete(provider, state, code string) (*models.User, error) {
+	args := m.Called(provider, state, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
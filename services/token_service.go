@@ -0,0 +1,436 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrNotOrgMember        = errors.New("not a member of this organization")
+)
+
+// sessionTouchInterval limits how often requests of a session update its
+// last use, so most requests only read the session
+const sessionTouchInterval = time.Minute
+
+// TokenPair holds the tokens returned to a client after login or refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	// Credentials of a new session for clients that use cookie sessions;
+	// empty after a refresh
+	SessionCookie string    `json:"-"`
+	CSRFToken     string    `json:"-"`
+	SessionExpiry time.Time `json:"-"`
+}
+
+// TokenService issues short-lived access tokens and rotating refresh tokens
+type TokenService struct {
+	users      repositories.UserRepository
+	repo       repositories.TokenRepository
+	sessions   repositories.SessionStore
+	denylist   repositories.TokenDenylist
+	jwtManager *utils.TokenManager
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// Ensure TokenService implements TokenServiceInterface interface
+var _ TokenServiceInterface = (*TokenService)(nil)
+
+func NewTokenService(users repositories.UserRepository, repo repositories.TokenRepository, sessions repositories.SessionStore, denylist repositories.TokenDenylist, jwtManager *utils.TokenManager, accessMinutes, refreshHours int) *TokenService {
+	return &TokenService{
+		users:      users,
+		repo:       repo,
+		sessions:   sessions,
+		denylist:   denylist,
+		jwtManager: jwtManager,
+		accessTTL:  time.Duration(accessMinutes) * time.Minute,
+		refreshTTL: time.Duration(refreshHours) * time.Hour,
+	}
+}
+
+// IssueTokens starts a new login session, and with it a new refresh token
+// family, for the user on the client with the given IP and user agent. amr
+// lists the methods the user authenticated with. The session can also be
+// used through the returned session cookie.
+func (s *TokenService) IssueTokens(u *models.User, amr []string, clientIP, userAgent string) (*TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	cookieSecret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:         familyID,
+		UserID:     u.ID,
+		Device:     utils.DeviceName(userAgent),
+		UserAgent:  userAgent,
+		IP:         clientIP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		CookieHash: utils.HashToken(cookieSecret),
+		CSRFHash:   utils.HashToken(csrfToken),
+		AuthTime:   now,
+		AMR:        amr,
+	}
+	pair, err := s.issue(u, session)
+	if err != nil {
+		return nil, err
+	}
+	pair.SessionCookie = fmt.Sprintf("%d.%s.%s", u.ID, familyID, cookieSecret)
+	pair.CSRFToken = csrfToken
+	pair.SessionExpiry = session.ExpiresAt
+	return pair, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that has already been
+// rotated or revoked revokes the whole family it belongs to.
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	rt, err := s.repo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if rt.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(rt)
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := s.repo.Revoke(rt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request rotated this token first
+		return nil, s.revokeReusedFamily(rt)
+	}
+
+	u, err := s.users.FindByID(rt.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessions.Get(u.ID, rt.FamilyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			// The session was revoked while its refresh token was in flight
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	session.LastSeenAt = time.Now()
+	return s.issue(u, session)
+}
+
+// Elevate records that the user of a session has just authenticated again
+// with the methods in amr and returns an access token whose auth_time shows
+// it. The refresh token of the session stays valid, and tokens it yields
+// carry the new auth_time as well.
+func (s *TokenService) Elevate(u *models.User, sessionID string, amr []string) (*TokenPair, error) {
+	return s.reissue(u, sessionID, func(session *models.Session, now time.Time) {
+		session.AuthTime = now
+		session.AMR = amr
+	})
+}
+
+// SwitchOrganization makes a session act in another of the user's
+// organizations and returns an access token for it. The session's refresh
+// token stays valid and yields tokens for the new organization.
+func (s *TokenService) SwitchOrganization(u *models.User, sessionID string, orgID uint) (*TokenPair, error) {
+	if u.Membership(orgID) == nil {
+		return nil, ErrNotOrgMember
+	}
+	return s.reissue(u, sessionID, func(session *models.Session, now time.Time) {
+		session.OrganizationID = orgID
+	})
+}
+
+// reissue applies update to a session and returns a new access token for
+// it without rotating its refresh token
+func (s *TokenService) reissue(u *models.User, sessionID string, update func(session *models.Session, now time.Time)) (*TokenPair, error) {
+	session, err := s.sessions.Get(u.ID, sessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	update(session, now)
+	session.LastSeenAt = now
+	access, err := s.signAccessToken(u, session, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Save(session); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// Revoke ends a single session: the presented access token is denylisted
+// until it expires and the session's refresh tokens are revoked
+func (s *TokenService) Revoke(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	if err := s.denylist.Add(tokenID, time.Until(s.jwtManager.AcceptedUntil(expiresAt))); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeFamily(sessionID); err != nil {
+		return err
+	}
+	return s.sessions.Delete(userID, sessionID)
+}
+
+// Sessions returns the active login sessions of a user, most recently
+// used first
+func (s *TokenService) Sessions(userID uint) ([]models.Session, error) {
+	sessions, err := s.sessions.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends one of a user's sessions, such as the login on a lost
+// device. Its access token is rejected from the next request on.
+func (s *TokenService) RevokeSession(userID uint, sessionID string) error {
+	session, err := s.sessions.Get(userID, sessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return s.Revoke(userID, session.ID, session.AccessTokenID, session.AccessExpiresAt)
+}
+
+// TouchSession reports whether the session an access token belongs to is
+// still active, and records the client IP and time of the request
+func (s *TokenService) TouchSession(userID uint, sessionID, clientIP string) (bool, error) {
+	session, err := s.sessions.Get(userID, sessionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return s.touch(session, clientIP)
+}
+
+// AuthenticateCookie resolves a session cookie to its session and user and
+// records the request like TouchSession. The session is nil when the cookie
+// is malformed or its session has ended.
+func (s *TokenService) AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error) {
+	parts := strings.SplitN(value, ".", 3)
+	if len(parts) != 3 {
+		return nil, nil, nil
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	session, err := s.sessions.Get(uint(userID), parts[1])
+	if err != nil {
+		if errors.Is(err, repositories.ErrSessionNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if session.CookieHash == "" || subtle.ConstantTimeCompare([]byte(utils.HashToken(parts[2])), []byte(session.CookieHash)) != 1 {
+		return nil, nil, nil
+	}
+
+	u, err := s.users.FindByID(session.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	if u.DisabledAt != nil {
+		return nil, nil, nil
+	}
+
+	active, err := s.touch(session, clientIP)
+	if err != nil || !active {
+		return nil, nil, err
+	}
+	return session, u, nil
+}
+
+// RevokeAll ends every session of a user
+func (s *TokenService) RevokeAll(userID uint) error {
+	sessions, err := s.sessions.List(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := s.denylist.Add(session.AccessTokenID, time.Until(s.jwtManager.AcceptedUntil(session.AccessExpiresAt))); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.sessions.DeleteAll(userID)
+}
+
+// issue signs a new token pair for a session and saves the session with
+// the new access token and expiry
+func (s *TokenService) issue(u *models.User, session *models.Session) (*TokenPair, error) {
+	now := time.Now()
+	access, err := s.signAccessToken(u, session, now)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := &models.RefreshToken{
+		UserID:    u.ID,
+		FamilyID:  session.ID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	if err := s.repo.Create(rt); err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = rt.ExpiresAt
+	if err := s.sessions.Save(session); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: raw,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// signAccessToken signs an access token for a session and records it as
+// the session's current access token. The caller saves the session.
+func (s *TokenService) signAccessToken(u *models.User, session *models.Session, now time.Time) (string, error) {
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	// The user may have left the session's organization since, or joined
+	// their first one
+	membership := u.Membership(session.OrganizationID)
+	if membership == nil {
+		membership = u.DefaultMembership()
+	}
+	session.OrganizationID = 0
+	if membership != nil {
+		session.OrganizationID = membership.OrganizationID
+	}
+
+	accessExp := now.Add(s.accessTTL)
+	claims := &utils.Claims{
+		SessionID:   session.ID,
+		Roles:       u.RoleNames(),
+		Permissions: u.PermissionNames(),
+		AMR:         session.AMR,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(u.ID), 10),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(accessExp),
+		},
+	}
+	if membership != nil {
+		claims.OrgID = membership.OrganizationID
+		claims.OrgRole = membership.Role
+	}
+	// Sessions started before auth_time was recorded have none
+	if !session.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(session.AuthTime)
+	}
+	access, err := s.jwtManager.Sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	session.AccessTokenID = jti
+	session.AccessExpiresAt = accessExp
+	return access, nil
+}
+
+// touch records a request of a session unless the session was recorded
+// from the same IP within sessionTouchInterval. It reports false when the
+// session ended in the meantime.
+func (s *TokenService) touch(session *models.Session, clientIP string) (bool, error) {
+	now := time.Now()
+	if session.IP == clientIP && now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return true, nil
+	}
+	return s.sessions.Touch(session.UserID, session.ID, clientIP, now)
+}
+
+func (s *TokenService) revokeReusedFamily(rt *models.RefreshToken) error {
+	log.Printf("Refresh token reuse detected for user %d, revoking family %s", rt.UserID, rt.FamilyID)
+	if err := s.repo.RevokeFamily(rt.FamilyID); err != nil {
+		return err
+	}
+	if session, err := s.sessions.Get(rt.UserID, rt.FamilyID); err == nil {
+		_ = s.denylist.Add(session.AccessTokenID, time.Until(s.jwtManager.AcceptedUntil(session.AccessExpiresAt)))
+	}
+	if err := s.sessions.Delete(rt.UserID, rt.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTokenService_IssueTokens(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockTokens := &MockTokenRepository{}
+	user := &models.User{ID: 1, Email: "test@example.com", Roles: []models.Role{
+		{Name: models.RoleUser},
+		{Name: models.RoleAdmin, Permissions: []models.Permission{{Name: models.PermissionUsersWrite}, {Name: models.PermissionUsersRead}}},
+	}}
+
+	var stored *models.RefreshToken
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.RefreshToken)
+	}).Return(nil)
+
+	jwtManager := testTokenManager(t)
+	service := NewTokenService(mockUsers, mockTokens, repositories.NewMemorySessionStore(), repositories.NewMemoryTokenDenylist(), jwtManager, 15, 720)
+	pair, err := service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pair.AccessToken)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, "Bearer", pair.TokenType)
+	assert.Equal(t, int64(15*60), pair.ExpiresIn)
+
+	// Only the hash of the refresh token may be persisted
+	assert.Equal(t, utils.HashToken(pair.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, pair.RefreshToken, stored.TokenHash)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.NotEmpty(t, stored.FamilyID)
+
+	// The access token carries the user as subject and the family as session
+	claims, err := jwtManager.Parse(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", claims.Subject)
+	assert.Equal(t, stored.FamilyID, claims.SessionID)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, []string{models.RoleUser, models.RoleAdmin}, claims.Roles)
+	assert.Equal(t, []string{models.PermissionUsersRead, models.PermissionUsersWrite}, claims.Permissions)
+	assert.Equal(t, []string{models.AMRPassword}, claims.AMR)
+	assert.WithinDuration(t, time.Now(), claims.AuthTime.Time, 5*time.Second)
+
+	// The session records the client that logged in
+	sessions, err := service.Sessions(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, stored.FamilyID, sessions[0].ID)
+	assert.Equal(t, "Firefox on Linux", sessions[0].Device)
+	assert.Equal(t, "203.0.113.7", sessions[0].IP)
+	assert.False(t, sessions[0].LastSeenAt.IsZero())
+	mockTokens.AssertExpectations(t)
+}
+
+func TestTokenService_Refresh(t *testing.T) {
+	user := &models.User{ID: 1, Email: "test@example.com"}
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		setupMock   func(*MockUserRepository, *MockTokenRepository)
+		noSession   bool
+		expectedErr error
+	}{
+		{
+			name: "successful rotation keeps the family",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				rt := &models.RefreshToken{ID: 7, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(rt, nil)
+				mockTokens.On("Revoke", uint(7)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(user, nil)
+				mockTokens.On("Create", mock.MatchedBy(func(t *models.RefreshToken) bool {
+					return t.FamilyID == "family-1" && t.UserID == 1
+				})).Return(nil)
+			},
+		},
+		{
+			name: "revoked session",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				rt := &models.RefreshToken{ID: 7, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(rt, nil)
+				mockTokens.On("Revoke", uint(7)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(user, nil)
+			},
+			noSession:   true,
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "unknown token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(nil, repositories.ErrTokenNotFound)
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				rt := &models.RefreshToken{ID: 7, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(-time.Hour)}
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(rt, nil)
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "reused token revokes the family",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				rt := &models.RefreshToken{ID: 7, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(rt, nil)
+				mockTokens.On("RevokeFamily", "family-1").Return(nil)
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "concurrent rotation revokes the family",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockTokenRepository) {
+				rt := &models.RefreshToken{ID: 7, UserID: 1, FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", utils.HashToken("refresh")).Return(rt, nil)
+				mockTokens.On("Revoke", uint(7)).Return(false, nil)
+				mockTokens.On("RevokeFamily", "family-1").Return(nil)
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockTokens := &MockTokenRepository{}
+			tt.setupMock(mockUsers, mockTokens)
+			sessions := repositories.NewMemorySessionStore()
+			if !tt.noSession {
+				_ = sessions.Save(&models.Session{ID: "family-1", UserID: 1, Device: "Firefox on Linux", ExpiresAt: time.Now().Add(time.Hour)})
+			}
+
+			service := NewTokenService(mockUsers, mockTokens, sessions, repositories.NewMemoryTokenDenylist(), testTokenManager(t), 15, 720)
+			pair, err := service.Refresh("refresh")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, pair)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, pair.AccessToken)
+				assert.NotEqual(t, "refresh", pair.RefreshToken)
+				// Rotation keeps the client the session started on
+				session, err := sessions.Get(1, "family-1")
+				assert.NoError(t, err)
+				assert.Equal(t, "Firefox on Linux", session.Device)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockTokens := &MockTokenRepository{}
+	sessions := repositories.NewMemorySessionStore()
+	denylist := repositories.NewMemoryTokenDenylist()
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	service := NewTokenService(mockUsers, mockTokens, sessions, denylist, testTokenManager(t), 15, 720)
+
+	_, err := service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "")
+	assert.NoError(t, err)
+	_, err = service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "")
+	assert.NoError(t, err)
+
+	active, err := sessions.List(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	// Logging out one session leaves the other one alone
+	first := active[0]
+	mockTokens.On("RevokeFamily", first.ID).Return(nil)
+	err = service.Revoke(user.ID, first.ID, first.AccessTokenID, first.AccessExpiresAt)
+	assert.NoError(t, err)
+
+	revoked, _ := denylist.Contains(first.AccessTokenID)
+	assert.True(t, revoked)
+	remaining, _ := sessions.List(user.ID)
+	assert.Len(t, remaining, 1)
+
+	// Logging out everywhere revokes the rest
+	second := remaining[0]
+	mockTokens.On("RevokeAllForUser", user.ID).Return(nil)
+	err = service.RevokeAll(user.ID)
+	assert.NoError(t, err)
+
+	revoked, _ = denylist.Contains(second.AccessTokenID)
+	assert.True(t, revoked)
+	remaining, _ = sessions.List(user.ID)
+	assert.Empty(t, remaining)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestTokenService_RevokeWithinLeeway(t *testing.T) {
+	keys, err := utils.NewKeyManager(utils.AlgorithmEdDSA, t.TempDir(), "", 0, 0)
+	assert.NoError(t, err)
+	jwtManager, err := utils.NewTokenManager(keys, "test-issuer", "test-audience", time.Minute, []string{utils.AlgorithmEdDSA})
+	assert.NoError(t, err)
+	mockTokens := &MockTokenRepository{}
+	mockTokens.On("RevokeFamily", "sid").Return(nil)
+	denylist := repositories.NewMemoryTokenDenylist()
+	service := NewTokenService(&MockUserRepository{}, mockTokens, repositories.NewMemorySessionStore(), denylist, jwtManager, 15, 720)
+
+	// Parse still accepts a token that expired less than the leeway ago,
+	// so it has to be denylisted as well
+	err = service.Revoke(1, "sid", "jti", time.Now().Add(-10*time.Second))
+	assert.NoError(t, err)
+
+	revoked, _ := denylist.Contains("jti")
+	assert.True(t, revoked)
+}
+
+func TestTokenService_RevokeSession(t *testing.T) {
+	mockTokens := &MockTokenRepository{}
+	sessions := repositories.NewMemorySessionStore()
+	denylist := repositories.NewMemoryTokenDenylist()
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	service := NewTokenService(&MockUserRepository{}, mockTokens, sessions, denylist, testTokenManager(t), 15, 720)
+	_, err := service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "")
+	assert.NoError(t, err)
+	active, _ := sessions.List(user.ID)
+	session := active[0]
+
+	ok, err := service.TouchSession(user.ID, session.ID, "198.51.100.4")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	touched, _ := sessions.Get(user.ID, session.ID)
+	assert.Equal(t, "198.51.100.4", touched.IP)
+
+	mockTokens.On("RevokeFamily", session.ID).Return(nil)
+	assert.NoError(t, service.RevokeSession(user.ID, session.ID))
+	revoked, _ := denylist.Contains(session.AccessTokenID)
+	assert.True(t, revoked)
+
+	// Requests of the revoked session are turned away
+	ok, err = service.TouchSession(user.ID, session.ID, "198.51.100.4")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.ErrorIs(t, service.RevokeSession(user.ID, session.ID), ErrSessionNotFound)
+	assert.ErrorIs(t, service.RevokeSession(2, "other"), ErrSessionNotFound)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestTokenService_AuthenticateCookie(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockTokens := &MockTokenRepository{}
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+	service := NewTokenService(mockUsers, mockTokens, repositories.NewMemorySessionStore(), repositories.NewMemoryTokenDenylist(), testTokenManager(t), 15, 720)
+	pair, err := service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "test-agent")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pair.CSRFToken)
+
+	session, u, err := service.AuthenticateCookie(pair.SessionCookie, "203.0.113.7")
+	assert.NoError(t, err)
+	assert.Equal(t, user, u)
+	assert.Equal(t, utils.HashToken(pair.CSRFToken), session.CSRFHash)
+
+	// Malformed cookies, unknown sessions and wrong secrets are rejected
+	for _, value := range []string{"", "1.other.secret", pair.SessionCookie + "x", "2" + pair.SessionCookie[1:]} {
+		session, _, err := service.AuthenticateCookie(value, "203.0.113.7")
+		assert.NoError(t, err)
+		assert.Nil(t, session, value)
+	}
+
+	mockTokens.On("RevokeFamily", mock.Anything).Return(nil)
+	assert.NoError(t, service.RevokeSession(user.ID, session.ID))
+	session, _, err = service.AuthenticateCookie(pair.SessionCookie, "203.0.113.7")
+	assert.NoError(t, err)
+	assert.Nil(t, session)
+}
+
+func TestTokenService_Elevate(t *testing.T) {
+	mockTokens := &MockTokenRepository{}
+	sessions := repositories.NewMemorySessionStore()
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	jwtManager := testTokenManager(t)
+	service := NewTokenService(&MockUserRepository{}, mockTokens, sessions, repositories.NewMemoryTokenDenylist(), jwtManager, 15, 720)
+	_, err := service.IssueTokens(user, []string{models.AMREmail}, "203.0.113.7", "test-agent")
+	assert.NoError(t, err)
+	active, _ := sessions.List(user.ID)
+	session := active[0]
+
+	pair, err := service.Elevate(user, session.ID, []string{models.AMRPassword})
+	assert.NoError(t, err)
+	assert.Empty(t, pair.RefreshToken)
+
+	claims, err := jwtManager.Parse(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, claims.SessionID)
+	assert.Equal(t, []string{models.AMRPassword}, claims.AMR)
+	assert.WithinDuration(t, time.Now(), claims.AuthTime.Time, 5*time.Second)
+
+	// The session remembers the reauthentication for later refreshes
+	elevated, _ := sessions.Get(user.ID, session.ID)
+	assert.Equal(t, claims.ID, elevated.AccessTokenID)
+	assert.Equal(t, []string{models.AMRPassword}, elevated.AMR)
+	assert.False(t, elevated.AuthTime.Before(session.AuthTime))
+
+	_, err = service.Elevate(user, "other", []string{models.AMRPassword})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestTokenService_SwitchOrganization(t *testing.T) {
+	mockTokens := &MockTokenRepository{}
+	sessions := repositories.NewMemorySessionStore()
+	joined := time.Now().Add(-time.Hour)
+	user := &models.User{ID: 1, Email: "test@example.com", Memberships: []models.Membership{
+		{ID: 2, OrganizationID: 20, UserID: 1, Role: models.OrgRoleMember, CreatedAt: joined.Add(time.Minute)},
+		{ID: 1, OrganizationID: 10, UserID: 1, Role: models.OrgRoleOwner, CreatedAt: joined},
+	}}
+
+	mockTokens.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	jwtManager := testTokenManager(t)
+	service := NewTokenService(&MockUserRepository{}, mockTokens, sessions, repositories.NewMemoryTokenDenylist(), jwtManager, 15, 720)
+
+	// New sessions start in the oldest organization
+	pair, err := service.IssueTokens(user, []string{models.AMRPassword}, "203.0.113.7", "test-agent")
+	assert.NoError(t, err)
+	claims, err := jwtManager.Parse(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), claims.OrgID)
+	assert.Equal(t, models.OrgRoleOwner, claims.OrgRole)
+
+	pair, err = service.SwitchOrganization(user, claims.SessionID, 20)
+	assert.NoError(t, err)
+	assert.Empty(t, pair.RefreshToken)
+	claims, err = jwtManager.Parse(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(20), claims.OrgID)
+	assert.Equal(t, models.OrgRoleMember, claims.OrgRole)
+
+	switched, _ := sessions.Get(user.ID, claims.SessionID)
+	assert.Equal(t, uint(20), switched.OrganizationID)
+
+	_, err = service.SwitchOrganization(user, claims.SessionID, 30)
+	assert.ErrorIs(t, err, ErrNotOrgMember)
+
+	// Losing the membership moves the session back to the default organization
+	user.Memberships = user.Memberships[1:]
+	pair, err = service.Elevate(user, claims.SessionID, []string{models.AMRPassword})
+	assert.NoError(t, err)
+	claims, err = jwtManager.Parse(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), claims.OrgID)
+}
+
+// testTokenManager returns a token manager signing with a temporary EdDSA key
+func testTokenManager(t *testing.T) *utils.TokenManager {
+	keys, err := utils.NewKeyManager(utils.AlgorithmEdDSA, t.TempDir(), "", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create key manager: %v", err)
+	}
+	tokens, err := utils.NewTokenManager(keys, "test-issuer", "test-audience", 0, []string{utils.AlgorithmEdDSA})
+	if err != nil {
+		t.Fatalf("failed to create token manager: %v", err)
+	}
+	return tokens
+}
+
+// MockTokenRepository is a mock implementation of TokenRepository interface
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+// Ensure MockTokenRepository implements TokenRepository interface
+var _ repositories.TokenRepository = (*MockTokenRepository)(nil)
+
+func (m *MockTokenRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) Create(t *models.RefreshToken) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) FindByHash(hash string) (*models.RefreshToken, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockTokenRepository) Revoke(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeFamily(familyID string) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeAllForUser(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrUnsupportedPasswordHash = errors.New("unsupported password hash")
+	ErrPasswordHashTooCostly   = errors.New("password hash parameters exceed the configured limits")
+	ErrInvalidImportRecord     = errors.New("name and a valid email are required")
+	ErrUserAlreadyExists       = errors.New("user with this email already exists")
+)
+
+// UserImport is a user moved over from another system together with its
+// existing password hash. Every hash utils.CompareHash verifies is
+// accepted, including the legacy PBKDF2-SHA256 and salted SHA-1 formats,
+// unless its work factors exceed the configured limits.
+type UserImport struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	PasswordHash  string `json:"password_hash"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// ImportFailure is a record the import skipped. Index is its position in
+// the imported list.
+type ImportFailure struct {
+	Index int    `json:"index"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	Created int             `json:"created"`
+	Skipped []ImportFailure `json:"skipped"`
+}
+
+// UserImportService creates users with password hashes from other systems.
+// Outdated hashes are replaced at each user's first successful login.
+type UserImportService struct {
+	users repositories.UserRepository
+	roles RoleServiceInterface
+}
+
+// Ensure UserImportService implements UserImportServiceInterface interface
+var _ UserImportServiceInterface = (*UserImportService)(nil)
+
+func NewUserImportService(users repositories.UserRepository, roles RoleServiceInterface) *UserImportService {
+	return &UserImportService{users: users, roles: roles}
+}
+
+// Import creates every valid record whose email is not registered yet.
+// Invalid and duplicate records are skipped and reported, so an import can
+// be repeated after fixing them.
+func (s *UserImportService) Import(records []UserImport) (*ImportReport, error) {
+	report := &ImportReport{Skipped: []ImportFailure{}}
+	for i, record := range records {
+		if err := s.importUser(record); err != nil {
+			report.Skipped = append(report.Skipped, ImportFailure{Index: i, Email: record.Email, Error: err.Error()})
+			continue
+		}
+		report.Created++
+	}
+	return report, nil
+}
+
+func (s *UserImportService) importUser(record UserImport) error {
+	name := strings.TrimSpace(record.Name)
+	email := strings.TrimSpace(record.Email)
+	if name == "" || email == "" {
+		return ErrInvalidImportRecord
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return ErrInvalidImportRecord
+	}
+	if err := utils.CheckHash(record.PasswordHash); err != nil {
+		if errors.Is(err, utils.ErrPasswordHashTooCostly) {
+			return ErrPasswordHashTooCostly
+		}
+		return ErrUnsupportedPasswordHash
+	}
+
+	if _, err := s.users.FindByEmail(email); err == nil {
+		return ErrUserAlreadyExists
+	} else if !errors.Is(err, repositories.ErrUserNotFound) {
+		return err
+	}
+
+	u := &models.User{
+		Name:     name,
+		Email:    email,
+		Password: record.PasswordHash,
+	}
+	if record.EmailVerified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
+	if err := s.users.Create(u); err != nil {
+		return err
+	}
+
+	if err := s.roles.AssignRole(u.ID, models.RoleUser); err != nil {
+		log.Printf("Failed to assign default role: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserImportService_Import(t *testing.T) {
+	const sha1Hash = "sha1$abc12$952b96a80331ff38f3696eb26bdb4b21da124835" // "password123"
+
+	mockUsers := &MockUserRepository{}
+	mockUsers.On("FindByEmail", "new@example.com").Return(nil, repositories.ErrUserNotFound)
+	mockUsers.On("FindByEmail", "taken@example.com").Return(&models.User{ID: 3, Email: "taken@example.com"}, nil)
+	mockUsers.On("Create", mock.MatchedBy(func(u *models.User) bool {
+		return u.Email == "new@example.com" && u.Password == sha1Hash && u.EmailVerifiedAt != nil
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.User).ID = 7
+	}).Return(nil)
+	mockRoles := &MockRoleService{}
+	mockRoles.On("AssignRole", uint(7), models.RoleUser).Return(nil)
+
+	service := NewUserImportService(mockUsers, mockRoles)
+	report, err := service.Import([]UserImport{
+		{Name: "New", Email: "new@example.com", PasswordHash: sha1Hash, EmailVerified: true},
+		{Name: "Taken", Email: "taken@example.com", PasswordHash: sha1Hash},
+		{Name: "Plain", Email: "plain@example.com", PasswordHash: "password123"},
+		{Name: "", Email: "not-an-email", PasswordHash: sha1Hash},
+		{Name: "Costly", Email: "costly@example.com", PasswordHash: "$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$a2V5"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []ImportFailure{
+		{Index: 1, Email: "taken@example.com", Error: ErrUserAlreadyExists.Error()},
+		{Index: 2, Email: "plain@example.com", Error: ErrUnsupportedPasswordHash.Error()},
+		{Index: 3, Email: "not-an-email", Error: ErrInvalidImportRecord.Error()},
+		{Index: 4, Email: "costly@example.com", Error: ErrPasswordHashTooCostly.Error()},
+	}, report.Skipped)
+	mockUsers.AssertExpectations(t)
+	mockRoles.AssertExpectations(t)
+}
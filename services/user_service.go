@@ -11,37 +11,86 @@ import (
 	"temp/models"
 	"temp/repositories"
 	"temp/utils"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrEmailNotVerified   = errors.New("email not verified")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	// ErrPasswordResetRequired is returned when an admin forced a password
+	// reset; the user has to follow the emailed reset link
+	ErrPasswordResetRequired = errors.New("password reset required")
 )
 
+// LoginResult is the outcome of a password login. When the user has
+// two-factor authentication enabled, Tokens is nil and MFAToken has to be
+// exchanged together with a TOTP or recovery code at /login/mfa.
+type LoginResult struct {
+	User         *models.User
+	Tokens       *TokenPair
+	MFAToken     string
+	MFAExpiresIn int64
+}
+
 type UserService struct {
-	repo        repositories.UserRepository
-	jwtSecret   string
-	expDuration time.Duration
+	repo     repositories.UserRepository
+	tokens   TokenServiceInterface
+	verifier VerificationServiceInterface
+	resets   PasswordResetServiceInterface
+	mfa      MFAServiceInterface
+	roles    RoleServiceInterface
+	lockout  LockoutServiceInterface
+	// passwords checks new passwords against the password policy
+	passwords  PasswordPolicyServiceInterface
+	magicLinks MagicLinkServiceInterface
+	social     SocialLoginServiceInterface
+	// requireVerified rejects logins of users who have not verified their email
+	requireVerified bool
 }
 
 // Ensure UserService implements UserServiceInterface interface
 var _ UserServiceInterface = (*UserService)(nil)
 
-func NewUserService(repo repositories.UserRepository, jwtSecret string, expHours int) *UserService {
+func NewUserService(repo repositories.UserRepository, tokens TokenServiceInterface, verifier VerificationServiceInterface, resets PasswordResetServiceInterface, mfa MFAServiceInterface, roles RoleServiceInterface, lockout LockoutServiceInterface, passwords PasswordPolicyServiceInterface, magicLinks MagicLinkServiceInterface, social SocialLoginServiceInterface, requireVerified bool) *UserService {
 	return &UserService{
-		repo:        repo,
-		jwtSecret:   jwtSecret,
-		expDuration: time.Duration(expHours) * time.Hour,
+		repo:            repo,
+		tokens:          tokens,
+		verifier:        verifier,
+		resets:          resets,
+		mfa:             mfa,
+		roles:           roles,
+		lockout:         lockout,
+		passwords:       passwords,
+		magicLinks:      magicLinks,
+		social:          social,
+		requireVerified: requireVerified,
 	}
 }
 
 func (s *UserService) Register(name, email, password string) (*models.User, error) {
+	return s.register(name, email, password, false, s.repo.Create)
+}
+
+// RegisterVerified creates an account for an email address that was
+// already proven, such as by following an invitation link. The email is
+// marked as verified and no verification email is sent. create stores the
+// new user, so the caller can store it in one transaction with the records
+// that made the address proven.
+func (s *UserService) RegisterVerified(name, email, password string, create func(u *models.User) error) (*models.User, error) {
+	return s.register(name, email, password, true, create)
+}
+
+func (s *UserService) register(name, email, password string, verified bool, create func(u *models.User) error) (*models.User, error) {
 	// check if user already exists
 	if _, err := s.repo.FindByEmail(email); err == nil {
 		return nil, errors.New("user with this email already exists")
 	}
 
+	if err := s.passwords.Check(&models.User{Name: name, Email: email}, password); err != nil {
+		return nil, err
+	}
+
 	hash, err := utils.GenerateHash(password)
 	if err != nil {
 		return nil, err
@@ -52,10 +101,31 @@ func (s *UserService) Register(name, email, password string) (*models.User, erro
 		Email:    email,
 		Password: hash,
 	}
+	if verified {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+	}
 
-	if err := s.repo.Create(u); err != nil {
+	if err := create(u); err != nil {
 		return nil, err
 	}
+	if err := s.passwords.Remember(u); err != nil {
+		log.Printf("Failed to record password history: %v", err)
+	}
+
+	// A user without roles has no permissions, which is all the default
+	// role grants, so a failed assignment does not fail the registration
+	if err := s.roles.AssignRole(u.ID, models.RoleUser); err != nil {
+		log.Printf("Failed to assign default role: %v", err)
+	}
+
+	// The account exists even if the email cannot be sent; the user can ask
+	// for a new link through /resend-verification
+	if !verified {
+		if err := s.verifier.SendVerification(u); err != nil {
+			log.Printf("Failed to send verification email: %v", err)
+		}
+	}
 
 	// Store user registration in Redis for demonstration
 	if global.Redis != nil {
@@ -70,36 +140,332 @@ func (s *UserService) Register(name, email, password string) (*models.User, erro
 	return u, nil
 }
 
-func (s *UserService) Authenticate(email, password string) (string, *models.User, error) {
+// Authenticate checks a password login. Failed attempts are counted per
+// account and per client IP and reserved before the password is checked; a
+// *RetryError is returned while the lockout service refuses further
+// attempts.
+func (s *UserService) Authenticate(email, password, clientIP, userAgent string) (*LoginResult, error) {
+	if err := s.lockout.Reserve(email, clientIP); err != nil {
+		return nil, err
+	}
+
 	u, err := s.repo.FindByEmail(email)
 	if err != nil {
-		return "", nil, ErrInvalidCredentials
+		// Spend the time of a password check so unknown emails cannot be
+		// told apart from wrong passwords
+		utils.CompareDummyHash(password)
+		s.recordFailure(email)
+		return nil, ErrInvalidCredentials
 	}
-
 	if !utils.CompareHash(password, u.Password) {
-		return "", nil, ErrInvalidCredentials
+		s.recordFailure(email)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.lockout.RecordSuccess(email, clientIP); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+
+	// Upgrade hashes made with an outdated algorithm or parameters while
+	// the plain password is at hand
+	if utils.NeedsRehash(u.Password) {
+		s.rehash(u, password)
 	}
 
-	// create token
-	claims := jwt.MapClaims{
-		"user_id": u.ID,
-		"exp":     time.Now().Add(s.expDuration).Unix(),
+	if err := s.checkLoginAllowed(u); err != nil {
+		return nil, err
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := t.SignedString([]byte(s.jwtSecret))
+	return s.completeLogin(u, []string{models.AMRPassword}, clientIP, userAgent)
+}
+
+// RequestMagicLink mails a passwordless login link if the email is registered
+func (s *UserService) RequestMagicLink(email, clientIP, userAgent string) error {
+	return s.magicLinks.Request(email, clientIP, userAgent)
+}
+
+// LoginWithMagicLink redeems a login link. It ends like a password login:
+// users with two-factor authentication still have to pass the challenge.
+func (s *UserService) LoginWithMagicLink(token, clientIP, userAgent string) (*LoginResult, error) {
+	u, err := s.magicLinks.Consume(token, clientIP, userAgent)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
+	if err := s.checkLoginAllowed(u); err != nil {
+		return nil, err
+	}
+	return s.completeLogin(u, []string{models.AMREmail}, clientIP, userAgent)
+}
 
-	// Store JWT token in Redis after successful authentication
-	if global.Redis != nil {
-		ctx := context.Background()
-		redisKey := fmt.Sprintf("user:token:%d", u.ID)
-		if err := global.Redis.Set(ctx, redisKey, signed, s.expDuration).Err(); err != nil {
-			// Log the error but don't fail login if Redis fails
-			log.Printf("Failed to store user token in Redis: %v", err)
+// LoginWithProvider finishes a login through an external identity
+// provider. Like every login it may still require the second factor.
+func (s *UserService) LoginWithProvider(provider, state, code, clientIP, userAgent string) (*LoginResult, error) {
+	u, err := s.social.Complete(provider, state, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginAllowed(u); err != nil {
+		return nil, err
+	}
+	return s.completeLogin(u, []string{models.AMRFederated}, clientIP, userAgent)
+}
+
+// checkLoginAllowed rejects users who may not log in whichever way they
+// authenticated: disabled accounts, accounts an admin forced to reset the
+// password and, when required, accounts with an unverified email
+func (s *UserService) checkLoginAllowed(u *models.User) error {
+	if u.DisabledAt != nil {
+		return ErrAccountDisabled
+	}
+	if u.PasswordResetRequired {
+		return ErrPasswordResetRequired
+	}
+	if s.requireVerified && u.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// completeLogin issues tokens for a user authenticated with the methods in
+// amr, or starts the second factor challenge when two-factor authentication
+// is enabled
+func (s *UserService) completeLogin(u *models.User, amr []string, clientIP, userAgent string) (*LoginResult, error) {
+	if u.MFAEnabledAt != nil {
+		challenge, expiresIn, err := s.mfa.Challenge(u)
+		if err != nil {
+			return nil, err
 		}
+		return &LoginResult{User: u, MFAToken: challenge, MFAExpiresIn: expiresIn}, nil
 	}
 
-	return signed, u, nil
+	// Issuing tokens also records the session under user:token:<id>
+	pair, err := s.tokens.IssueTokens(u, amr, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{User: u, Tokens: pair}, nil
+}
+
+// Reauthenticate confirms the identity of a logged in user with the
+// password, a TOTP or recovery code, or both, and elevates the session so
+// sensitive operations are allowed for a while. Users with two-factor
+// authentication must give a code, so a stolen session and password cannot
+// reach the second factor settings. Failures count towards the login
+// lockout like failed logins.
+func (s *UserService) Reauthenticate(userID uint, sessionID, password, code, clientIP string) (*TokenPair, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabledAt != nil && code == "" {
+		return nil, ErrMFACodeRequired
+	}
+	if password == "" && code == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if err := s.lockout.Reserve(u.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	var amr []string
+	if password != "" {
+		if !utils.CompareHash(password, u.Password) {
+			s.recordFailure(u.Email)
+			return nil, ErrInvalidCredentials
+		}
+		amr = append(amr, models.AMRPassword)
+	}
+	if code != "" {
+		if err := s.mfa.VerifyCode(u, code); err != nil {
+			if errors.Is(err, ErrInvalidMFACode) {
+				s.recordFailure(u.Email)
+			}
+			return nil, err
+		}
+		amr = append(amr, models.AMROTP)
+	}
+	if len(amr) > 1 {
+		amr = append(amr, models.AMRMultiFactor)
+	}
+
+	if err := s.lockout.RecordSuccess(u.Email, clientIP); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+	return s.tokens.Elevate(u, sessionID, amr)
+}
+
+func (s *UserService) recordFailure(email string) {
+	if err := s.lockout.RecordFailure(email); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	}
+}
+
+// rehash stores a new hash of the password. A failure only delays the
+// upgrade to the next login.
+func (s *UserService) rehash(u *models.User, password string) {
+	hash, err := utils.GenerateHash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password: %v", err)
+		return
+	}
+	previous := u.Password
+	u.Password = hash
+	if err := s.repo.Update(u); err != nil {
+		u.Password = previous
+		log.Printf("Failed to store rehashed password: %v", err)
+	}
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair
+func (s *UserService) RefreshTokens(refreshToken string) (*TokenPair, error) {
+	return s.tokens.Refresh(refreshToken)
+}
+
+// Logout revokes the session the presented access token belongs to
+func (s *UserService) Logout(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	return s.tokens.Revoke(userID, sessionID, tokenID, expiresAt)
+}
+
+// LogoutAll revokes every session of the user
+func (s *UserService) LogoutAll(userID uint) error {
+	return s.tokens.RevokeAll(userID)
+}
+
+// VerifyEmail redeems an email verification token
+func (s *UserService) VerifyEmail(token string) error {
+	return s.verifier.Verify(token)
+}
+
+// ResendVerification mails a new verification link to an unverified user
+func (s *UserService) ResendVerification(email string) error {
+	return s.verifier.Resend(email)
+}
+
+// ForgotPassword mails a password reset link if the email is registered
+func (s *UserService) ForgotPassword(email string) error {
+	return s.resets.RequestReset(email)
+}
+
+// UnlockAccount lifts a lockout using a token from an unlock email
+func (s *UserService) UnlockAccount(token string) error {
+	return s.lockout.Unlock(token)
+}
+
+// ResetPassword sets a new password using a token from a reset email
+func (s *UserService) ResetPassword(token, newPassword string) error {
+	return s.resets.Reset(token, newPassword)
+}
+
+// ProfileUpdate holds the profile fields to change. Nil fields are left
+// untouched and empty strings clear the optional fields.
+type ProfileUpdate struct {
+	Name        *string
+	DisplayName *string
+	AvatarURL   *string
+	Bio         *string
+	Locale      *string
+	Timezone    *string
+}
+
+// GetProfile loads the profile of a user
+func (s *UserService) GetProfile(userID uint) (*models.User, error) {
+	return s.repo.FindByID(userID)
+}
+
+// UpdateProfile changes the editable profile fields of a user
+func (s *UserService) UpdateProfile(userID uint, update ProfileUpdate) (*models.User, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		u.Name = *update.Name
+	}
+	if update.DisplayName != nil {
+		u.DisplayName = *update.DisplayName
+	}
+	if update.AvatarURL != nil {
+		u.AvatarURL = *update.AvatarURL
+	}
+	if update.Bio != nil {
+		u.Bio = *update.Bio
+	}
+	if update.Locale != nil {
+		u.Locale = *update.Locale
+	}
+	if update.Timezone != nil {
+		u.Timezone = *update.Timezone
+	}
+
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ChangeEmail moves an account to a new email address. The new address
+// counts as unverified until the user follows the link sent to it.
+func (s *UserService) ChangeEmail(userID uint, email string) (*models.User, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Email == email {
+		return u, nil
+	}
+	if _, err := s.repo.FindByEmail(email); err == nil {
+		return nil, ErrUserAlreadyExists
+	} else if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, err
+	}
+
+	u.Email = email
+	u.EmailVerifiedAt = nil
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+
+	// The address is changed even if the email cannot be sent; the user can
+	// ask for a new link through /resend-verification
+	if err := s.verifier.SendVerification(u); err != nil {
+		log.Printf("Failed to send verification email: %v", err)
+	}
+	return u, nil
+}
+
+// DeleteAccount ends every session of a user and deletes the account
+func (s *UserService) DeleteAccount(userID uint) error {
+	if err := s.tokens.RevokeAll(userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(userID)
+}
+
+// ChangePassword replaces the password of a user after checking the current one
+func (s *UserService) ChangePassword(userID uint, oldPassword, newPassword string) (*models.User, error) {
+	u, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.CompareHash(oldPassword, u.Password) {
+		return nil, ErrIncorrectPassword
+	}
+	if err := s.passwords.Check(u, newPassword); err != nil {
+		return nil, err
+	}
+
+	hash, err := utils.GenerateHash(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = hash
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	if err := s.passwords.Remember(u); err != nil {
+		log.Printf("Failed to record password history: %v", err)
+	}
+	return u, nil
 }
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"strings"
 	"temp/models"
 	"temp/repositories"
+	"temp/utils"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,6 +19,8 @@ func TestUserService_Register(t *testing.T) {
 		email       string
 		password    string
 		setupMock   func(*MockUserRepository)
+		sendErr     error
+		policyErr   error
 		expectedErr string
 	}{
 		{
@@ -42,6 +47,29 @@ func TestUserService_Register(t *testing.T) {
 			},
 			expectedErr: "user with this email already exists",
 		},
+		{
+			name:     "password violates the policy",
+			userName: "Test User",
+			email:    "test@example.com",
+			password: "short",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByEmail", "test@example.com").Return(nil, repositories.ErrUserNotFound)
+			},
+			policyErr:   &PasswordPolicyError{Violations: []PasswordViolation{{Rule: PasswordRuleMinLength, Message: "must be at least 8 characters long"}}},
+			expectedErr: "must be at least 8 characters long",
+		},
+		{
+			name:     "verification email failure does not fail registration",
+			userName: "Test User",
+			email:    "test@example.com",
+			password: "password123",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByEmail", "test@example.com").Return(nil, repositories.ErrUserNotFound)
+				mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+			},
+			sendErr:     assert.AnError,
+			expectedErr: "",
+		},
 		{
 			name:     "empty password",
 			userName: "Test User",
@@ -58,9 +86,18 @@ func TestUserService_Register(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := &MockUserRepository{}
+			mockVerifier := &MockVerificationService{}
 			tt.setupMock(mockRepo)
+			mockVerifier.On("SendVerification", mock.AnythingOfType("*models.User")).Return(tt.sendErr)
+			mockRoles := &MockRoleService{}
+			mockRoles.On("AssignRole", mock.AnythingOfType("uint"), models.RoleUser).Return(nil)
+			mockPasswords := &MockPasswordPolicyService{}
+			mockPasswords.On("Check", mock.MatchedBy(func(u *models.User) bool {
+				return u.Name == tt.userName && u.Email == tt.email
+			}), tt.password).Return(tt.policyErr)
+			mockPasswords.On("Remember", mock.AnythingOfType("*models.User")).Return(nil).Maybe()
 
-			service := NewUserService(mockRepo, "test-secret", 24)
+			service := NewUserService(mockRepo, &MockTokenService{}, mockVerifier, &MockPasswordResetService{}, &MockMFAService{}, mockRoles, &MockLockoutService{}, mockPasswords, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
 			user, err := service.Register(tt.userName, tt.email, tt.password)
 
 			if tt.expectedErr != "" {
@@ -73,6 +110,9 @@ func TestUserService_Register(t *testing.T) {
 				assert.Equal(t, tt.userName, user.Name)
 				assert.Equal(t, tt.email, user.Email)
 				assert.NotEmpty(t, user.Password) // Password should be hashed
+				mockVerifier.AssertCalled(t, "SendVerification", user)
+				mockRoles.AssertCalled(t, "AssignRole", user.ID, models.RoleUser)
+				mockPasswords.AssertCalled(t, "Remember", user)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -80,20 +120,52 @@ func TestUserService_Register(t *testing.T) {
 	}
 }
 
+func TestUserService_RegisterVerified(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("FindByEmail", "invitee@example.com").Return(nil, repositories.ErrUserNotFound)
+	mockVerifier := &MockVerificationService{}
+	mockRoles := &MockRoleService{}
+	mockRoles.On("AssignRole", mock.AnythingOfType("uint"), models.RoleUser).Return(nil)
+	mockPasswords := &MockPasswordPolicyService{}
+	mockPasswords.On("Check", mock.AnythingOfType("*models.User"), "password123").Return(nil)
+	mockPasswords.On("Remember", mock.AnythingOfType("*models.User")).Return(nil)
+
+	service := NewUserService(mockRepo, &MockTokenService{}, mockVerifier, &MockPasswordResetService{}, &MockMFAService{}, mockRoles, &MockLockoutService{}, mockPasswords, &MockMagicLinkService{}, &MockSocialLoginService{}, true)
+	var created *models.User
+	user, err := service.RegisterVerified("Invitee", "invitee@example.com", "password123", func(u *models.User) error {
+		u.ID = 9
+		created = u
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	// The caller stores the user
+	assert.Same(t, created, user)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	// The email is already proven, so no verification email is sent
+	mockVerifier.AssertNotCalled(t, "SendVerification", mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_Authenticate(t *testing.T) {
 	tests := []struct {
-		name        string
-		email       string
-		password    string
-		setupMock   func(*MockUserRepository)
-		expectedErr string
-		expectToken bool
+		name            string
+		email           string
+		password        string
+		requireVerified bool
+		setupMock       func(*MockUserRepository, *MockTokenService, *MockMFAService)
+		expectedErr     string
+		expectToken     bool
+		expectMFA       bool
+		// lockoutErr is returned by the lockout reservation before the password is looked at
+		lockoutErr error
 	}{
 		{
 			name:     "successful authentication",
 			email:    "test@example.com",
 			password: "password123",
-			setupMock: func(mockRepo *MockUserRepository) {
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
 				user := &models.User{
 					ID:       1,
 					Name:     "Test User",
@@ -101,6 +173,7 @@ func TestUserService_Authenticate(t *testing.T) {
 					Password: "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
 				}
 				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+				mockTokens.On("IssueTokens", user, []string{models.AMRPassword}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
 			},
 			expectedErr: "",
 			expectToken: true,
@@ -109,7 +182,7 @@ func TestUserService_Authenticate(t *testing.T) {
 			name:     "user not found",
 			email:    "nonexistent@example.com",
 			password: "password123",
-			setupMock: func(mockRepo *MockUserRepository) {
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
 				mockRepo.On("FindByEmail", "nonexistent@example.com").Return(nil, repositories.ErrUserNotFound)
 			},
 			expectedErr: "invalid credentials",
@@ -119,7 +192,7 @@ func TestUserService_Authenticate(t *testing.T) {
 			name:     "invalid password",
 			email:    "test@example.com",
 			password: "wrongpassword",
-			setupMock: func(mockRepo *MockUserRepository) {
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
 				user := &models.User{
 					ID:       1,
 					Name:     "Test User",
@@ -131,42 +204,569 @@ func TestUserService_Authenticate(t *testing.T) {
 			expectedErr: "invalid credentials",
 			expectToken: false,
 		},
+		{
+			name:     "disabled account",
+			email:    "test@example.com",
+			password: "password123",
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+				disabledAt := time.Now()
+				user := &models.User{
+					ID:         1,
+					Email:      "test@example.com",
+					Password:   "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+					DisabledAt: &disabledAt,
+				}
+				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+			},
+			expectedErr: "account is disabled",
+		},
+		{
+			name:     "password reset required",
+			email:    "test@example.com",
+			password: "password123",
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+				user := &models.User{
+					ID:                    1,
+					Email:                 "test@example.com",
+					Password:              "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+					PasswordResetRequired: true,
+				}
+				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+			},
+			expectedErr: "password reset required",
+		},
+		{
+			name:            "unverified email when verification is required",
+			email:           "test@example.com",
+			password:        "password123",
+			requireVerified: true,
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+				user := &models.User{
+					ID:       1,
+					Name:     "Test User",
+					Email:    "test@example.com",
+					Password: "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+				}
+				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+			},
+			expectedErr: "email not verified",
+			expectToken: false,
+		},
+		{
+			name:            "verified email when verification is required",
+			email:           "test@example.com",
+			password:        "password123",
+			requireVerified: true,
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+				verifiedAt := time.Now()
+				user := &models.User{
+					ID:              1,
+					Name:            "Test User",
+					Email:           "test@example.com",
+					Password:        "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+					EmailVerifiedAt: &verifiedAt,
+				}
+				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+				mockTokens.On("IssueTokens", user, []string{models.AMRPassword}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedErr: "",
+			expectToken: true,
+		},
+		{
+			name:     "locked account is refused before the password check",
+			email:    "test@example.com",
+			password: "password123",
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+			},
+			lockoutErr:  &RetryError{Err: ErrAccountLocked, RetryAfter: time.Minute},
+			expectedErr: "account is temporarily locked",
+		},
+		{
+			name:     "two-factor authentication returns a challenge",
+			email:    "test@example.com",
+			password: "password123",
+			setupMock: func(mockRepo *MockUserRepository, mockTokens *MockTokenService, mockMFA *MockMFAService) {
+				enabledAt := time.Now()
+				user := &models.User{
+					ID:           1,
+					Email:        "test@example.com",
+					Password:     "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+					MFAEnabledAt: &enabledAt,
+				}
+				mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+				mockMFA.On("Challenge", user).Return("challenge", int64(300), nil)
+			},
+			expectedErr: "",
+			expectMFA:   true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := &MockUserRepository{}
-			tt.setupMock(mockRepo)
+			mockTokens := &MockTokenService{}
+			mockMFA := &MockMFAService{}
+			mockLockout := &MockLockoutService{}
+			tt.setupMock(mockRepo, mockTokens, mockMFA)
+			mockLockout.On("Reserve", tt.email, "203.0.113.7").Return(tt.lockoutErr)
+			mockLockout.On("RecordFailure", tt.email).Return(nil).Maybe()
+			mockLockout.On("RecordSuccess", tt.email, "203.0.113.7").Return(nil).Maybe()
+			// The bcrypt hashes of the fixtures are upgraded on login
+			mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil).Maybe()
 
-			service := NewUserService(mockRepo, "test-secret", 24)
-			token, user, err := service.Authenticate(tt.email, tt.password)
+			service := NewUserService(mockRepo, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, mockMFA, &MockRoleService{}, mockLockout, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, tt.requireVerified)
+			result, err := service.Authenticate(tt.email, tt.password, "203.0.113.7", "test-agent")
 
 			if tt.expectedErr != "" {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
-				assert.Empty(t, token)
-				assert.Nil(t, user)
+				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, user)
+				assert.NotNil(t, result.User)
 				if tt.expectToken {
-					assert.NotEmpty(t, token)
+					assert.NotEmpty(t, result.Tokens.AccessToken)
+					assert.NotEmpty(t, result.Tokens.RefreshToken)
+				}
+				if tt.expectMFA {
+					assert.Nil(t, result.Tokens)
+					assert.Equal(t, "challenge", result.MFAToken)
+					assert.Equal(t, int64(300), result.MFAExpiresIn)
 				}
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+			mockMFA.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_AuthenticateRehash(t *testing.T) {
+	const bcryptHash = "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m" // "password123"
+	currentHash, err := utils.GenerateHash("password123")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		hash         string
+		expectUpdate bool
+	}{
+		{name: "outdated hash is upgraded", hash: bcryptHash, expectUpdate: true},
+		{name: "imported pbkdf2 hash is upgraded", hash: "pbkdf2_sha256$1000$somesalt$9uGciTK0YsFs8IX66F2YGyx+F/21bBVCbHT6pTGREzA=", expectUpdate: true},
+		{name: "imported sha1 hash is upgraded", hash: "sha1$abc12$952b96a80331ff38f3696eb26bdb4b21da124835", expectUpdate: true},
+		{name: "current hash is kept", hash: currentHash, expectUpdate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: 1, Email: "test@example.com", Password: tt.hash}
+			mockRepo := &MockUserRepository{}
+			mockRepo.On("FindByEmail", "test@example.com").Return(user, nil)
+			mockRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+				return strings.HasPrefix(u.Password, "$argon2id$") && utils.CompareHash("password123", u.Password)
+			})).Return(nil).Maybe()
+			mockTokens := &MockTokenService{}
+			mockTokens.On("IssueTokens", user, []string{models.AMRPassword}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access"}, nil)
+			mockLockout := &MockLockoutService{}
+			mockLockout.On("Reserve", "test@example.com", "203.0.113.7").Return(nil)
+			mockLockout.On("RecordSuccess", "test@example.com", "203.0.113.7").Return(nil)
+
+			service := NewUserService(mockRepo, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, mockLockout, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+			_, err := service.Authenticate("test@example.com", "password123", "203.0.113.7", "test-agent")
+
+			assert.NoError(t, err)
+			if tt.expectUpdate {
+				mockRepo.AssertCalled(t, "Update", user)
+				assert.False(t, utils.NeedsRehash(user.Password))
+			} else {
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+				assert.Equal(t, currentHash, user.Password)
+			}
+		})
+	}
+}
+
+func TestUserService_LoginWithMagicLink(t *testing.T) {
+	mfaEnabledAt := time.Now()
+	disabledAt := time.Now()
+
+	tests := []struct {
+		name        string
+		user        *models.User
+		setupMock   func(*MockTokenService, *MockMFAService, *models.User)
+		expectedErr error
+		expectMFA   bool
+	}{
+		{
+			name: "tokens issued",
+			user: &models.User{ID: 1, Email: "test@example.com"},
+			setupMock: func(mockTokens *MockTokenService, mockMFA *MockMFAService, u *models.User) {
+				mockTokens.On("IssueTokens", u, []string{models.AMREmail}, "203.0.113.7", "Firefox").Return(&TokenPair{AccessToken: "access"}, nil)
+			},
+		},
+		{
+			name: "second factor still required",
+			user: &models.User{ID: 1, Email: "test@example.com", MFAEnabledAt: &mfaEnabledAt},
+			setupMock: func(mockTokens *MockTokenService, mockMFA *MockMFAService, u *models.User) {
+				mockMFA.On("Challenge", u).Return("challenge", int64(300), nil)
+			},
+			expectMFA: true,
+		},
+		{
+			name:        "disabled user",
+			user:        &models.User{ID: 1, Email: "test@example.com", DisabledAt: &disabledAt},
+			setupMock:   func(mockTokens *MockTokenService, mockMFA *MockMFAService, u *models.User) {},
+			expectedErr: ErrAccountDisabled,
+		},
+		{
+			name:        "password reset required",
+			user:        &models.User{ID: 1, Email: "test@example.com", PasswordResetRequired: true},
+			setupMock:   func(mockTokens *MockTokenService, mockMFA *MockMFAService, u *models.User) {},
+			expectedErr: ErrPasswordResetRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := &MockTokenService{}
+			mockMFA := &MockMFAService{}
+			mockMagicLinks := &MockMagicLinkService{}
+			mockMagicLinks.On("Consume", "token", "203.0.113.7", "Firefox").Return(tt.user, nil)
+			tt.setupMock(mockTokens, mockMFA, tt.user)
+
+			service := NewUserService(&MockUserRepository{}, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, mockMFA, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, mockMagicLinks, &MockSocialLoginService{}, false)
+			result, err := service.LoginWithMagicLink("token", "203.0.113.7", "Firefox")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else if tt.expectMFA {
+				assert.NoError(t, err)
+				assert.Nil(t, result.Tokens)
+				assert.Equal(t, "challenge", result.MFAToken)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "access", result.Tokens.AccessToken)
+			}
+			mockTokens.AssertExpectations(t)
+			mockMFA.AssertExpectations(t)
+			mockMagicLinks.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_LoginWithProvider(t *testing.T) {
+	disabledAt := time.Now()
+
+	tests := []struct {
+		name            string
+		user            *models.User
+		requireVerified bool
+		setupMock       func(*MockTokenService, *models.User)
+		expectedErr     error
+	}{
+		{
+			name: "tokens issued",
+			user: &models.User{ID: 1, Email: "test@example.com"},
+			setupMock: func(mockTokens *MockTokenService, u *models.User) {
+				mockTokens.On("IssueTokens", u, []string{models.AMRFederated}, "203.0.113.7", "test-agent").Return(&TokenPair{AccessToken: "access"}, nil)
+			},
+		},
+		{
+			name:        "disabled user",
+			user:        &models.User{ID: 1, Email: "test@example.com", DisabledAt: &disabledAt},
+			setupMock:   func(mockTokens *MockTokenService, u *models.User) {},
+			expectedErr: ErrAccountDisabled,
+		},
+		{
+			name:        "password reset required",
+			user:        &models.User{ID: 1, Email: "test@example.com", PasswordResetRequired: true},
+			setupMock:   func(mockTokens *MockTokenService, u *models.User) {},
+			expectedErr: ErrPasswordResetRequired,
+		},
+		{
+			name:            "email not verified",
+			user:            &models.User{ID: 1, Email: "test@example.com"},
+			requireVerified: true,
+			setupMock:       func(mockTokens *MockTokenService, u *models.User) {},
+			expectedErr:     ErrEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := &MockTokenService{}
+			mockSocial := &MockSocialLoginService{}
+			mockSocial.On("Complete", "google", "state", "code").Return(tt.user, nil)
+			tt.setupMock(mockTokens, tt.user)
+
+			service := NewUserService(&MockUserRepository{}, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, mockSocial, tt.requireVerified)
+			result, err := service.LoginWithProvider("google", "state", "code", "203.0.113.7", "test-agent")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "access", result.Tokens.AccessToken)
+			}
+			mockTokens.AssertExpectations(t)
+			mockSocial.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_GetProfile(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	user := &models.User{ID: 1, Name: "Test User", Email: "test@example.com"}
+	mockRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockRepo.On("FindByID", uint(2)).Return(nil, repositories.ErrUserNotFound)
+
+	service := NewUserService(mockRepo, &MockTokenService{}, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+
+	profile, err := service.GetProfile(1)
+	assert.NoError(t, err)
+	assert.Equal(t, user, profile)
+
+	profile, err = service.GetProfile(2)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	assert.Nil(t, profile)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	user := &models.User{ID: 1, Name: "Old Name", Email: "test@example.com", Bio: "Old bio", Locale: "en"}
+	mockRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil)
+
+	name, bio, timezone := "New Name", "", "Europe/Berlin"
+	service := NewUserService(mockRepo, &MockTokenService{}, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+	updated, err := service.UpdateProfile(1, ProfileUpdate{Name: &name, Bio: &bio, Timezone: &timezone})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "New Name", updated.Name)
+	assert.Equal(t, "", updated.Bio)
+	assert.Equal(t, "Europe/Berlin", updated.Timezone)
+	// Fields that were not sent are left alone
+	assert.Equal(t, "en", updated.Locale)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ChangeEmail(t *testing.T) {
+	verifiedAt := time.Now()
+
+	t.Run("new address needs verification", func(t *testing.T) {
+		user := &models.User{ID: 1, Email: "old@example.com", EmailVerifiedAt: &verifiedAt}
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("FindByID", uint(1)).Return(user, nil)
+		mockRepo.On("FindByEmail", "new@example.com").Return(nil, repositories.ErrUserNotFound)
+		mockRepo.On("Update", user).Return(nil)
+		mockVerifier := &MockVerificationService{}
+		mockVerifier.On("SendVerification", user).Return(nil)
+
+		service := NewUserService(mockRepo, &MockTokenService{}, mockVerifier, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+		u, err := service.ChangeEmail(1, "new@example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "new@example.com", u.Email)
+		assert.Nil(t, u.EmailVerifiedAt)
+		mockRepo.AssertExpectations(t)
+		mockVerifier.AssertExpectations(t)
+	})
+
+	t.Run("address taken", func(t *testing.T) {
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("FindByID", uint(1)).Return(&models.User{ID: 1, Email: "old@example.com"}, nil)
+		mockRepo.On("FindByEmail", "taken@example.com").Return(&models.User{ID: 2}, nil)
+
+		service := NewUserService(mockRepo, &MockTokenService{}, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+		_, err := service.ChangeEmail(1, "taken@example.com")
+
+		assert.ErrorIs(t, err, ErrUserAlreadyExists)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+}
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("Delete", uint(1)).Return(nil)
+	mockTokens := &MockTokenService{}
+	mockTokens.On("RevokeAll", uint(1)).Return(nil)
+
+	service := NewUserService(mockRepo, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+
+	assert.NoError(t, service.DeleteAccount(1))
+	mockRepo.AssertExpectations(t)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	const currentHash = "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m" // "password123"
+
+	tests := []struct {
+		name        string
+		oldPassword string
+		setupMock   func(*MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:        "successful change",
+			oldPassword: "password123",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByID", uint(1)).Return(&models.User{ID: 1, Password: currentHash}, nil)
+				mockRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+					return utils.CompareHash("newpassword123", u.Password)
+				})).Return(nil)
+			},
+		},
+		{
+			name:        "incorrect current password",
+			oldPassword: "wrongpassword",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByID", uint(1)).Return(&models.User{ID: 1, Password: currentHash}, nil)
+			},
+			expectedErr: ErrIncorrectPassword,
+		},
+		{
+			name:        "new password violates the policy",
+			oldPassword: "password123",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByID", uint(1)).Return(&models.User{ID: 2, Password: currentHash}, nil)
+			},
+			expectedErr: ErrPasswordPolicy,
+		},
+		{
+			name:        "user not found",
+			oldPassword: "password123",
+			setupMock: func(mockRepo *MockUserRepository) {
+				mockRepo.On("FindByID", uint(1)).Return(nil, repositories.ErrUserNotFound)
+			},
+			expectedErr: repositories.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.setupMock(mockRepo)
+			// The policy refuses the new password of user 2
+			mockPasswords := &MockPasswordPolicyService{}
+			mockPasswords.On("Check", mock.MatchedBy(func(u *models.User) bool { return u.ID == 1 }), "newpassword123").Return(nil).Maybe()
+			mockPasswords.On("Check", mock.MatchedBy(func(u *models.User) bool { return u.ID == 2 }), "newpassword123").Return(&PasswordPolicyError{Violations: []PasswordViolation{{Rule: PasswordRuleReused}}}).Maybe()
+			mockPasswords.On("Remember", mock.AnythingOfType("*models.User")).Return(nil).Maybe()
+
+			service := NewUserService(mockRepo, &MockTokenService{}, &MockVerificationService{}, &MockPasswordResetService{}, &MockMFAService{}, &MockRoleService{}, &MockLockoutService{}, mockPasswords, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+			user, err := service.ChangePassword(1, tt.oldPassword, "newpassword123")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, user)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_Reauthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		password      string
+		code          string
+		mfaEnabled    bool
+		lockoutErr    error
+		codeErr       error
+		expectedAMR   []string
+		expectFailure bool
+		// noAttempt requests are refused before an attempt is reserved
+		noAttempt     bool
+		expectedError error
+	}{
+		{name: "password", password: "password123", expectedAMR: []string{models.AMRPassword}},
+		{name: "totp code", code: "123456", expectedAMR: []string{models.AMROTP}},
+		{name: "password and code", password: "password123", code: "123456", expectedAMR: []string{models.AMRPassword, models.AMROTP, models.AMRMultiFactor}},
+		{name: "wrong password", password: "wrongpassword", expectFailure: true, expectedError: ErrInvalidCredentials},
+		{name: "wrong code", code: "000000", codeErr: ErrInvalidMFACode, expectFailure: true, expectedError: ErrInvalidMFACode},
+		{name: "nothing given", noAttempt: true, expectedError: ErrInvalidCredentials},
+		{name: "password alone with two-factor enabled", password: "password123", mfaEnabled: true, noAttempt: true, expectedError: ErrMFACodeRequired},
+		{name: "password and code with two-factor enabled", password: "password123", code: "123456", mfaEnabled: true, expectedAMR: []string{models.AMRPassword, models.AMROTP, models.AMRMultiFactor}},
+		{name: "locked out", password: "password123", lockoutErr: &RetryError{Err: ErrAccountLocked, RetryAfter: time.Minute}, expectedError: ErrAccountLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{
+				ID:       1,
+				Email:    "test@example.com",
+				Password: "$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m", // "password123"
+			}
+			if tt.mfaEnabled {
+				now := time.Now()
+				user.MFAEnabledAt = &now
+			}
+			mockRepo := &MockUserRepository{}
+			mockTokens := &MockTokenService{}
+			mockMFA := &MockMFAService{}
+			mockLockout := &MockLockoutService{}
+			mockRepo.On("FindByID", user.ID).Return(user, nil)
+			if !tt.noAttempt {
+				mockLockout.On("Reserve", user.Email, "203.0.113.7").Return(tt.lockoutErr)
+			}
+			if tt.code != "" {
+				mockMFA.On("VerifyCode", user, tt.code).Return(tt.codeErr).Maybe()
+			}
+			if tt.expectFailure {
+				mockLockout.On("RecordFailure", user.Email).Return(nil)
+			}
+			if tt.expectedAMR != nil {
+				mockLockout.On("RecordSuccess", user.Email, "203.0.113.7").Return(nil)
+				mockTokens.On("Elevate", user, "session-1", tt.expectedAMR).Return(&TokenPair{AccessToken: "elevated"}, nil)
+			}
+
+			service := NewUserService(mockRepo, mockTokens, &MockVerificationService{}, &MockPasswordResetService{}, mockMFA, &MockRoleService{}, mockLockout, &MockPasswordPolicyService{}, &MockMagicLinkService{}, &MockSocialLoginService{}, false)
+			pair, err := service.Reauthenticate(user.ID, "session-1", tt.password, tt.code, "203.0.113.7")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, pair)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "elevated", pair.AccessToken)
+			}
+			mockTokens.AssertExpectations(t)
+			mockLockout.AssertExpectations(t)
 		})
 	}
 }
 
 func TestNewUserService(t *testing.T) {
 	mockRepo := &MockUserRepository{}
-	service := NewUserService(mockRepo, "test-secret", 24)
+	mockTokens := &MockTokenService{}
+	mockVerifier := &MockVerificationService{}
+	mockResets := &MockPasswordResetService{}
+	mockMFA := &MockMFAService{}
+	mockRoles := &MockRoleService{}
+	mockLockout := &MockLockoutService{}
+	mockPasswords := &MockPasswordPolicyService{}
+	mockMagicLinks := &MockMagicLinkService{}
+	mockSocial := &MockSocialLoginService{}
+	service := NewUserService(mockRepo, mockTokens, mockVerifier, mockResets, mockMFA, mockRoles, mockLockout, mockPasswords, mockMagicLinks, mockSocial, true)
 
 	assert.NotNil(t, service)
 	assert.Equal(t, mockRepo, service.repo)
-	assert.Equal(t, "test-secret", service.jwtSecret)
-	assert.Equal(t, int64(24*60*60*1000000000), int64(service.expDuration)) // 24 hours in nanoseconds
+	assert.Equal(t, mockTokens, service.tokens)
+	assert.Equal(t, mockVerifier, service.verifier)
+	assert.Equal(t, mockResets, service.resets)
+	assert.Equal(t, mockMFA, service.mfa)
+	assert.Equal(t, mockRoles, service.roles)
+	assert.Equal(t, mockLockout, service.lockout)
+	assert.Equal(t, mockPasswords, service.passwords)
+	assert.Equal(t, mockMagicLinks, service.magicLinks)
+	assert.Equal(t, mockSocial, service.social)
+	assert.True(t, service.requireVerified)
 }
 
 // MockUserRepository is a mock implementation of UserRepository interface
@@ -203,6 +803,29 @@ func (m *MockUserRepository) FindByID(id uint) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Update(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) List(q repositories.UserQuery) ([]models.User, int64, error) {
+	args := m.Called(q)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 // MockUserService is a mock implementation of UserServiceInterface interface
 type MockUserService struct {
 	mock.Mock
@@ -219,12 +842,294 @@ func (m *MockUserService) Register(name, email, password string) (*models.User,
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) Authenticate(email, password string) (string, *models.User, error) {
-	args := m.Called(email, password)
-	if args.Get(1) == nil {
-		return args.String(0), nil, args.Error(2)
+func (m *MockUserService) RegisterVerified(name, email, password string, create func(u *models.User) error) (*models.User, error) {
+	args := m.Called(name, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	u := args.Get(0).(*models.User)
+	if err := create(u); err != nil {
+		return nil, err
+	}
+	return u, args.Error(1)
+}
+
+func (m *MockUserService) Authenticate(email, password, clientIP, userAgent string) (*LoginResult, error) {
+	args := m.Called(email, password, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) RefreshTokens(refreshToken string) (*TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockUserService) Logout(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	args := m.Called(userID, sessionID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LogoutAll(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResendVerification(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForgotPassword(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetPassword(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UnlockAccount(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestMagicLink(email, clientIP, userAgent string) error {
+	args := m.Called(email, clientIP, userAgent)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LoginWithMagicLink(token, clientIP, userAgent string) (*LoginResult, error) {
+	args := m.Called(token, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) LoginWithProvider(provider, state, code, clientIP, userAgent string) (*LoginResult, error) {
+	args := m.Called(provider, state, code, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockUserService) GetProfile(userID uint) (*models.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) UpdateProfile(userID uint, update ProfileUpdate) (*models.User, error) {
+	args := m.Called(userID, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(userID uint, oldPassword, newPassword string) (*models.User, error) {
+	args := m.Called(userID, oldPassword, newPassword)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) ChangeEmail(userID uint, email string) (*models.User, error) {
+	args := m.Called(userID, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) DeleteAccount(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) Reauthenticate(userID uint, sessionID, password, code, clientIP string) (*TokenPair, error) {
+	args := m.Called(userID, sessionID, password, code, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+// MockTokenService is a mock implementation of TokenServiceInterface interface
+type MockTokenService struct {
+	mock.Mock
+}
+
+// Ensure MockTokenService implements TokenServiceInterface interface
+var _ TokenServiceInterface = (*MockTokenService)(nil)
+
+func (m *MockTokenService) IssueTokens(u *models.User, amr []string, clientIP, userAgent string) (*TokenPair, error) {
+	args := m.Called(u, amr, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Elevate(u *models.User, sessionID string, amr []string) (*TokenPair, error) {
+	args := m.Called(u, sessionID, amr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) SwitchOrganization(u *models.User, sessionID string, orgID uint) (*TokenPair, error) {
+	args := m.Called(u, sessionID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	args := m.Called(refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TokenPair), args.Error(1)
+}
+
+func (m *MockTokenService) Revoke(userID uint, sessionID, tokenID string, expiresAt time.Time) error {
+	args := m.Called(userID, sessionID, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) RevokeAll(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) Sessions(userID uint) ([]models.Session, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockTokenService) RevokeSession(userID uint, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockTokenService) TouchSession(userID uint, sessionID, clientIP string) (bool, error) {
+	args := m.Called(userID, sessionID, clientIP)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenService) AuthenticateCookie(value, clientIP string) (*models.Session, *models.User, error) {
+	args := m.Called(value, clientIP)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.Session), args.Get(1).(*models.User), args.Error(2)
+}
+
+// MockVerificationService is a mock implementation of VerificationServiceInterface interface
+type MockVerificationService struct {
+	mock.Mock
+}
+
+// Ensure MockVerificationService implements VerificationServiceInterface interface
+var _ VerificationServiceInterface = (*MockVerificationService)(nil)
+
+func (m *MockVerificationService) SendVerification(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) Verify(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockVerificationService) Resend(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+// MockPasswordResetService is a mock implementation of PasswordResetServiceInterface interface
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+// Ensure MockPasswordResetService implements PasswordResetServiceInterface interface
+var _ PasswordResetServiceInterface = (*MockPasswordResetService)(nil)
+
+func (m *MockPasswordResetService) RequestReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) Reset(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+// MockMFAService is a mock implementation of MFAServiceInterface interface
+type MockMFAService struct {
+	mock.Mock
+}
+
+// Ensure MockMFAService implements MFAServiceInterface interface
+var _ MFAServiceInterface = (*MockMFAService)(nil)
+
+func (m *MockMFAService) EnrollTOTP(userID uint) (*TOTPEnrollment, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) ConfirmTOTP(userID uint, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
-	return args.String(0), args.Get(1).(*models.User), args.Error(2)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) DisableTOTP(userID uint, password, code, clientIP string) error {
+	args := m.Called(userID, password, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) Challenge(u *models.User) (string, int64, error) {
+	args := m.Called(u)
+	return args.String(0), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockMFAService) CompleteLogin(challenge, code, clientIP, userAgent string) (*LoginResult, error) {
+	args := m.Called(challenge, code, clientIP, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockMFAService) VerifyCode(u *models.User, code string) error {
+	args := m.Called(u, code)
+	return args.Error(0)
 }
 
 // TestMockUserService tests the mock service implementation
@@ -240,11 +1145,10 @@ func TestMockUserService(t *testing.T) {
 	mockService.AssertExpectations(t)
 
 	// Test Authenticate
-	token := "test-token"
-	mockService.On("Authenticate", "test@example.com", "password").Return(token, expectedUser, nil)
-	returnedToken, returnedUser, err := mockService.Authenticate("test@example.com", "password")
+	result := &LoginResult{User: expectedUser, Tokens: &TokenPair{AccessToken: "test-token", RefreshToken: "test-refresh"}}
+	mockService.On("Authenticate", "test@example.com", "password", "127.0.0.1", "test-agent").Return(result, nil)
+	returnedResult, err := mockService.Authenticate("test@example.com", "password", "127.0.0.1", "test-agent")
 	assert.NoError(t, err)
-	assert.Equal(t, token, returnedToken)
-	assert.Equal(t, expectedUser, returnedUser)
+	assert.Equal(t, result, returnedResult)
 	mockService.AssertExpectations(t)
 }
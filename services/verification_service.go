@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+// VerificationService issues and redeems email verification tokens
+type VerificationService struct {
+	users  repositories.UserRepository
+	tokens repositories.OneTimeTokenRepository
+	mailer EmailServiceInterface
+	ttl    time.Duration
+}
+
+// Ensure VerificationService implements VerificationServiceInterface interface
+var _ VerificationServiceInterface = (*VerificationService)(nil)
+
+func NewVerificationService(users repositories.UserRepository, tokens repositories.OneTimeTokenRepository, mailer EmailServiceInterface, ttlHours int) *VerificationService {
+	return &VerificationService{
+		users:  users,
+		tokens: tokens,
+		mailer: mailer,
+		ttl:    time.Duration(ttlHours) * time.Hour,
+	}
+}
+
+// SendVerification mails a new verification link to the user. Links sent
+// earlier stop working so only the latest email can be used.
+func (s *VerificationService) SendVerification(u *models.User) error {
+	if err := s.tokens.ConsumeAllForUser(u.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	raw, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	t := &models.OneTimeToken{
+		UserID:    u.ID,
+		Purpose:   models.TokenPurposeEmailVerification,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.tokens.Create(t); err != nil {
+		return err
+	}
+
+	return s.mailer.SendVerificationEmail(u.Email, raw)
+}
+
+// Verify redeems a verification token and marks the user's email as verified
+func (s *VerificationService) Verify(token string) error {
+	t, err := s.tokens.FindByHash(utils.HashToken(token), models.TokenPurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	consumed, err := s.tokens.Consume(t.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+
+	u, err := s.users.FindByID(t.UserID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	return s.users.Update(u)
+}
+
+// Resend mails a new verification link. Unknown and already verified
+// addresses are silently ignored so the endpoint cannot be used to find
+// out which emails are registered.
+func (s *VerificationService) Resend(email string) error {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if u.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.SendVerification(u)
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"temp/models"
+	"temp/repositories"
+	"temp/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerificationService_SendVerification(t *testing.T) {
+	mockUsers := &MockUserRepository{}
+	mockTokens := &MockOneTimeTokenRepository{}
+	mockMailer := &MockEmailService{}
+	user := &models.User{ID: 1, Email: "test@example.com"}
+
+	var stored *models.OneTimeToken
+	var mailed string
+	mockTokens.On("ConsumeAllForUser", user.ID, models.TokenPurposeEmailVerification).Return(nil)
+	mockTokens.On("Create", mock.AnythingOfType("*models.OneTimeToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.OneTimeToken)
+	}).Return(nil)
+	mockMailer.On("SendVerificationEmail", user.Email, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		mailed = args.String(1)
+	}).Return(nil)
+
+	service := NewVerificationService(mockUsers, mockTokens, mockMailer, 24)
+	err := service.SendVerification(user)
+
+	assert.NoError(t, err)
+	// Only the hash of the mailed token may be persisted
+	assert.Equal(t, utils.HashToken(mailed), stored.TokenHash)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, models.TokenPurposeEmailVerification, stored.Purpose)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, time.Minute)
+	mockTokens.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestVerificationService_Verify(t *testing.T) {
+	hash := utils.HashToken("token")
+	usedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		setupMock   func(*MockUserRepository, *MockOneTimeTokenRepository)
+		expectedErr error
+	}{
+		{
+			name: "successful verification",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeEmailVerification).Return(ot, nil)
+				mockTokens.On("Consume", uint(3)).Return(true, nil)
+				mockUsers.On("FindByID", uint(1)).Return(&models.User{ID: 1}, nil)
+				mockUsers.On("Update", mock.MatchedBy(func(u *models.User) bool {
+					return u.ID == 1 && u.EmailVerifiedAt != nil
+				})).Return(nil)
+			},
+		},
+		{
+			name: "unknown token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				mockTokens.On("FindByHash", hash, models.TokenPurposeEmailVerification).Return(nil, repositories.ErrTokenNotFound)
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "expired token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(-time.Hour)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeEmailVerification).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "used token",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeEmailVerification).Return(ot, nil)
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			name: "token redeemed concurrently",
+			setupMock: func(mockUsers *MockUserRepository, mockTokens *MockOneTimeTokenRepository) {
+				ot := &models.OneTimeToken{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+				mockTokens.On("FindByHash", hash, models.TokenPurposeEmailVerification).Return(ot, nil)
+				mockTokens.On("Consume", uint(3)).Return(false, nil)
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockTokens := &MockOneTimeTokenRepository{}
+			tt.setupMock(mockUsers, mockTokens)
+
+			service := NewVerificationService(mockUsers, mockTokens, &MockEmailService{}, 24)
+			err := service.Verify("token")
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockUsers.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerificationService_Resend(t *testing.T) {
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		findErr    error
+		expectSend bool
+	}{
+		{name: "unverified user gets a new link", user: &models.User{ID: 1, Email: "test@example.com"}, expectSend: true},
+		{name: "verified user is ignored", user: &models.User{ID: 1, Email: "test@example.com", EmailVerifiedAt: &verifiedAt}},
+		{name: "unknown email is ignored", findErr: repositories.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers := &MockUserRepository{}
+			mockTokens := &MockOneTimeTokenRepository{}
+			mockMailer := &MockEmailService{}
+			if tt.user != nil {
+				mockUsers.On("FindByEmail", "test@example.com").Return(tt.user, nil)
+			} else {
+				mockUsers.On("FindByEmail", "test@example.com").Return(nil, tt.findErr)
+			}
+			if tt.expectSend {
+				mockTokens.On("ConsumeAllForUser", uint(1), models.TokenPurposeEmailVerification).Return(nil)
+				mockTokens.On("Create", mock.AnythingOfType("*models.OneTimeToken")).Return(nil)
+				mockMailer.On("SendVerificationEmail", "test@example.com", mock.AnythingOfType("string")).Return(nil)
+			}
+
+			service := NewVerificationService(mockUsers, mockTokens, mockMailer, 24)
+			err := service.Resend("test@example.com")
+
+			assert.NoError(t, err)
+			mockUsers.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+// MockOneTimeTokenRepository is a mock implementation of OneTimeTokenRepository interface
+type MockOneTimeTokenRepository struct {
+	mock.Mock
+}
+
+// Ensure MockOneTimeTokenRepository implements OneTimeTokenRepository interface
+var _ repositories.OneTimeTokenRepository = (*MockOneTimeTokenRepository)(nil)
+
+func (m *MockOneTimeTokenRepository) Migrate() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) Create(t *models.OneTimeToken) error {
+	args := m.Called(t)
+	return args.Error(0)
+}
+
+func (m *MockOneTimeTokenRepository) FindByHash(hash, purpose string) (*models.OneTimeToken, error) {
+	args := m.Called(hash, purpose)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OneTimeToken), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) Consume(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOneTimeTokenRepository) ConsumeAllForUser(userID uint, purpose string) error {
+	args := m.Called(userID, purpose)
+	return args.Error(0)
+}
+
+// MockEmailService is a mock implementation of EmailServiceInterface interface
+type MockEmailService struct {
+	mock.Mock
+}
+
+// Ensure MockEmailService implements EmailServiceInterface interface
+var _ EmailServiceInterface = (*MockEmailService)(nil)
+
+func (m *MockEmailService) SendVerificationEmail(to, verificationToken string) error {
+	args := m.Called(to, verificationToken)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendPasswordResetEmail(to, resetToken string) error {
+	args := m.Called(to, resetToken)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendUnlockEmail(to, unlockToken string) error {
+	args := m.Called(to, unlockToken)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendMagicLinkEmail(to, loginToken string) error {
+	args := m.Called(to, loginToken)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendInvitationEmail(to, organization, role, invitationToken string) error {
+	args := m.Called(to, organization, role, invitationToken)
+	return args.Error(0)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"temp/middlewares"
 	"temp/models"
 
 	"github.com/gin-gonic/gin"
@@ -57,7 +58,7 @@ func CreateTestContext(req *http.Request) (*gin.Context, *httptest.ResponseRecor
 	return c, w
 }
 
-// SetUserInContext sets a user ID in the gin context for testing
+// SetUserInContext sets an authenticated principal for the user in the gin context for testing
 func SetUserInContext(c *gin.Context, userID uint) {
-	c.Set("user_id", userID)
+	middlewares.SetPrincipal(c, &middlewares.Principal{UserID: userID})
 }
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key so it can be told apart from a JWT
+// and found by secret scanners
+const APIKeyPrefix = "pat_"
+
+// GenerateAPIKey returns a new API key of the form pat_<id>_<secret> and
+// its displayable prefix pat_<id>
+func GenerateAPIKey() (key, prefix string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
+
+// IsAPIKey reports whether a bearer credential looks like an API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, IsAPIKey(key))
+	assert.True(t, strings.HasPrefix(key, prefix+"_"))
+	assert.Len(t, prefix, len(APIKeyPrefix)+8)
+
+	other, _, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestIsAPIKey(t *testing.T) {
+	assert.True(t, IsAPIKey("pat_0a1b2c3d_secret"))
+	assert.False(t, IsAPIKey("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+}
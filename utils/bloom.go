@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomMagic starts every bloom filter file
+const bloomMagic = "PWBF"
+
+var ErrInvalidBloomFilter = errors.New("invalid bloom filter file")
+
+// BloomFilter is a compact set of strings that answers membership queries
+// with a configurable false positive rate and no false negatives. It is
+// used to check passwords against a breached-password list without
+// keeping the list in memory.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint32
+}
+
+// NewBloomFilter sizes a filter for n entries at the false positive rate p
+func NewBloomFilter(n int, p float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Add inserts s into the filter
+func (f *BloomFilter) Add(s string) {
+	h1, h2 := bloomHashes(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains reports whether s has probably been added to the filter
+func (f *BloomFilter) Contains(s string) bool {
+	h1, h2 := bloomHashes(s)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo stores the filter as the magic "PWBF", the number of hash
+// functions k (uint32), the number of bits m (uint64) and the bits, all
+// little endian
+func (f *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 16)
+	copy(header, bloomMagic)
+	binary.LittleEndian.PutUint32(header[4:], f.k)
+	binary.LittleEndian.PutUint64(header[8:], f.m)
+	if _, err := bw.Write(header); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, f.bits); err != nil {
+		return 0, err
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+	return int64(len(header) + 8*len(f.bits)), nil
+}
+
+// ReadBloomFilter reads a filter written by WriteTo
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, ErrInvalidBloomFilter
+	}
+	if string(header[:4]) != bloomMagic {
+		return nil, ErrInvalidBloomFilter
+	}
+	f := &BloomFilter{
+		k: binary.LittleEndian.Uint32(header[4:]),
+		m: binary.LittleEndian.Uint64(header[8:]),
+	}
+	if f.k == 0 || f.m == 0 || f.m > 1<<40 {
+		return nil, ErrInvalidBloomFilter
+	}
+	f.bits = make([]uint64, (f.m+63)/64)
+	if err := binary.Read(br, binary.LittleEndian, f.bits); err != nil {
+		return nil, ErrInvalidBloomFilter
+	}
+	return f, nil
+}
+
+// LoadBloomFilter reads a filter from a file written by WriteTo
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBloomFilter(file)
+}
+
+// bloomHashes derives the two hashes of the double hashing scheme from
+// SHA-256. h2 is odd so it never collapses every probe onto one bit.
+func bloomHashes(s string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(s))
+	return binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("password%d", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.Contains(fmt.Sprintf("password%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.Contains(fmt.Sprintf("other%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 300)
+}
+
+func TestBloomFilter_WriteAndRead(t *testing.T) {
+	f := NewBloomFilter(10, 0.001)
+	f.Add("123456")
+	f.Add("qwerty")
+
+	var buf bytes.Buffer
+	_, err := f.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	loaded, err := ReadBloomFilter(&buf)
+	assert.NoError(t, err)
+	assert.True(t, loaded.Contains("123456"))
+	assert.True(t, loaded.Contains("qwerty"))
+	assert.False(t, loaded.Contains("correct horse battery staple"))
+
+	_, err = ReadBloomFilter(bytes.NewReader([]byte("not a filter")))
+	assert.ErrorIs(t, err, ErrInvalidBloomFilter)
+}
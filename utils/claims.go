@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidClaims = errors.New("invalid token claims")
+
+// Claims is the payload of an access token. The user ID is carried in the
+// standard sub claim, the session (refresh token family) in sid and the
+// user's roles and the permissions they grant in roles and perms.
+// auth_time and amr tell when and how the user last authenticated.
+// org_id and org_role name the organization the session is acting in and
+// the user's role there.
+// Tokens issued to OAuth clients carry the client in client_id and the
+// granted scopes, space separated, in scope instead of a session and roles;
+// with the client credentials grant the subject is the client itself.
+type Claims struct {
+	SessionID   string           `json:"sid,omitempty"`
+	Roles       []string         `json:"roles,omitempty"`
+	Permissions []string         `json:"perms,omitempty"`
+	ClientID    string           `json:"client_id,omitempty"`
+	Scope       string           `json:"scope,omitempty"`
+	AuthTime    *jwt.NumericDate `json:"auth_time,omitempty"`
+	AMR         []string         `json:"amr,omitempty"`
+	OrgID       uint             `json:"org_id,omitempty"`
+	OrgRole     string           `json:"org_role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IDClaims is the payload of an OpenID Connect ID token. The audience is
+// the client the token was issued to.
+type IDClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID stored in the subject claim
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidClaims
+	}
+	return uint(id), nil
+}
+
+// TokenManager signs access tokens and validates them against the
+// configured issuer, audience, clock skew and algorithm allowlist
+type TokenManager struct {
+	keys       *KeyManager
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	algorithms []string
+}
+
+// NewTokenManager creates a token manager. The allowlist must contain the
+// algorithm of the key manager, otherwise our own tokens would be rejected.
+func NewTokenManager(keys *KeyManager, issuer, audience string, leeway time.Duration, algorithms []string) (*TokenManager, error) {
+	if !containsString(algorithms, keys.algorithm) {
+		return nil, fmt.Errorf("allowed jwt algorithms %v do not include signing algorithm %s", algorithms, keys.algorithm)
+	}
+	return &TokenManager{
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+		leeway:     leeway,
+		algorithms: algorithms,
+	}, nil
+}
+
+// Sign stamps the issuer and audience on the claims and signs them
+func (m *TokenManager) Sign(claims *Claims) (string, error) {
+	if m.issuer != "" {
+		claims.Issuer = m.issuer
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	return m.keys.Sign(claims)
+}
+
+// SignIDToken stamps the issuer on ID token claims and signs them
+func (m *TokenManager) SignIDToken(claims *IDClaims) (string, error) {
+	if m.issuer != "" {
+		claims.Issuer = m.issuer
+	}
+	return m.keys.Sign(claims)
+}
+
+// AcceptedUntil returns when a token expiring at exp stops being accepted,
+// which is later than exp by the allowed clock skew. A revoked token has to
+// stay denylisted until then.
+func (m *TokenManager) AcceptedUntil(exp time.Time) time.Time {
+	return exp.Add(m.leeway)
+}
+
+// Parse verifies a token and returns its claims. Tokens must be signed with
+// an allowed algorithm, carry a user ID as subject, an ID and expiry, and
+// match the configured issuer and audience.
+func (m *TokenManager) Parse(token string) (*Claims, error) {
+	claims, err := m.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := claims.UserID(); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Verify checks a token like Parse but also accepts tokens whose subject
+// is not a user, such as those issued with the client credentials grant
+func (m *TokenManager) Verify(token string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(m.algorithms),
+		jwt.WithLeeway(m.leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+	tok, err := jwt.ParseWithClaims(token, claims, m.keys.Keyfunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !tok.Valid || claims.ID == "" || claims.Subject == "" {
+		return nil, ErrInvalidClaims
+	}
+	return claims, nil
+}
@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenManager(t *testing.T, issuer, audience string) *TokenManager {
+	keys, err := NewKeyManager(AlgorithmHS256, "", "test-secret", 0, 0)
+	assert.NoError(t, err)
+	tokens, err := NewTokenManager(keys, issuer, audience, 30*time.Second, []string{AlgorithmHS256})
+	assert.NoError(t, err)
+	return tokens
+}
+
+func testClaims(exp time.Time) *Claims {
+	return &Claims{
+		SessionID: "session-1",
+		Roles:     []string{"admin"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "42",
+			ID:        "jti-1",
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	}
+}
+
+func TestTokenManager_SignAndParse(t *testing.T) {
+	tokens := newTestTokenManager(t, "issuer", "audience")
+
+	signed, err := tokens.Sign(testClaims(time.Now().Add(time.Minute)))
+	assert.NoError(t, err)
+
+	claims, err := tokens.Parse(signed)
+	assert.NoError(t, err)
+	userID, err := claims.UserID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), userID)
+	assert.Equal(t, "session-1", claims.SessionID)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.Equal(t, "issuer", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"audience"}, claims.Audience)
+}
+
+func TestTokenManager_Parse(t *testing.T) {
+	tokens := newTestTokenManager(t, "issuer", "audience")
+	keys := tokens.keys
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{
+			name: "expired within clock skew",
+			token: func() string {
+				signed, _ := tokens.Sign(testClaims(time.Now().Add(-10 * time.Second)))
+				return signed
+			},
+			wantErr: false,
+		},
+		{
+			name: "expired beyond clock skew",
+			token: func() string {
+				signed, _ := tokens.Sign(testClaims(time.Now().Add(-time.Minute)))
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				other := newTestTokenManager(t, "someone-else", "audience")
+				signed, _ := other.Sign(testClaims(time.Now().Add(time.Minute)))
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				other := newTestTokenManager(t, "issuer", "another-service")
+				signed, _ := other.Sign(testClaims(time.Now().Add(time.Minute)))
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing token ID",
+			token: func() string {
+				claims := testClaims(time.Now().Add(time.Minute))
+				claims.ID = ""
+				signed, _ := tokens.Sign(claims)
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing expiry",
+			token: func() string {
+				claims := testClaims(time.Now())
+				claims.ExpiresAt = nil
+				signed, _ := tokens.Sign(claims)
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "algorithm outside the allowlist",
+			token: func() string {
+				claims := testClaims(time.Now().Add(time.Minute))
+				claims.Issuer = "issuer"
+				claims.Audience = jwt.ClaimStrings{"audience"}
+				signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(keys.secret)
+				return signed
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsigned token",
+			token: func() string {
+				claims := testClaims(time.Now().Add(time.Minute))
+				claims.Issuer = "issuer"
+				claims.Audience = jwt.ClaimStrings{"audience"}
+				signed, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return signed
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tokens.Parse(tt.token())
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, claims)
+			}
+		})
+	}
+}
+
+func TestTokenManager_VerifyClientToken(t *testing.T) {
+	tokens := newTestTokenManager(t, "issuer", "audience")
+
+	claims := testClaims(time.Now().Add(time.Minute))
+	claims.Subject = "reporting-app"
+	claims.ClientID = "reporting-app"
+	claims.Scope = "profile:read"
+	signed, err := tokens.Sign(claims)
+	assert.NoError(t, err)
+
+	// Tokens without a user are not accepted as logins
+	_, err = tokens.Parse(signed)
+	assert.ErrorIs(t, err, ErrInvalidClaims)
+
+	verified, err := tokens.Verify(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "reporting-app", verified.ClientID)
+	assert.Equal(t, "profile:read", verified.Scope)
+}
+
+func TestNewTokenManager_AllowlistMustIncludeSigningAlgorithm(t *testing.T) {
+	keys, err := NewKeyManager(AlgorithmHS256, "", "test-secret", 0, 0)
+	assert.NoError(t, err)
+
+	_, err = NewTokenManager(keys, "issuer", "audience", 0, []string{AlgorithmRS256})
+	assert.Error(t, err)
+}
+
+func TestGenerateJWT(t *testing.T) {
+	tokens := newTestTokenManager(t, "issuer", "audience")
+
+	signed, err := GenerateJWT(tokens, 7, 1)
+	assert.NoError(t, err)
+
+	claims, err := tokens.Parse(signed)
+	assert.NoError(t, err)
+	userID, _ := claims.UserID()
+	assert.Equal(t, uint(7), userID)
+}
@@ -1,36 +1,112 @@
 package utils
 
 import (
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// GenerateHash creates a bcrypt hash from the provided password
+var (
+	// passwordHasher hashes new passwords
+	passwordHasher PasswordHasher = DefaultArgon2id
+	// passwordVerifiers check stored hashes of every supported algorithm,
+	// including the legacy ones of imported users
+	passwordVerifiers = []PasswordVerifier{DefaultArgon2id, DefaultBcrypt, DefaultScrypt, PBKDF2SHA256Verifier{}, SaltedSHA1Verifier{}}
+
+	// dummyHash is a hash made by dummyHasher, compared against when there
+	// is no stored hash
+	dummyMu     sync.Mutex
+	dummyHash   string
+	dummyHasher PasswordHasher
+)
+
+// SetPasswordHasher selects the algorithm and parameters of new password
+// hashes. It is meant to be called once at startup.
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasher = h
+}
+
+// GenerateHash creates a hash of the provided password with the configured hasher
 // Returns the hashed password as a string or an error if hashing fails
 func GenerateHash(pwd string) (string, error) {
-	b, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
+	return passwordHasher.Hash(pwd)
 }
 
-// CompareHash compares a password with its hash
+// CompareHash compares a password with its hash, whichever supported
+// algorithm made it
 // Returns true if the password matches the hash, false otherwise
 func CompareHash(pwd, hash string) bool {
-	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pwd)); err != nil {
-		return false
+	for _, v := range passwordVerifiers {
+		if v.Identifies(hash) {
+			return v.Verify(pwd, hash)
+		}
+	}
+	return false
+}
+
+// CompareDummyHash compares a password with a hash made by the configured
+// hasher. A login for an unknown account calls it to take as long as one
+// with a wrong password, so response times do not reveal which accounts
+// exist.
+func CompareDummyHash(pwd string) {
+	dummyMu.Lock()
+	if dummyHasher != passwordHasher {
+		if hash, err := passwordHasher.Hash("dummy password"); err == nil {
+			dummyHash, dummyHasher = hash, passwordHasher
+		}
 	}
-	return true
+	hash := dummyHash
+	dummyMu.Unlock()
+
+	CompareHash(pwd, hash)
 }
 
-func GenerateJWT(secret string, userID uint, expirationHours int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Duration(expirationHours) * time.Hour).Unix(),
+// IsSupportedHash reports whether CompareHash can verify passwords against
+// hash, which makes it fit to be imported
+func IsSupportedHash(hash string) bool {
+	for _, v := range passwordVerifiers {
+		if v.Identifies(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHash returns nil when CompareHash can verify passwords against hash
+// within the cost limits, ErrPasswordHashTooCostly when its parameters
+// exceed them and ErrInvalidPasswordHash otherwise
+func CheckHash(hash string) error {
+	for _, v := range passwordVerifiers {
+		if v.Identifies(hash) {
+			return v.Check(hash)
+		}
+	}
+	return ErrInvalidPasswordHash
+}
+
+// NeedsRehash reports whether a hash was made with another algorithm or
+// other parameters than the configured hasher uses
+func NeedsRehash(hash string) bool {
+	return !passwordHasher.Identifies(hash) || !passwordHasher.Current(hash)
+}
+
+// GenerateJWT signs a standalone access token for the user
+func GenerateJWT(tokens *TokenManager, userID uint, expirationHours int) (string, error) {
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expirationHours) * time.Hour)),
+		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return tokens.Sign(claims)
 }
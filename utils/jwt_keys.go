@@ -0,0 +1,469 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// KeyActivationDelay is how long a rotated key is published in the JWKS
+// before it starts signing, giving verifiers time to refresh their cache
+const KeyActivationDelay = 5 * time.Minute
+
+var (
+	ErrUnknownSigningKey       = errors.New("unknown signing key")
+	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
+	ErrNoSigningKey            = errors.New("no signing key available")
+	ErrInvalidJWK              = errors.New("invalid or unsupported JSON web key")
+)
+
+// SigningKey is a key pair loaded from the keys directory.
+// Its ID is the file name without the .pem extension and is
+// published as the kid header of every token it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	private   interface{}
+	public    interface{}
+}
+
+// JSONWebKey is the public part of a signing key in JWK format (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey decodes the key for verifying signatures. RSA, P-256 and
+// Ed25519 keys are supported, which covers what identity providers publish.
+func (k JSONWebKey) PublicKey() (interface{}, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() > math.MaxInt32 {
+			return nil, ErrInvalidJWK
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, ErrInvalidJWK
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidJWK
+		}
+		// Uncompressed point encoding: 0x04 || X || Y
+		point := append(append([]byte{4}, x...), y...)
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+		return key, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidJWK
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, ErrInvalidJWK
+}
+
+// JSONWebKeySet is the document served on /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// KeyManager signs tokens with the active key of the configured algorithm
+// and verifies them with any key still present in the keys directory.
+// With HS256 it falls back to the shared secret and publishes no keys.
+type KeyManager struct {
+	mu        sync.RWMutex
+	algorithm string
+	dir       string
+	secret    []byte
+	rotation  time.Duration
+	retention time.Duration
+	keys      []*SigningKey // newest first
+}
+
+// NewKeyManager loads the keys in dir, generating a first key when the
+// directory holds none for the configured algorithm
+func NewKeyManager(algorithm, dir, secret string, rotation, retention time.Duration) (*KeyManager, error) {
+	m := &KeyManager{
+		algorithm: algorithm,
+		dir:       dir,
+		secret:    []byte(secret),
+		rotation:  rotation,
+		retention: retention,
+	}
+
+	switch algorithm {
+	case AlgorithmHS256:
+		if secret == "" {
+			return nil, errors.New("jwt secret is required for HS256")
+		}
+		return m, nil
+	case AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %s", algorithm)
+	}
+
+	if dir == "" {
+		return nil, fmt.Errorf("jwt keys directory is required for %s", algorithm)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keys directory: %w", err)
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	if m.newestKey() == nil {
+		if err := m.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	if m.algorithm == AlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	}
+
+	key := m.activeKey()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	t := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	t.Header["kid"] = key.ID
+	return t.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key of a token from its kid header.
+// It is meant to be passed to jwt.Parse.
+func (m *KeyManager) Keyfunc(t *jwt.Token) (interface{}, error) {
+	if m.algorithm == AlgorithmHS256 {
+		if t.Method.Alg() != AlgorithmHS256 {
+			return nil, ErrUnexpectedSigningMethod
+		}
+		return m.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.keys {
+		if key.ID != kid {
+			continue
+		}
+		if t.Method.Alg() != key.Algorithm {
+			return nil, ErrUnexpectedSigningMethod
+		}
+		return key.public, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// JWKS returns the public keys that tokens may currently be signed with
+func (m *KeyManager) JWKS() JSONWebKeySet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := JSONWebKey{Kid: key.ID, Use: "sig", Alg: key.Algorithm}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// Reload re-reads the keys directory. Keys added by an operator or by
+// another instance become available and removed keys stop verifying.
+func (m *KeyManager) Reload() error {
+	if m.algorithm == AlgorithmHS256 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read keys directory: %w", err)
+	}
+
+	keys := make([]*SigningKey, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		key, err := loadSigningKey(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+// Rotate generates a new key of the configured algorithm. It is published
+// immediately and becomes the signing key after KeyActivationDelay; older
+// keys keep verifying until they are pruned.
+func (m *KeyManager) Rotate() error {
+	if m.algorithm == AlgorithmHS256 {
+		return nil
+	}
+
+	var private interface{}
+	var err error
+	switch m.algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	suffix, err := GenerateRandomToken(4)
+	if err != nil {
+		return err
+	}
+	id := time.Now().UTC().Format("20060102T150405Z") + "-" + suffix
+
+	// Write to a temporary file first so other instances never read half a key
+	tmp, err := os.CreateTemp(m.dir, ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := pem.Encode(tmp, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(m.dir, id+".pem")); err != nil {
+		return err
+	}
+
+	return m.Reload()
+}
+
+// RotateIfDue reloads the keys directory, rotates the active key once it is
+// older than the rotation period and deletes keys that were replaced longer
+// than the retention period ago
+func (m *KeyManager) RotateIfDue() error {
+	if m.algorithm == AlgorithmHS256 {
+		return nil
+	}
+	if err := m.Reload(); err != nil {
+		return err
+	}
+
+	newest := m.newestKey()
+	if newest == nil || (m.rotation > 0 && time.Since(newest.CreatedAt) > m.rotation) {
+		if err := m.Rotate(); err != nil {
+			return err
+		}
+	}
+
+	if m.retention <= 0 {
+		return nil
+	}
+	m.mu.RLock()
+	var expired []string
+	for i := 1; i < len(m.keys); i++ {
+		// A key stops signing once its successor is activated
+		if time.Since(m.keys[i-1].CreatedAt) > KeyActivationDelay+m.retention {
+			expired = append(expired, m.keys[i].ID)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, id := range expired {
+		if err := os.Remove(filepath.Join(m.dir, id+".pem")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if len(expired) > 0 {
+		return m.Reload()
+	}
+	return nil
+}
+
+// StartRotation calls RotateIfDue every interval in the background
+func (m *KeyManager) StartRotation(interval time.Duration) {
+	if m.algorithm == AlgorithmHS256 || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := m.RotateIfDue(); err != nil {
+				log.Printf("Failed to rotate signing keys: %v", err)
+			}
+		}
+	}()
+}
+
+// activeKey returns the newest key of the configured algorithm that has been
+// published for at least KeyActivationDelay, or the newest key when none has
+func (m *KeyManager) activeKey() *SigningKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var newest *SigningKey
+	for _, key := range m.keys {
+		if key.Algorithm != m.algorithm {
+			continue
+		}
+		if newest == nil {
+			newest = key
+		}
+		if time.Since(key.CreatedAt) >= KeyActivationDelay {
+			return key
+		}
+	}
+	return newest
+}
+
+func (m *KeyManager) newestKey() *SigningKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.keys {
+		if key.Algorithm == m.algorithm {
+			return key
+		}
+	}
+	return nil
+}
+
+func loadSigningKey(path string) (*SigningKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		ID:        strings.TrimSuffix(filepath.Base(path), ".pem"),
+		CreatedAt: info.ModTime(),
+		private:   private,
+	}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm = AlgorithmRS256
+		key.public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+		key.public = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+	return key, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
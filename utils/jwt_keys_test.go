@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyManager_SignAndVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		kty       string
+	}{
+		{name: "RS256", algorithm: AlgorithmRS256, kty: "RSA"},
+		{name: "EdDSA", algorithm: AlgorithmEdDSA, kty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeyManager(tt.algorithm, t.TempDir(), "", 0, 0)
+			assert.NoError(t, err)
+
+			signed, err := keys.Sign(jwt.MapClaims{"user_id": 1, "exp": time.Now().Add(time.Minute).Unix()})
+			assert.NoError(t, err)
+
+			tok, err := jwt.Parse(signed, keys.Keyfunc, jwt.WithValidMethods([]string{keys.algorithm}))
+			assert.NoError(t, err)
+			assert.True(t, tok.Valid)
+			assert.Equal(t, tt.algorithm, tok.Method.Alg())
+
+			// The kid header must point at a published key
+			jwks := keys.JWKS()
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tok.Header["kid"], jwks.Keys[0].Kid)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+
+			// The published key verifies the token on its own
+			public, err := jwks.Keys[0].PublicKey()
+			assert.NoError(t, err)
+			_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return public, nil })
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyManager_RejectsForeignTokens(t *testing.T) {
+	keys, err := NewKeyManager(AlgorithmEdDSA, t.TempDir(), "", 0, 0)
+	assert.NoError(t, err)
+	other, err := NewKeyManager(AlgorithmEdDSA, t.TempDir(), "", 0, 0)
+	assert.NoError(t, err)
+
+	// Signed by a key we do not know
+	signed, err := other.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+	_, err = jwt.Parse(signed, keys.Keyfunc, jwt.WithValidMethods([]string{keys.algorithm}))
+	assert.ErrorIs(t, err, ErrUnknownSigningKey)
+
+	// Signed with the shared secret algorithm
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": 1}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	_, err = jwt.Parse(hmac, keys.Keyfunc, jwt.WithValidMethods([]string{keys.algorithm}))
+	assert.Error(t, err)
+}
+
+func TestKeyManager_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := NewKeyManager(AlgorithmEdDSA, dir, "", time.Hour, time.Hour)
+	assert.NoError(t, err)
+
+	// Age the first key past the rotation period
+	first := keys.JWKS().Keys[0].Kid
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, first+".pem"), old, old))
+	assert.NoError(t, keys.Reload())
+	oldToken, err := keys.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+
+	assert.NoError(t, keys.RotateIfDue())
+	jwks := keys.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+
+	// The new key is published but does not sign until it has been activated
+	tok, err := jwt.Parse(oldToken, keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, first, tok.Header["kid"])
+	signed, err := keys.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+	tok, err = jwt.Parse(signed, keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, first, tok.Header["kid"])
+
+	// Once the successor is past activation and retention, the old key is removed
+	second := jwks.Keys[0].Kid
+	older := time.Now().Add(-(KeyActivationDelay + 2*time.Hour))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, first+".pem"), older.Add(-time.Hour), older.Add(-time.Hour)))
+	newer := time.Now().Add(-(KeyActivationDelay + 90*time.Minute))
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, second+".pem"), newer, newer))
+	keys.rotation = 0
+	assert.NoError(t, keys.RotateIfDue())
+
+	jwks = keys.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, second, jwks.Keys[0].Kid)
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.ErrorIs(t, err, ErrUnknownSigningKey)
+}
+
+func TestKeyManager_HS256(t *testing.T) {
+	keys, err := NewKeyManager(AlgorithmHS256, "", "test-secret", 0, 0)
+	assert.NoError(t, err)
+
+	signed, err := keys.Sign(jwt.MapClaims{"user_id": 1})
+	assert.NoError(t, err)
+	tok, err := jwt.Parse(signed, keys.Keyfunc, jwt.WithValidMethods([]string{AlgorithmHS256}))
+	assert.NoError(t, err)
+	assert.True(t, tok.Valid)
+	assert.Empty(t, keys.JWKS().Keys)
+
+	_, err = NewKeyManager(AlgorithmHS256, "", "", 0, 0)
+	assert.Error(t, err)
+}
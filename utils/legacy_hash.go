@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Legacy hashes can only be verified. Users imported with one get a
+// current hash at their first successful login.
+
+// PBKDF2SHA256Verifier checks hashes in the Django format
+// pbkdf2_sha256$<iterations>$<salt>$<base64 key>
+type PBKDF2SHA256Verifier struct{}
+
+// Ensure PBKDF2SHA256Verifier implements PasswordVerifier interface
+var _ PasswordVerifier = PBKDF2SHA256Verifier{}
+
+func (PBKDF2SHA256Verifier) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "pbkdf2_sha256$")
+}
+
+func (PBKDF2SHA256Verifier) Verify(password, hash string) bool {
+	iterations, salt, expected, err := parsePBKDF2SHA256(hash)
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+func (PBKDF2SHA256Verifier) Check(hash string) error {
+	_, _, _, err := parsePBKDF2SHA256(hash)
+	return err
+}
+
+func parsePBKDF2SHA256(hash string) (int, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 {
+		return 0, nil, nil, ErrInvalidPasswordHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return 0, nil, nil, ErrInvalidPasswordHash
+	}
+	expected, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return 0, nil, nil, ErrInvalidPasswordHash
+	}
+	if iterations > hashCostLimits.PBKDF2Iterations {
+		return 0, nil, nil, ErrPasswordHashTooCostly
+	}
+	return iterations, []byte(parts[2]), expected, nil
+}
+
+// SaltedSHA1Verifier checks hashes in the format sha1$<salt>$<hex digest>
+// where the digest is SHA-1 of the salt followed by the password
+type SaltedSHA1Verifier struct{}
+
+// Ensure SaltedSHA1Verifier implements PasswordVerifier interface
+var _ PasswordVerifier = SaltedSHA1Verifier{}
+
+func (SaltedSHA1Verifier) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "sha1$")
+}
+
+func (SaltedSHA1Verifier) Verify(password, hash string) bool {
+	salt, expected, err := parseSaltedSHA1(hash)
+	if err != nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(salt + password))
+	return subtle.ConstantTimeCompare(sum[:], expected) == 1
+}
+
+func (SaltedSHA1Verifier) Check(hash string) error {
+	_, _, err := parseSaltedSHA1(hash)
+	return err
+}
+
+func parseSaltedSHA1(hash string) (string, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 3 {
+		return "", nil, ErrInvalidPasswordHash
+	}
+	expected, err := hex.DecodeString(strings.ToLower(parts[2]))
+	if err != nil || len(expected) != sha1.Size {
+		return "", nil, ErrInvalidPasswordHash
+	}
+	return parts[1], expected, nil
+}
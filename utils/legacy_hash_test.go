@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyHashes(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		expected bool
+	}{
+		{name: "pbkdf2-sha256 match", hash: "pbkdf2_sha256$1000$somesalt$9uGciTK0YsFs8IX66F2YGyx+F/21bBVCbHT6pTGREzA=", password: "password123", expected: true},
+		{name: "pbkdf2-sha256 mismatch", hash: "pbkdf2_sha256$1000$somesalt$9uGciTK0YsFs8IX66F2YGyx+F/21bBVCbHT6pTGREzA=", password: "wrongpassword", expected: false},
+		{name: "pbkdf2-sha256 malformed", hash: "pbkdf2_sha256$many$somesalt$9uGciTK0YsFs8IX66F2YGyx+F/21bBVCbHT6pTGREzA=", password: "password123", expected: false},
+		{name: "salted sha1 match", hash: "sha1$abc12$952b96a80331ff38f3696eb26bdb4b21da124835", password: "password123", expected: true},
+		{name: "salted sha1 upper case digest", hash: "sha1$abc12$952B96A80331FF38F3696EB26BDB4B21DA124835", password: "password123", expected: true},
+		{name: "salted sha1 mismatch", hash: "sha1$abc12$952b96a80331ff38f3696eb26bdb4b21da124835", password: "wrongpassword", expected: false},
+		{name: "salted sha1 malformed", hash: "sha1$abc12$not-hex", password: "password123", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, IsSupportedHash(tt.hash))
+			assert.Equal(t, tt.expected, CompareHash(tt.password, tt.hash))
+			// Legacy hashes are always upgraded
+			assert.True(t, NeedsRehash(tt.hash))
+		})
+	}
+
+	assert.False(t, IsSupportedHash("md5$abc$def"))
+	assert.False(t, IsSupportedHash("plaintext"))
+}
@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Password hashing algorithms
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+	HashScrypt   = "scrypt"
+)
+
+var (
+	ErrInvalidPasswordHash   = errors.New("invalid password hash")
+	ErrPasswordHashTooCostly = errors.New("password hash parameters exceed the cost limits")
+)
+
+// HashCostLimits caps the work factors read from stored hashes. Verifying
+// a password against a hash above them fails instead of spending minutes
+// or gigabytes on a crafted hash.
+type HashCostLimits struct {
+	Argon2idMemoryKiB   uint32
+	Argon2idIterations  uint32
+	Argon2idParallelism uint8
+	BcryptCost          int
+	ScryptLogN          uint8
+	ScryptR             int
+	ScryptP             int
+	PBKDF2Iterations    int
+}
+
+// DefaultHashCostLimits leave room above the default parameters of every
+// algorithm and the usual parameters of imported hashes
+var DefaultHashCostLimits = HashCostLimits{
+	Argon2idMemoryKiB:   65536,
+	Argon2idIterations:  10,
+	Argon2idParallelism: 8,
+	BcryptCost:          14,
+	ScryptLogN:          17,
+	ScryptR:             16,
+	ScryptP:             4,
+	PBKDF2Iterations:    1_200_000,
+}
+
+var hashCostLimits = DefaultHashCostLimits
+
+// SetHashCostLimits sets the highest work factors accepted in stored
+// hashes. It is meant to be called once at startup.
+func SetHashCostLimits(l HashCostLimits) {
+	hashCostLimits = l
+}
+
+// PasswordVerifier checks passwords against hashes of one algorithm
+type PasswordVerifier interface {
+	// Verify reports whether password matches a hash of this algorithm,
+	// whatever parameters it was made with, as long as they are within
+	// the cost limits
+	Verify(password, hash string) bool
+	// Identifies reports whether hash was made by this algorithm
+	Identifies(hash string) bool
+	// Check returns ErrInvalidPasswordHash for a malformed hash and
+	// ErrPasswordHashTooCostly for one above the cost limits
+	Check(hash string) error
+}
+
+// PasswordHasher hashes passwords into self-describing strings. argon2id
+// and scrypt use the PHC string format, bcrypt its own modular crypt
+// format, so the algorithm and parameters can be read back from a hash.
+type PasswordHasher interface {
+	PasswordVerifier
+	Hash(password string) (string, error)
+	// Current reports whether hash was made with this hasher's parameters
+	Current(hash string) bool
+}
+
+// Argon2idHasher hashes with argon2id, the default algorithm
+type Argon2idHasher struct {
+	MemoryKiB   uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id follows the OWASP recommendation of 19 MiB, two
+// iterations and one lane
+var DefaultArgon2id = &Argon2idHasher{MemoryKiB: 19456, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// Ensure Argon2idHasher implements PasswordHasher interface
+var _ PasswordHasher = (*Argon2idHasher)(nil)
+
+// Hash returns $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := randomSalt(h.SaltLength)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.MemoryKiB, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.MemoryKiB, h.Iterations, h.Parallelism,
+		phcEncode(salt), phcEncode(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, hash string) bool {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.MemoryKiB, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1
+}
+
+func (h *Argon2idHasher) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Check(hash string) error {
+	_, _, _, err := parseArgon2id(hash)
+	return err
+}
+
+func (h *Argon2idHasher) Current(hash string) bool {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	return params.MemoryKiB == h.MemoryKiB && params.Iterations == h.Iterations && params.Parallelism == h.Parallelism &&
+		uint32(len(salt)) == h.SaltLength && uint32(len(key)) == h.KeyLength
+}
+
+func parseArgon2id(hash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	salt, key, err := phcSaltAndKey(parts[4], parts[5])
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	if params.MemoryKiB > hashCostLimits.Argon2idMemoryKiB || params.Iterations > hashCostLimits.Argon2idIterations ||
+		params.Parallelism > hashCostLimits.Argon2idParallelism {
+		return nil, nil, nil, ErrPasswordHashTooCostly
+	}
+	return params, salt, key, nil
+}
+
+// DefaultBcrypt uses the bcrypt cost the project hashed with before
+// argon2id became the default
+var DefaultBcrypt = &BcryptHasher{Cost: bcrypt.DefaultCost}
+
+// BcryptHasher hashes with bcrypt. bcrypt ignores everything after the
+// first 72 bytes of a password and refuses longer ones.
+type BcryptHasher struct {
+	Cost int
+}
+
+// Ensure BcryptHasher implements PasswordHasher interface
+var _ PasswordHasher = (*BcryptHasher)(nil)
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (h *BcryptHasher) Verify(password, hash string) bool {
+	if h.Check(hash) != nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h *BcryptHasher) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) Check(hash string) error {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return ErrInvalidPasswordHash
+	}
+	if cost > hashCostLimits.BcryptCost {
+		return ErrPasswordHashTooCostly
+	}
+	return nil
+}
+
+func (h *BcryptHasher) Current(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == h.Cost
+}
+
+// ScryptHasher hashes with scrypt. LogN is the base-2 logarithm of the
+// CPU/memory cost N.
+type ScryptHasher struct {
+	LogN       uint8
+	R          int
+	P          int
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultScrypt uses N=2^15, r=8 and p=1
+var DefaultScrypt = &ScryptHasher{LogN: 15, R: 8, P: 1, SaltLength: 16, KeyLength: 32}
+
+// Ensure ScryptHasher implements PasswordHasher interface
+var _ PasswordHasher = (*ScryptHasher)(nil)
+
+// Hash returns $scrypt$ln=<logN>,r=<r>,p=<p>$<salt>$<key>
+func (h *ScryptHasher) Hash(password string) (string, error) {
+	salt, err := randomSalt(h.SaltLength)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.LogN, h.R, h.P, int(h.KeyLength))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", h.LogN, h.R, h.P, phcEncode(salt), phcEncode(key)), nil
+}
+
+func (h *ScryptHasher) Verify(password, hash string) bool {
+	params, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return false
+	}
+	actual, err := scrypt.Key([]byte(password), salt, 1<<params.LogN, params.R, params.P, len(key))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(actual, key) == 1
+}
+
+func (h *ScryptHasher) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$scrypt$")
+}
+
+func (h *ScryptHasher) Check(hash string) error {
+	_, _, _, err := parseScrypt(hash)
+	return err
+}
+
+func (h *ScryptHasher) Current(hash string) bool {
+	params, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return false
+	}
+	return params.LogN == h.LogN && params.R == h.R && params.P == h.P &&
+		uint32(len(salt)) == h.SaltLength && uint32(len(key)) == h.KeyLength
+}
+
+func parseScrypt(hash string) (*ScryptHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != HashScrypt {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	params := &ScryptHasher{}
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P); err != nil {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	salt, key, err := phcSaltAndKey(parts[3], parts[4])
+	if err != nil || params.LogN == 0 || params.LogN > 30 || params.R <= 0 || params.P <= 0 {
+		return nil, nil, nil, ErrInvalidPasswordHash
+	}
+	if params.LogN > hashCostLimits.ScryptLogN || params.R > hashCostLimits.ScryptR || params.P > hashCostLimits.ScryptP {
+		return nil, nil, nil, ErrPasswordHashTooCostly
+	}
+	return params, salt, key, nil
+}
+
+// phcEncode encodes bytes in the unpadded base64 of PHC strings
+func phcEncode(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func phcSaltAndKey(salt, key string) ([]byte, []byte, error) {
+	s, err := base64.RawStdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	k, err := base64.RawStdEncoding.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, nil, ErrInvalidPasswordHash
+	}
+	return s, k, nil
+}
+
+func randomSalt(n uint32) ([]byte, error) {
+	salt := make([]byte, n)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
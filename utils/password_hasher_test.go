@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	hashers := []struct {
+		name   string
+		hasher PasswordHasher
+		prefix string
+	}{
+		{name: "argon2id", hasher: &Argon2idHasher{MemoryKiB: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", hasher: &BcryptHasher{Cost: 4}, prefix: "$2a$04$"},
+		{name: "scrypt", hasher: &ScryptHasher{LogN: 10, R: 8, P: 1, SaltLength: 16, KeyLength: 32}, prefix: "$scrypt$ln=10,r=8,p=1$"},
+	}
+
+	for _, tt := range hashers {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("password123")
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+
+			assert.True(t, tt.hasher.Identifies(hash))
+			assert.True(t, tt.hasher.Current(hash))
+			assert.True(t, tt.hasher.Verify("password123", hash))
+			assert.False(t, tt.hasher.Verify("wrongpassword", hash))
+			// Stored hashes of every algorithm verify through CompareHash
+			assert.True(t, CompareHash("password123", hash))
+
+			for _, other := range hashers {
+				if other.name != tt.name {
+					assert.False(t, other.hasher.Identifies(hash))
+				}
+			}
+		})
+	}
+}
+
+func TestPasswordHashers_Current(t *testing.T) {
+	argon := &Argon2idHasher{MemoryKiB: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hash, err := argon.Hash("password123")
+	assert.NoError(t, err)
+
+	stronger := *argon
+	stronger.Iterations = 2
+	assert.False(t, stronger.Current(hash))
+	// Verification reads the parameters from the hash
+	assert.True(t, stronger.Verify("password123", hash))
+
+	assert.False(t, (&BcryptHasher{Cost: 12}).Current("$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m"))
+	assert.False(t, argon.Verify("password123", "$argon2id$v=19$m=1024,t=1,p=1$broken"))
+}
+
+func TestHashCostLimits(t *testing.T) {
+	defer SetHashCostLimits(hashCostLimits)
+
+	argon := &Argon2idHasher{MemoryKiB: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hash, err := argon.Hash("password123")
+	assert.NoError(t, err)
+	assert.NoError(t, CheckHash(hash))
+	assert.True(t, CompareHash("password123", hash))
+
+	limits := DefaultHashCostLimits
+	limits.Argon2idMemoryKiB = 1024
+	SetHashCostLimits(limits)
+	assert.ErrorIs(t, CheckHash(hash), ErrPasswordHashTooCostly)
+	// Hashes above the limits are not even computed
+	assert.False(t, CompareHash("password123", hash))
+
+	assert.ErrorIs(t, CheckHash("$2a$31$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m"), ErrPasswordHashTooCostly)
+	assert.ErrorIs(t, CheckHash("$scrypt$ln=30,r=8,p=1$c29tZXNhbHQ$a2V5"), ErrPasswordHashTooCostly)
+	assert.ErrorIs(t, CheckHash("pbkdf2_sha256$5000000$somesalt$9uGciTK0YsFs8IX66F2YGyx+F/21bBVCbHT6pTGREzA="), ErrPasswordHashTooCostly)
+	assert.ErrorIs(t, CheckHash("$argon2id$v=19$m=1024,t=1,p=1$broken"), ErrInvalidPasswordHash)
+	assert.ErrorIs(t, CheckHash("plaintext"), ErrInvalidPasswordHash)
+}
+
+func TestCompareDummyHash(t *testing.T) {
+	defer SetPasswordHasher(passwordHasher)
+	argon := &Argon2idHasher{MemoryKiB: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	SetPasswordHasher(argon)
+
+	CompareDummyHash("password123")
+	assert.True(t, argon.Current(dummyHash))
+
+	// The dummy hash follows the configured hasher, so it costs as much as
+	// the hashes of new passwords
+	bcryptHasher := &BcryptHasher{Cost: 4}
+	SetPasswordHasher(bcryptHasher)
+	CompareDummyHash("password123")
+	assert.True(t, bcryptHasher.Current(dummyHash))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	defer SetPasswordHasher(passwordHasher)
+	SetPasswordHasher(&Argon2idHasher{MemoryKiB: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	current, err := GenerateHash("password123")
+	assert.NoError(t, err)
+	assert.False(t, NeedsRehash(current))
+	assert.True(t, NeedsRehash("$2a$10$vnz04c9pQOhKP3lc7p4LLOZYHapMZBdodhQdv5TYw/4gL3.xpGv4m"))
+
+	SetPasswordHasher(&Argon2idHasher{MemoryKiB: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	assert.True(t, NeedsRehash(current))
+	assert.True(t, CompareHash("password123", current))
+}
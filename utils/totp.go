@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238). They are the defaults of every common
+// authenticator app, so the provisioning URI states them only for clarity.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// TOTPSkew is how many periods before and after the current one are
+	// accepted to tolerate clock drift between the server and the device
+	TOTPSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 encoded 160-bit secret
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI that authenticator apps
+// import, usually by scanning it as a QR code
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCode returns the code of the time step containing t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpStep(t)), nil
+}
+
+// ValidateTOTP checks a code against the time steps around t and returns
+// the step it matched. Callers should reject steps that are not newer than
+// the last accepted one so a code cannot be replayed.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != TOTPDigits {
+		return 0, false
+	}
+	current := totpStep(t)
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCode returns a random code formatted as xxxxx-xxxxx
+func GenerateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// NormalizeRecoveryCode strips the separators and case users tend to
+// change when typing a recovery code
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod.Seconds())
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+// hotp computes an HOTP value (RFC 4226) for a counter
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
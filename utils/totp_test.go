@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 appendix B
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists 8-digit codes; a 6-digit code is their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, time.Unix(tt.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := TOTPCode(rfc6238Secret, now)
+	assert.NoError(t, err)
+
+	step, ok := ValidateTOTP(rfc6238Secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+
+	// One period of clock drift is tolerated, two are not
+	_, ok = ValidateTOTP(rfc6238Secret, code, now.Add(TOTPPeriod))
+	assert.True(t, ok)
+	_, ok = ValidateTOTP(rfc6238Secret, code, now.Add(3*TOTPPeriod))
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(rfc6238Secret, "000000", now)
+	assert.False(t, ok)
+	_, ok = ValidateTOTP(rfc6238Secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	uri, err := url.Parse(TOTPProvisioningURI("User Management API", "test@example.com", secret))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/User Management API:test@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "User Management API", uri.Query().Get("issuer"))
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := GenerateRecoveryCode()
+	assert.NoError(t, err)
+	assert.Len(t, code, 11)
+	assert.Equal(t, 5, strings.Index(code, "-"))
+
+	assert.Equal(t, strings.ReplaceAll(code, "-", ""), NormalizeRecoveryCode(" "+strings.ToUpper(code)+" "))
+}
@@ -0,0 +1,65 @@
+package utils
+
+import "strings"
+
+// uaRule maps a user agent token to a readable name. Rules are checked in
+// order, so tokens that other agents imitate (Safari, Chrome) come last.
+type uaRule struct {
+	token string
+	name  string
+}
+
+var browserRules = []uaRule{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+	{"okhttp/", "Android app"},
+	{"Go-http-client/", "Go client"},
+	{"python-requests/", "Python client"},
+}
+
+var platformRules = []uaRule{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName returns a short description of the client behind a user
+// agent, such as "Chrome on Windows", for users to recognise their
+// sessions by
+func DeviceName(userAgent string) string {
+	browser := matchUARule(browserRules, userAgent)
+	platform := matchUARule(platformRules, userAgent)
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchUARule(rules []uaRule, userAgent string) string {
+	for _, rule := range rules {
+		if strings.Contains(userAgent, rule.token) {
+			return rule.name
+		}
+	}
+	return ""
+}